@@ -3,12 +3,14 @@ package ast
 import (
 	"bytes"
 	"github.com/lxdlam/monkey-plus/token"
+	"math/big"
 	"strings"
 )
 
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position
 }
 
 type Statement interface {
@@ -23,6 +25,8 @@ type Expression interface {
 
 type Program struct {
 	Statements []Statement
+	// File is the path of the source file, empty if the program is not loaded from a file
+	File string
 }
 
 func (p *Program) TokenLiteral() string {
@@ -33,11 +37,28 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	} else {
+		return token.Position{Line: 1, Column: 1}
+	}
+}
+
 func (p *Program) String() string {
+	return joinStatements(p.Statements)
+}
+
+// joinStatements concatenates the statements, the expression statements are separated by `;`
+// so the result parses to the same statements
+func joinStatements(statements []Statement) string {
 	var out bytes.Buffer
 
-	for _, s := range p.Statements {
+	for i, s := range statements {
 		out.WriteString(s.String())
+		if _, ok := s.(*ExpressionStatement); ok && i != len(statements)-1 {
+			out.WriteString(";")
+		}
 	}
 
 	return out.String()
@@ -46,17 +67,23 @@ func (p *Program) String() string {
 type LetStatement struct {
 	Token token.Token
 	Name  *Identifier
+	// Type is the optional annotation of the binding
+	Type  TypeExpression
 	Value Expression
 }
 
 func (ls *LetStatement) statementNode()       {}
 func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal }
+func (ls *LetStatement) Pos() token.Position  { return ls.Token.Pos }
 
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(ls.TokenLiteral() + " ")
 	out.WriteString(ls.Name.String())
+	if ls.Type != nil {
+		out.WriteString(": " + ls.Type.String())
+	}
 	out.WriteString(" = ")
 
 	if ls.Value != nil {
@@ -71,10 +98,20 @@ func (ls *LetStatement) String() string {
 type Identifier struct {
 	Token token.Token
 	Value string
+	// Slot locates the binding if it is local to a function, nil if the name is looked up by name
+	Slot *Slot
+}
+
+// Slot is the location of a binding in the environments of the function calls: the binding is
+// at Index in the environment Depth calls out from the current one
+type Slot struct {
+	Depth int
+	Index int
 }
 
 func (i *Identifier) expressionNode()      {}
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
+func (i *Identifier) Pos() token.Position  { return i.Token.Pos }
 func (i *Identifier) String() string       { return i.Value }
 
 type ReturnStatement struct {
@@ -84,6 +121,7 @@ type ReturnStatement struct {
 
 func (rs *ReturnStatement) statementNode()       {}
 func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
+func (rs *ReturnStatement) Pos() token.Position  { return rs.Token.Pos }
 
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
@@ -105,6 +143,7 @@ type ExpressionStatement struct {
 
 func (es *ExpressionStatement) statementNode()       {}
 func (es *ExpressionStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExpressionStatement) Pos() token.Position  { return es.Token.Pos }
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
 		return es.Expression.String()
@@ -120,8 +159,31 @@ type IntegerLiteral struct {
 
 func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
+func (il *IntegerLiteral) Pos() token.Position  { return il.Token.Pos }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
+// BigIntLiteral is an integer with the `n` suffix or out of the range of int64, its token literal
+// keeps the suffix if any
+type BigIntLiteral struct {
+	Token token.Token
+	Value *big.Int
+}
+
+func (bl *BigIntLiteral) expressionNode()      {}
+func (bl *BigIntLiteral) TokenLiteral() string { return bl.Token.Literal }
+func (bl *BigIntLiteral) Pos() token.Position  { return bl.Token.Pos }
+func (bl *BigIntLiteral) String() string       { return bl.Token.Literal }
+
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) Pos() token.Position  { return fl.Token.Pos }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+
 type PrefixExpression struct {
 	Token    token.Token
 	Operator string
@@ -130,6 +192,7 @@ type PrefixExpression struct {
 
 func (pe *PrefixExpression) expressionNode()      {}
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PrefixExpression) Pos() token.Position  { return pe.Token.Pos }
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -150,6 +213,7 @@ type InfixExpression struct {
 
 func (ie *InfixExpression) expressionNode()      {}
 func (ie *InfixExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *InfixExpression) Pos() token.Position  { return ie.Token.Pos }
 func (ie *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -169,23 +233,21 @@ type Boolean struct {
 
 func (b *Boolean) expressionNode()      {}
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
+func (b *Boolean) Pos() token.Position  { return b.Token.Pos }
 func (b *Boolean) String() string       { return b.Token.Literal }
 
 type BlockStatement struct {
 	Token      token.Token
 	Statements []Statement
+	// End is the position of the closing brace
+	End token.Position
 }
 
 func (bs *BlockStatement) statementNode()       {}
 func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) Pos() token.Position  { return bs.Token.Pos }
 func (bs *BlockStatement) String() string {
-	var out bytes.Buffer
-
-	for _, s := range bs.Statements {
-		out.WriteString(s.String())
-	}
-
-	return out.String()
+	return "{ " + joinStatements(bs.Statements) + " }"
 }
 
 type IfExpression struct {
@@ -197,16 +259,17 @@ type IfExpression struct {
 
 func (ie *IfExpression) expressionNode()      {}
 func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IfExpression) Pos() token.Position  { return ie.Token.Pos }
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
-	out.WriteString("if")
+	out.WriteString("if (")
 	out.WriteString(ie.Condition.String())
-	out.WriteString(" ")
+	out.WriteString(") ")
 	out.WriteString(ie.Consequence.String())
 
 	if ie.Alternative != nil {
-		out.WriteString("else ")
+		out.WriteString(" else ")
 		out.WriteString(ie.Alternative.String())
 	}
 
@@ -216,24 +279,49 @@ func (ie *IfExpression) String() string {
 type FunctionLiteral struct {
 	Token      token.Token
 	Parameters []*Identifier
-	Body       *BlockStatement
+	// ParameterTypes holds the optional annotations of the parameters, it is empty or as long as Parameters
+	ParameterTypes []TypeExpression
+	ReturnType     TypeExpression
+	Body           *BlockStatement
+	// Name is the name of the binding if the function is defined by a let statement
+	Name string
+	// Locals holds the names of the slots of the environments of the calls
+	Locals []string
+}
+
+// ParameterType returns the annotation of the i-th parameter, nil if it has none
+func (fl *FunctionLiteral) ParameterType(i int) TypeExpression {
+	if i < len(fl.ParameterTypes) {
+		return fl.ParameterTypes[i]
+	}
+
+	return nil
 }
 
 func (fl *FunctionLiteral) expressionNode()      {}
 func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) Pos() token.Position  { return fl.Token.Pos }
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
 	params := []string{}
 
-	for _, p := range fl.Parameters {
-		params = append(params, p.String())
+	for i, p := range fl.Parameters {
+		if t := fl.ParameterType(i); t != nil {
+			params = append(params, p.String()+": "+t.String())
+		} else {
+			params = append(params, p.String())
+		}
 	}
 
 	out.WriteString(fl.TokenLiteral())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(")")
+	if fl.ReturnType != nil {
+		out.WriteString(" -> " + fl.ReturnType.String())
+	}
+	out.WriteString(" ")
 	out.WriteString(fl.Body.String())
 
 	return out.String()
@@ -243,10 +331,13 @@ type CallExpression struct {
 	Token     token.Token
 	Function  Expression
 	Arguments []Expression
+	// Tail is set if the call is in tail position in the body of a function literal
+	Tail bool
 }
 
 func (ce *CallExpression) expressionNode()      {}
 func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) Pos() token.Position  { return ce.Token.Pos }
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 
@@ -270,7 +361,35 @@ type StringLiteral struct {
 
 func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
-func (sl *StringLiteral) String() string       { return sl.Token.Literal }
+func (sl *StringLiteral) Pos() token.Position  { return sl.Token.Pos }
+func (sl *StringLiteral) String() string       { return "\"" + sl.Token.Literal + "\"" }
+
+// InterpolatedString is a string literal with embedded expressions like "Hello ${name}". Parts are
+// the raw texts as StringLiterals and the expressions, in source order.
+type InterpolatedString struct {
+	Token token.Token
+	Parts []Expression
+}
+
+func (is *InterpolatedString) expressionNode()      {}
+func (is *InterpolatedString) TokenLiteral() string { return is.Token.Literal }
+func (is *InterpolatedString) Pos() token.Position  { return is.Token.Pos }
+
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("\"")
+	for _, part := range is.Parts {
+		if text, ok := part.(*StringLiteral); ok {
+			out.WriteString(text.Token.Literal)
+		} else {
+			out.WriteString("${" + part.String() + "}")
+		}
+	}
+	out.WriteString("\"")
+
+	return out.String()
+}
 
 type ArrayLiteral struct {
 	Token    token.Token
@@ -279,6 +398,7 @@ type ArrayLiteral struct {
 
 func (al *ArrayLiteral) expressionNode()      {}
 func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) Pos() token.Position  { return al.Token.Pos }
 func (al *ArrayLiteral) String() string {
 	var out bytes.Buffer
 
@@ -302,6 +422,7 @@ type IndexExpression struct {
 
 func (ie *IndexExpression) expressionNode()      {}
 func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Pos() token.Position  { return ie.Token.Pos }
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 
@@ -317,16 +438,25 @@ func (ie *IndexExpression) String() string {
 type HashLiteral struct {
 	Token token.Token
 	Pairs map[Expression]Expression
+	// Keys holds the keys of Pairs in source order
+	Keys []Expression
 }
 
 func (hl *HashLiteral) expressionNode()      {}
 func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) Pos() token.Position  { return hl.Token.Pos }
 func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	if len(hl.Keys) == len(hl.Pairs) {
+		for _, key := range hl.Keys {
+			pairs = append(pairs, key.String()+":"+hl.Pairs[key].String())
+		}
+	} else {
+		for key, value := range hl.Pairs {
+			pairs = append(pairs, key.String()+":"+value.String())
+		}
 	}
 
 	out.WriteString("{")
@@ -335,3 +465,69 @@ func (hl *HashLiteral) String() string {
 
 	return out.String()
 }
+
+// TypeExpression is an optional type annotation, the evaluator ignores them
+type TypeExpression interface {
+	Node
+	typeNode()
+}
+
+// NamedType is a basic type like `int`, the names are checked by the type checker
+type NamedType struct {
+	Token token.Token
+	Name  string
+}
+
+func (nt *NamedType) typeNode()            {}
+func (nt *NamedType) TokenLiteral() string { return nt.Token.Literal }
+func (nt *NamedType) Pos() token.Position  { return nt.Token.Pos }
+func (nt *NamedType) String() string       { return nt.Name }
+
+type ArrayType struct {
+	Token   token.Token
+	Element TypeExpression
+}
+
+func (at *ArrayType) typeNode()            {}
+func (at *ArrayType) TokenLiteral() string { return at.Token.Literal }
+func (at *ArrayType) Pos() token.Position  { return at.Token.Pos }
+func (at *ArrayType) String() string       { return "[" + at.Element.String() + "]" }
+
+type HashType struct {
+	Token token.Token
+	Key   TypeExpression
+	Value TypeExpression
+}
+
+func (ht *HashType) typeNode()            {}
+func (ht *HashType) TokenLiteral() string { return ht.Token.Literal }
+func (ht *HashType) Pos() token.Position  { return ht.Token.Pos }
+func (ht *HashType) String() string       { return "{" + ht.Key.String() + ": " + ht.Value.String() + "}" }
+
+type FunctionType struct {
+	Token      token.Token
+	Parameters []TypeExpression
+	// Return is nil if the return type is not annotated
+	Return TypeExpression
+}
+
+func (ft *FunctionType) typeNode()            {}
+func (ft *FunctionType) TokenLiteral() string { return ft.Token.Literal }
+func (ft *FunctionType) Pos() token.Position  { return ft.Token.Pos }
+func (ft *FunctionType) String() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range ft.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString("fn(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(")")
+	if ft.Return != nil {
+		out.WriteString(" -> " + ft.Return.String())
+	}
+
+	return out.String()
+}
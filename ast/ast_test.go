@@ -2,6 +2,7 @@ package ast
 
 import (
 	"github.com/lxdlam/monkey-plus/token"
+	"strings"
 	"testing"
 )
 
@@ -35,3 +36,40 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String() wrong. got=%q", program.String())
 	}
 }
+
+func TestInspect(t *testing.T) {
+	program := &Program{
+		Statements: []Statement{
+			&ExpressionStatement{
+				Expression: &InfixExpression{
+					Left:     &Identifier{Value: "a"},
+					Operator: "+",
+					Right: &CallExpression{
+						Function:  &Identifier{Value: "f"},
+						Arguments: []Expression{&IntegerLiteral{Value: 1}},
+					},
+				},
+			},
+			&LetStatement{Name: &Identifier{Value: "b"}},
+		},
+	}
+
+	var identifiers []string
+	count := 0
+	Inspect(program, func(node Node) bool {
+		count++
+		if ident, ok := node.(*Identifier); ok {
+			identifiers = append(identifiers, ident.Value)
+		}
+		_, isCall := node.(*CallExpression)
+		return !isCall
+	})
+
+	if count != 7 {
+		t.Errorf("wrong number of visited nodes. got=%d, want=7", count)
+	}
+
+	if strings.Join(identifiers, ",") != "a,b" {
+		t.Errorf("wrong identifiers. got=%v", identifiers)
+	}
+}
@@ -0,0 +1,140 @@
+package ast
+
+// Inspect traverses the AST in depth-first order. It starts by calling f(node), if f returns true,
+// Inspect invokes f recursively for each of the non-nil children of node.
+func Inspect(node Node, f func(Node) bool) {
+	if isNil(node) || !f(node) {
+		return
+	}
+
+	switch node := node.(type) {
+	case *Program:
+		for _, s := range node.Statements {
+			Inspect(s, f)
+		}
+	case *LetStatement:
+		Inspect(node.Name, f)
+		Inspect(node.Type, f)
+		Inspect(node.Value, f)
+	case *ReturnStatement:
+		Inspect(node.ReturnValue, f)
+	case *ExpressionStatement:
+		Inspect(node.Expression, f)
+	case *BlockStatement:
+		for _, s := range node.Statements {
+			Inspect(s, f)
+		}
+	case *PrefixExpression:
+		Inspect(node.Right, f)
+	case *InfixExpression:
+		Inspect(node.Left, f)
+		Inspect(node.Right, f)
+	case *IfExpression:
+		Inspect(node.Condition, f)
+		Inspect(node.Consequence, f)
+		Inspect(node.Alternative, f)
+	case *FunctionLiteral:
+		for i, p := range node.Parameters {
+			Inspect(p, f)
+			Inspect(node.ParameterType(i), f)
+		}
+		Inspect(node.ReturnType, f)
+		Inspect(node.Body, f)
+	case *CallExpression:
+		Inspect(node.Function, f)
+		for _, a := range node.Arguments {
+			Inspect(a, f)
+		}
+	case *InterpolatedString:
+		for _, part := range node.Parts {
+			Inspect(part, f)
+		}
+	case *ArrayLiteral:
+		for _, el := range node.Elements {
+			Inspect(el, f)
+		}
+	case *IndexExpression:
+		Inspect(node.Left, f)
+		Inspect(node.Index, f)
+	case *ArrayType:
+		Inspect(node.Element, f)
+	case *HashType:
+		Inspect(node.Key, f)
+		Inspect(node.Value, f)
+	case *FunctionType:
+		for _, p := range node.Parameters {
+			Inspect(p, f)
+		}
+		Inspect(node.Return, f)
+	case *HashLiteral:
+		if len(node.Keys) == len(node.Pairs) {
+			for _, key := range node.Keys {
+				Inspect(key, f)
+				Inspect(node.Pairs[key], f)
+			}
+		} else {
+			for key, value := range node.Pairs {
+				Inspect(key, f)
+				Inspect(value, f)
+			}
+		}
+	}
+}
+
+// The parser may leave typed nil nodes in the tree when there are parse errors
+func isNil(node Node) bool {
+	switch node := node.(type) {
+	case nil:
+		return true
+	case *Program:
+		return node == nil
+	case *LetStatement:
+		return node == nil
+	case *ReturnStatement:
+		return node == nil
+	case *ExpressionStatement:
+		return node == nil
+	case *BlockStatement:
+		return node == nil
+	case *Identifier:
+		return node == nil
+	case *IntegerLiteral:
+		return node == nil
+	case *FloatLiteral:
+		return node == nil
+	case *BigIntLiteral:
+		return node == nil
+	case *Boolean:
+		return node == nil
+	case *StringLiteral:
+		return node == nil
+	case *InterpolatedString:
+		return node == nil
+	case *PrefixExpression:
+		return node == nil
+	case *InfixExpression:
+		return node == nil
+	case *IfExpression:
+		return node == nil
+	case *FunctionLiteral:
+		return node == nil
+	case *CallExpression:
+		return node == nil
+	case *ArrayLiteral:
+		return node == nil
+	case *IndexExpression:
+		return node == nil
+	case *HashLiteral:
+		return node == nil
+	case *NamedType:
+		return node == nil
+	case *ArrayType:
+		return node == nil
+	case *HashType:
+		return node == nil
+	case *FunctionType:
+		return node == nil
+	default:
+		return false
+	}
+}
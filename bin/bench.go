@@ -0,0 +1,78 @@
+package bin
+
+import (
+	"flag"
+	"fmt"
+	"github.com/lxdlam/monkey-plus/tester"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func Bench(args []string) int {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	run := flags.String("run", "", "run only the benchmarks matching the regular expression")
+	benchtime := flags.String("benchtime", "1s", "run every benchmark for the duration, or the number of iterations like 100x")
+	count := flags.Int("count", 1, "measure every benchmark the number of times")
+	warmup := flags.Int("warmup", 1, "call every benchmark the number of times before measuring")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	bencher := tester.NewBencher(os.Stdout)
+	bencher.Context = ctx
+	bencher.Count = *count
+	bencher.Warmup = *warmup
+
+	if *run != "" {
+		filter, err := regexp.Compile(*run)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -run regular expression: %s\n", err)
+			return 2
+		}
+		bencher.Filter = filter
+	}
+
+	if strings.HasSuffix(*benchtime, "x") {
+		n, err := strconv.Atoi(strings.TrimSuffix(*benchtime, "x"))
+		if err != nil || n <= 0 {
+			fmt.Fprintf(os.Stderr, "invalid -benchtime: %s\n", *benchtime)
+			return 2
+		}
+		bencher.Iterations = n
+	} else {
+		d, err := time.ParseDuration(*benchtime)
+		if err != nil || d <= 0 {
+			fmt.Fprintf(os.Stderr, "invalid -benchtime: %s\n", *benchtime)
+			return 2
+		}
+		bencher.Time = d
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, err := tester.Discover(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if len(files) == 0 {
+		fmt.Println("no test files found")
+		return 0
+	}
+
+	if !bencher.Run(files) {
+		return 1
+	}
+
+	return 0
+}
@@ -3,20 +3,64 @@ package bin
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/lxdlam/monkey-plus/evaluator"
+	"github.com/lxdlam/monkey-plus/format"
 	"github.com/lxdlam/monkey-plus/lexer"
 	"github.com/lxdlam/monkey-plus/object"
+	"github.com/lxdlam/monkey-plus/optimizer"
 	"github.com/lxdlam/monkey-plus/parser"
+	"github.com/lxdlam/monkey-plus/profiler"
 	"io"
 	"log"
 	"os"
 	"strings"
 )
 
-func Run(in io.Reader, out io.Writer) {
+type Options struct {
+	// File is the path of the running script, empty if running a code snippet
+	File string
+	// ProfilePath is where the pprof profile will be written to if set
+	ProfilePath string
+	// Optimize rewrites the program with the optimizer before running it
+	Optimize bool
+	// PrintOptimized prints the optimized program instead of running it
+	PrintOptimized bool
+	// MaxSteps stops the script after this many statements, 0 for no limit
+	MaxSteps int
+	// CheckOverflow makes the integer overflows an error instead of promoting to big integers
+	CheckOverflow bool
+	// Permissions limit the files the script can access, every file is allowed if nil
+	Permissions *object.Permissions
+	// Stdin is where the script reads its input from, os.Stdin if not set
+	Stdin io.Reader
+	// Seed makes the random builtins reproducible, they are seeded from the time if nil
+	Seed *int64
+	// Context stops the script once it is canceled, an interrupt cancels it if not set
+	Context context.Context
+}
+
+// Run reads the whole program from in and runs it, it reports whether the program is parsed and
+// does not end with an error
+func Run(in io.Reader, out io.Writer, opts Options) bool {
 	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
+	runtime := &object.Runtime{
+		MaxSteps:      opts.MaxSteps,
+		CheckOverflow: opts.CheckOverflow,
+		Permissions:   opts.Permissions,
+		Stdin:         opts.Stdin,
+	}
+	if opts.Seed != nil {
+		runtime.Seed(*opts.Seed)
+	}
+	runtime.Context = opts.Context
+	if runtime.Context == nil {
+		ctx, stop := interruptContext()
+		defer stop()
+		runtime.Context = ctx
+	}
+	env := object.NewEnvironmentWithRuntime(runtime)
 
 	var codes bytes.Buffer
 
@@ -33,6 +77,7 @@ func Run(in io.Reader, out io.Writer) {
 	p := parser.New(l)
 
 	program := p.ParseProgram()
+	program.File = opts.File
 
 	errorLength := len(p.Errors())
 	if errorLength != 0 {
@@ -44,19 +89,47 @@ func Run(in io.Reader, out io.Writer) {
 		if err != nil {
 			log.Fatalf(err.Error())
 		}
-		return
+		return false
+	}
+
+	if opts.Optimize || opts.PrintOptimized {
+		optimizer.Optimize(program)
+	}
+
+	if opts.PrintOptimized {
+		if _, err := io.WriteString(out, format.Program(program, nil)); err != nil {
+			log.Fatalf(err.Error())
+		}
+		return true
+	}
+
+	var prof *profiler.Profiler
+	if opts.ProfilePath != "" {
+		prof = profiler.New()
+		runtime.AddHook(prof)
 	}
 
 	evaluated := evaluator.Eval(program, env)
+
+	if prof != nil {
+		prof.Stop()
+		if err := writeProfile(opts.ProfilePath, prof.WriteProfile); err != nil {
+			log.Fatalf(err.Error())
+		}
+	}
+
 	if evaluated != nil && evaluated.Inspect() != "null" {
 		_, err := io.WriteString(out, evaluated.Inspect())
 		if err != nil {
 			log.Fatalf(err.Error())
 		}
 	}
+
+	_, failed := evaluated.(*object.Error)
+	return !failed
 }
 
-func RunFile(path string) {
+func RunFile(path string, opts Options) bool {
 	file, err := os.Open(path)
 	if err != nil {
 		log.Fatalf(err.Error())
@@ -64,9 +137,10 @@ func RunFile(path string) {
 
 	defer file.Close()
 
-	Run(file, os.Stdout)
+	opts.File = path
+	return Run(file, os.Stdout, opts)
 }
 
-func RunCode(code string) {
-	Run(strings.NewReader(code), os.Stdout)
+func RunCode(code string, opts Options) bool {
+	return Run(strings.NewReader(code), os.Stdout, opts)
 }
@@ -0,0 +1,21 @@
+package bin
+
+import (
+	"fmt"
+	"github.com/lxdlam/monkey-plus/dap"
+	"os"
+)
+
+func DAP(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "usage: dap")
+		return 2
+	}
+
+	if err := dap.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,56 @@
+package bin
+
+import (
+	"flag"
+	"fmt"
+	"github.com/lxdlam/monkey-plus/debugger"
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/object"
+	"github.com/lxdlam/monkey-plus/parser"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+func Debug(args []string) int {
+	flags := flag.NewFlagSet("debug", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: debug <file>")
+		return 2
+	}
+
+	path := flags.Arg(0)
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	p := parser.New(lexer.New(string(source)))
+	program := p.ParseProgram()
+	program.File = path
+
+	if len(p.Errors()) != 0 {
+		fmt.Fprintf(os.Stderr, "parse error: %s\n", strings.Join(p.Errors(), "\n"))
+		return 1
+	}
+
+	fmt.Println("Monkey debugger, type help for the commands")
+	result, err := debugger.NewConsole(os.Stdin, os.Stdout).Run(program)
+	if err != nil {
+		return 1
+	}
+
+	fmt.Println("program exited")
+
+	if result != nil && result.Type() == object.ERROR_OBJ {
+		fmt.Println(result.Inspect())
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,77 @@
+package bin
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/lxdlam/monkey-plus/lint"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+func Lint(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the issues as a JSON array")
+	enable := flags.String("enable", "", "comma separated rules to run, all the rules by default: "+strings.Join(lint.Rules, ","))
+	disable := flags.String("disable", "", "comma separated rules to skip")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	config := lint.NewConfig()
+	if *enable != "" {
+		if err := config.Only(strings.Split(*enable, ",")...); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	if *disable != "" {
+		if err := config.Disable(strings.Split(*disable, ",")...); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, err := lint.Discover(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	issues := []lint.Issue{}
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		issues = append(issues, lint.Source(file, string(src), config)...)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(issues); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	} else {
+		for _, issue := range issues {
+			fmt.Println(issue.String())
+		}
+	}
+
+	if len(issues) != 0 {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,21 @@
+package bin
+
+import (
+	"fmt"
+	"github.com/lxdlam/monkey-plus/lsp"
+	"os"
+)
+
+func LSP(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "usage: lsp")
+		return 2
+	}
+
+	if err := lsp.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,97 @@
+package bin
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/lxdlam/monkey-plus/object"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// paths is a flag that can be repeated
+type paths []string
+
+func (p *paths) String() string { return strings.Join(*p, ",") }
+
+func (p *paths) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
+// RunFlags registers the flags of running a script, the returned function makes the options once
+// the flags are parsed
+func RunFlags(flags *flag.FlagSet) func() (Options, error) {
+	var opts Options
+	flags.StringVar(&opts.ProfilePath, "profile", "", "write a pprof profile of the Monkey functions to the file")
+	flags.BoolVar(&opts.Optimize, "O", false, "fold the constants and prune the dead branches before running")
+	flags.BoolVar(&opts.PrintOptimized, "print-optimized", false, "print the optimized program instead of running it")
+	flags.IntVar(&opts.MaxSteps, "max-steps", 0, "stop the script after the number of statements, 0 for no limit")
+	flags.BoolVar(&opts.CheckOverflow, "check-overflow", false, "fail on the integer overflows instead of promoting to big integers")
+
+	seed := flags.Int64("seed", 0, "seed the random builtins, so the runs are reproducible")
+
+	var allowRead, allowWrite paths
+	var sandbox bool
+	flags.Var(&allowRead, "allow-read", "allow the script to read the files under the directory, can be repeated")
+	flags.Var(&allowWrite, "allow-write", "allow the script to read and write the files under the directory, can be repeated")
+	flags.BoolVar(&sandbox, "sandbox", false, "deny the access to the files not allowed by -allow-read or -allow-write")
+
+	return func() (Options, error) {
+		flags.Visit(func(f *flag.Flag) {
+			if f.Name == "seed" {
+				opts.Seed = seed
+			}
+		})
+
+		if sandbox || len(allowRead) > 0 || len(allowWrite) > 0 {
+			permissions, err := object.NewPermissions(allowRead, allowWrite)
+			if err != nil {
+				return opts, err
+			}
+			opts.Permissions = permissions
+		}
+
+		return opts, nil
+	}
+}
+
+// interruptContext is canceled by the first interrupt, which stops the script with an error. The
+// handler is removed then, so the next interrupt kills a script blocked e.g. on reading the input.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	return ctx, stop
+}
+
+// RunScript runs a script file, the standard input is left to the script. It fails if the script
+// cannot be parsed or ends with an error.
+func RunScript(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	options := RunFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: monkey run [flags] script.mp")
+		return 2
+	}
+
+	opts, err := options()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if !RunFile(flags.Arg(0), opts) {
+		return 1
+	}
+
+	return 0
+}
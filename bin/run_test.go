@@ -0,0 +1,50 @@
+package bin
+
+import (
+	"bytes"
+	"context"
+	"github.com/lxdlam/monkey-plus/evaluator"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunCanceled(t *testing.T) {
+	evaluator.InitBuiltins()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var out bytes.Buffer
+	start := time.Now()
+	if Run(strings.NewReader("sleep(60000); 1"), &out, Options{Context: ctx}) {
+		t.Errorf("a canceled script should fail")
+	}
+
+	if out.String() != "ERROR: sleep canceled: context deadline exceeded" {
+		t.Errorf("wrong output. got=%q", out.String())
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("the script should stop once canceled, took %s", elapsed)
+	}
+}
+
+func TestInterruptContext(t *testing.T) {
+	ctx, stop := interruptContext()
+	defer stop()
+
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := process.Signal(os.Interrupt); err != nil {
+		t.Skipf("cannot interrupt the process: %s", err)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("the interrupt should cancel the context")
+	}
+}
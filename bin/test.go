@@ -0,0 +1,99 @@
+package bin
+
+import (
+	"flag"
+	"fmt"
+	"github.com/lxdlam/monkey-plus/coverage"
+	"github.com/lxdlam/monkey-plus/tester"
+	"io"
+	"os"
+	"regexp"
+)
+
+func Test(args []string) int {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	run := flags.String("run", "", "run only the tests matching the regular expression")
+	cover := flags.Bool("cover", false, "report the statement coverage of every file")
+	coverProfile := flags.String("coverprofile", "", "write a coverage profile in go cover format to the file")
+	lcov := flags.String("lcov", "", "write a coverage profile in LCOV format to the file")
+	seed := flags.Int64("seed", 0, "seed the random builtins of every test, so the runs are reproducible")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	runner := tester.New(os.Stdout)
+	runner.Context = ctx
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			runner.Seed = seed
+		}
+	})
+	if *run != "" {
+		filter, err := regexp.Compile(*run)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -run regular expression: %s\n", err)
+			return 2
+		}
+		runner.Filter = filter
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, err := tester.Discover(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if len(files) == 0 {
+		fmt.Println("no test files found")
+		return 0
+	}
+
+	if *cover || *coverProfile != "" || *lcov != "" {
+		runner.Coverage = coverage.New()
+	}
+
+	passed := runner.Run(files)
+
+	if runner.Coverage != nil {
+		runner.ReportCoverage()
+
+		if *coverProfile != "" {
+			if err := writeProfile(*coverProfile, runner.Coverage.WriteGoCover); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+		}
+
+		if *lcov != "" {
+			if err := writeProfile(*lcov, runner.Coverage.WriteLCOV); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+		}
+	}
+
+	if !passed {
+		return 1
+	}
+
+	return 0
+}
+
+func writeProfile(path string, write func(w io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	return write(file)
+}
@@ -0,0 +1,61 @@
+package bin
+
+import (
+	"flag"
+	"fmt"
+	"github.com/lxdlam/monkey-plus/evaluator"
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/lint"
+	"github.com/lxdlam/monkey-plus/parser"
+	"github.com/lxdlam/monkey-plus/typecheck"
+	"io/ioutil"
+	"os"
+)
+
+func Typecheck(args []string) int {
+	flags := flag.NewFlagSet("typecheck", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, err := lint.Discover(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	failed := false
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		p := parser.New(lexer.New(string(src)))
+		program := p.ParseProgram()
+		if errs := p.ErrorList(); len(errs) != 0 {
+			for _, err := range errs {
+				fmt.Printf("%s:%s: %s\n", file, err.Token.Pos, err.Message)
+			}
+			failed = true
+			continue
+		}
+
+		for _, err := range typecheck.Check(program, evaluator.BuiltinNames()) {
+			fmt.Printf("%s:%s\n", file, err.Error())
+			failed = true
+		}
+	}
+
+	if failed {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,225 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/lxdlam/monkey-plus/ast"
+	"github.com/lxdlam/monkey-plus/object"
+	"github.com/lxdlam/monkey-plus/token"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+// Block is a statement that can be covered
+type Block struct {
+	Start token.Position
+	End   token.Position
+	Count int
+}
+
+type FileSummary struct {
+	File    string
+	Covered int
+	Total   int
+}
+
+func (fs FileSummary) Percent() float64 {
+	if fs.Total == 0 {
+		return 100
+	}
+
+	return float64(fs.Covered) * 100 / float64(fs.Total)
+}
+
+// Profile records how many times every statement of the loaded files is executed. It implements object.Hook.
+type Profile struct {
+	files  map[string][]*Block
+	blocks map[ast.Statement]*Block
+}
+
+func New() *Profile {
+	return &Profile{
+		files:  make(map[string][]*Block),
+		blocks: make(map[ast.Statement]*Block),
+	}
+}
+
+func (p *Profile) LoadProgram(program *ast.Program) {
+	if program.File == "" {
+		return
+	}
+
+	if _, ok := p.files[program.File]; ok {
+		return
+	}
+
+	var blocks []*Block
+	register := func(statements []ast.Statement) {
+		for _, stmt := range statements {
+			if _, ok := p.blocks[stmt]; ok {
+				continue
+			}
+
+			block := &Block{Start: stmt.Pos(), End: endOfLine(stmt)}
+			p.blocks[stmt] = block
+			blocks = append(blocks, block)
+		}
+	}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Program:
+			register(node.Statements)
+		case *ast.BlockStatement:
+			register(node.Statements)
+		}
+		return true
+	})
+
+	sort.Slice(blocks, func(i, j int) bool {
+		return less(blocks[i].Start, blocks[j].Start)
+	})
+
+	p.files[program.File] = blocks
+}
+
+func (p *Profile) BeforeStatement(stmt ast.Statement, env *object.Environment) {
+	if block, ok := p.blocks[stmt]; ok {
+		block.Count++
+	}
+}
+
+func (p *Profile) EnterFunction(fn *object.Function, args []object.Object) {}
+
+func (p *Profile) ExitFunction(fn *object.Function, result object.Object) {}
+
+func (p *Profile) Files() []string {
+	var files []string
+	for file := range p.files {
+		files = append(files, file)
+	}
+
+	sort.Strings(files)
+	return files
+}
+
+func (p *Profile) Blocks(file string) []*Block {
+	return p.files[file]
+}
+
+func (p *Profile) Summary() []FileSummary {
+	var summaries []FileSummary
+
+	for _, file := range p.Files() {
+		summary := FileSummary{File: file}
+		for _, block := range p.files[file] {
+			summary.Total++
+			if block.Count > 0 {
+				summary.Covered++
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return summaries
+}
+
+// WriteGoCover writes the profile in the format of `go test -coverprofile`, so `go tool cover` can render it
+func (p *Profile) WriteGoCover(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "mode: count")
+
+	for _, file := range p.Files() {
+		name := absPath(file)
+		for _, block := range p.files[file] {
+			fmt.Fprintf(out, "%s:%d.%d,%d.%d 1 %d\n", name,
+				block.Start.Line, block.Start.Column, block.End.Line, block.End.Column, block.Count)
+		}
+	}
+
+	return out.Flush()
+}
+
+// WriteLCOV writes the line coverage in LCOV tracefile format, which can be rendered by genhtml
+func (p *Profile) WriteLCOV(w io.Writer) error {
+	out := bufio.NewWriter(w)
+
+	for _, file := range p.Files() {
+		lines := make(map[int]int)
+		var order []int
+
+		for _, block := range p.files[file] {
+			count, ok := lines[block.Start.Line]
+			if !ok {
+				order = append(order, block.Start.Line)
+			}
+			if !ok || block.Count > count {
+				lines[block.Start.Line] = block.Count
+			}
+		}
+
+		sort.Ints(order)
+
+		fmt.Fprintln(out, "TN:")
+		fmt.Fprintf(out, "SF:%s\n", absPath(file))
+
+		hit := 0
+		for _, line := range order {
+			fmt.Fprintf(out, "DA:%d,%d\n", line, lines[line])
+			if lines[line] > 0 {
+				hit++
+			}
+		}
+
+		fmt.Fprintf(out, "LF:%d\n", len(order))
+		fmt.Fprintf(out, "LH:%d\n", hit)
+		fmt.Fprintln(out, "end_of_record")
+	}
+
+	return out.Flush()
+}
+
+// The block of a statement ends at the last token of the statement on the same line, so blocks of
+// the nested statements will not overlap with their parents in most cases
+func endOfLine(stmt ast.Statement) token.Position {
+	start := stmt.Pos()
+	end := token.Position{Line: start.Line, Column: start.Column + len(stmt.TokenLiteral())}
+
+	ast.Inspect(stmt, func(node ast.Node) bool {
+		pos := node.Pos()
+		if pos.Line != start.Line {
+			return true
+		}
+
+		length := len(node.TokenLiteral())
+		switch node.(type) {
+		case *ast.StringLiteral, *ast.InterpolatedString:
+			length += 2
+		}
+
+		if pos.Column+length > end.Column {
+			end.Column = pos.Column + length
+		}
+
+		return true
+	})
+
+	return end
+}
+
+func less(a, b token.Position) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+
+	return a.Column < b.Column
+}
+
+func absPath(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		return abs
+	}
+
+	return file
+}
@@ -0,0 +1,100 @@
+package coverage
+
+import (
+	"bytes"
+	"github.com/lxdlam/monkey-plus/evaluator"
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/object"
+	"github.com/lxdlam/monkey-plus/parser"
+	"strings"
+	"testing"
+)
+
+const input = `let sign = fn(x) {
+  if (x > 0) {
+    return 1;
+  } else {
+    return -1;
+  }
+};
+sign(5);
+sign(6);
+`
+
+func runWithProfile(t *testing.T) *Profile {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	program.File = "sign.mp"
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has errors: %v", p.Errors())
+	}
+
+	profile := New()
+	runtime := &object.Runtime{}
+	runtime.AddHook(profile)
+	evaluator.Eval(program, object.NewEnvironmentWithRuntime(runtime))
+
+	return profile
+}
+
+func TestProfile(t *testing.T) {
+	profile := runWithProfile(t)
+
+	blocks := profile.Blocks("sign.mp")
+	expected := []struct {
+		line  int
+		count int
+	}{
+		{1, 1},
+		{2, 2},
+		{3, 2},
+		{5, 0},
+		{8, 1},
+		{9, 1},
+	}
+
+	if len(blocks) != len(expected) {
+		t.Fatalf("wrong number of blocks. got=%d, want=%d", len(blocks), len(expected))
+	}
+
+	for i, tt := range expected {
+		if blocks[i].Start.Line != tt.line || blocks[i].Count != tt.count {
+			t.Errorf("blocks[%d] wrong. got=line %d count %d, want=line %d count %d",
+				i, blocks[i].Start.Line, blocks[i].Count, tt.line, tt.count)
+		}
+	}
+
+	summary := profile.Summary()
+	if len(summary) != 1 || summary[0].Covered != 5 || summary[0].Total != 6 {
+		t.Errorf("wrong summary. got=%+v", summary)
+	}
+}
+
+func TestWriteGoCover(t *testing.T) {
+	var out bytes.Buffer
+	if err := runWithProfile(t).WriteGoCover(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if lines[0] != "mode: count" {
+		t.Errorf("wrong mode line. got=%q", lines[0])
+	}
+
+	if !strings.HasSuffix(lines[2], "sign.mp:2.3,2.15 1 2") {
+		t.Errorf("wrong block line. got=%q", lines[2])
+	}
+}
+
+func TestWriteLCOV(t *testing.T) {
+	var out bytes.Buffer
+	if err := runWithProfile(t).WriteLCOV(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"DA:2,2\n", "DA:5,0\n", "LF:6\n", "LH:5\n", "end_of_record\n"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("LCOV output does not contain %q. got=%q", expected, out.String())
+		}
+	}
+}
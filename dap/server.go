@@ -0,0 +1,459 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/lxdlam/monkey-plus/ast"
+	"github.com/lxdlam/monkey-plus/debugger"
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/object"
+	"github.com/lxdlam/monkey-plus/parser"
+	"github.com/lxdlam/monkey-plus/transport"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const THREAD_ID = 1
+
+// Server is a debug adapter running the program in the same process. It implements debugger.Frontend.
+type Server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	writeMu sync.Mutex
+	seq     int
+
+	debugger    *debugger.Debugger
+	program     *ast.Program
+	stopOnEntry bool
+	launched    bool
+	configured  bool
+	started     bool
+
+	mu       sync.Mutex
+	paused   bool
+	quitting bool
+	actions  chan debugger.Action
+	done     chan struct{}
+
+	// The variables references are only valid while paused
+	refs map[int]interface{}
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	s := &Server{
+		in:      bufio.NewReader(in),
+		out:     out,
+		actions: make(chan debugger.Action),
+		done:    make(chan struct{}),
+		refs:    make(map[int]interface{}),
+	}
+
+	s.debugger = debugger.New(s)
+	s.debugger.Runtime.Stdout = &outputWriter{server: s}
+
+	return s
+}
+
+// Serve handles the requests until the client disconnects or the input is closed
+func (s *Server) Serve() error {
+	for {
+		content, err := transport.ReadMessage(s.in)
+		if err == io.EOF {
+			s.quit()
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var req Request
+		if err := json.Unmarshal(content, &req); err != nil {
+			return err
+		}
+
+		if req.Type != "request" {
+			continue
+		}
+
+		if !s.handle(&req) {
+			return nil
+		}
+	}
+}
+
+// Done is closed when the program finishes
+func (s *Server) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Server) handle(req *Request) bool {
+	switch req.Command {
+	case "initialize":
+		s.respond(req, Capabilities{
+			SupportsConfigurationDoneRequest: true,
+			SupportsEvaluateForHovers:        true,
+			SupportsTerminateRequest:         true,
+		})
+		s.event("initialized", nil)
+	case "launch":
+		s.launch(req)
+	case "setBreakpoints":
+		s.setBreakpoints(req)
+	case "configurationDone":
+		s.configured = true
+		s.respond(req, nil)
+		s.start()
+	case "threads":
+		s.respond(req, map[string]interface{}{"threads": []Thread{{ID: THREAD_ID, Name: "main"}}})
+	case "stackTrace":
+		s.stackTrace(req)
+	case "scopes":
+		s.scopes(req)
+	case "variables":
+		s.variables(req)
+	case "evaluate":
+		s.evaluate(req)
+	case "continue":
+		s.resume(req, debugger.Continue, map[string]interface{}{"allThreadsContinued": true})
+	case "next":
+		s.resume(req, debugger.Next, nil)
+	case "stepIn":
+		s.resume(req, debugger.Step, nil)
+	case "stepOut":
+		s.resume(req, debugger.Finish, nil)
+	case "pause":
+		s.debugger.Pause()
+		s.respond(req, nil)
+	case "disconnect", "terminate":
+		s.respond(req, nil)
+		s.quit()
+		return req.Command != "disconnect"
+	default:
+		s.fail(req, fmt.Sprintf("unsupported request %q", req.Command))
+	}
+
+	return true
+}
+
+func (s *Server) launch(req *Request) {
+	var args LaunchArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil || args.Program == "" {
+		s.fail(req, "launch requires the program")
+		return
+	}
+
+	source, err := ioutil.ReadFile(args.Program)
+	if err != nil {
+		s.fail(req, err.Error())
+		return
+	}
+
+	p := parser.New(lexer.New(string(source)))
+	program := p.ParseProgram()
+	program.File = args.Program
+
+	if len(p.Errors()) != 0 {
+		s.fail(req, "parse error: "+strings.Join(p.Errors(), "; "))
+		return
+	}
+
+	s.program = program
+	s.stopOnEntry = args.StopOnEntry
+	s.launched = true
+	s.respond(req, nil)
+	s.start()
+}
+
+// The program starts when it's launched and all the breakpoints are configured
+func (s *Server) start() {
+	if !s.launched || !s.configured || s.started {
+		return
+	}
+
+	s.started = true
+	s.debugger.StopOnEntry(s.stopOnEntry)
+
+	go func() {
+		result, err := s.debugger.Run(s.program)
+
+		exitCode := 0
+		if err != nil {
+			exitCode = 1
+		} else if result != nil && result.Type() == object.ERROR_OBJ {
+			s.event("output", map[string]interface{}{"category": "stderr", "output": result.Inspect() + "\n"})
+			exitCode = 1
+		}
+
+		s.event("exited", map[string]interface{}{"exitCode": exitCode})
+		s.event("terminated", nil)
+		close(s.done)
+	}()
+}
+
+func (s *Server) setBreakpoints(req *Request) {
+	var args SetBreakpointsArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		s.fail(req, err.Error())
+		return
+	}
+
+	var lines []int
+	for _, bp := range args.Breakpoints {
+		lines = append(lines, bp.Line)
+	}
+
+	breakpoints := []Breakpoint{}
+	for _, bp := range s.debugger.SetBreakpoints(args.Source.Path, lines) {
+		breakpoints = append(breakpoints, Breakpoint{Verified: true, Line: bp.Line, Source: &args.Source})
+	}
+
+	s.respond(req, map[string]interface{}{"breakpoints": breakpoints})
+}
+
+// Paused blocks the program until the client resumes it
+func (s *Server) Paused(d *debugger.Debugger, reason debugger.Reason) debugger.Action {
+	s.mu.Lock()
+	if s.quitting {
+		s.mu.Unlock()
+		return debugger.Quit
+	}
+	s.paused = true
+	s.refs = make(map[int]interface{})
+	s.mu.Unlock()
+
+	s.event("stopped", map[string]interface{}{
+		"reason":            string(reason),
+		"threadId":          THREAD_ID,
+		"allThreadsStopped": true,
+	})
+
+	return <-s.actions
+}
+
+func (s *Server) resume(req *Request, action debugger.Action, body interface{}) {
+	if !s.isPaused() {
+		s.fail(req, "the program is not paused")
+		return
+	}
+
+	s.mu.Lock()
+	s.paused = false
+	s.mu.Unlock()
+
+	s.respond(req, body)
+	s.actions <- action
+}
+
+func (s *Server) quit() {
+	s.mu.Lock()
+	s.quitting = true
+	paused := s.paused
+	s.paused = false
+	s.mu.Unlock()
+
+	if paused {
+		s.actions <- debugger.Quit
+	}
+}
+
+func (s *Server) isPaused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.paused
+}
+
+// The frames are numbered from the bottom, so the ids stay the same while stepping in the same frame
+func (s *Server) stackTrace(req *Request) {
+	if !s.isPaused() {
+		s.fail(req, "the program is not paused")
+		return
+	}
+
+	frames := s.debugger.Frames()
+	stackFrames := []StackFrame{}
+	for i := len(frames) - 1; i >= 0; i-- {
+		frame := frames[i]
+		stackFrames = append(stackFrames, StackFrame{
+			ID:     i,
+			Name:   frame.Name,
+			Source: &Source{Name: filepath.Base(frame.File), Path: frame.File},
+			Line:   frame.Pos.Line,
+			Column: frame.Pos.Column,
+		})
+	}
+
+	s.respond(req, map[string]interface{}{"stackFrames": stackFrames, "totalFrames": len(stackFrames)})
+}
+
+func (s *Server) scopes(req *Request) {
+	var args ScopesArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		s.fail(req, err.Error())
+		return
+	}
+
+	frames := s.debugger.Frames()
+	if !s.isPaused() || args.FrameID < 0 || args.FrameID >= len(frames) {
+		s.fail(req, "invalid frame")
+		return
+	}
+
+	// Every environment of the chain is a scope, the outermost one is the globals
+	scopes := []Scope{}
+	for env, depth := frames[args.FrameID].Env, 0; env != nil; env, depth = env.Outer(), depth+1 {
+		name := "Closure"
+		if env.Outer() == nil {
+			name = "Globals"
+		} else if depth == 0 {
+			name = "Locals"
+		}
+
+		scopes = append(scopes, Scope{Name: name, VariablesReference: s.reference(env), Expensive: env.Outer() == nil})
+	}
+
+	s.respond(req, map[string]interface{}{"scopes": scopes})
+}
+
+func (s *Server) variables(req *Request) {
+	var args VariablesArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		s.fail(req, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	container, ok := s.refs[args.VariablesReference]
+	s.mu.Unlock()
+
+	if !s.isPaused() || !ok {
+		s.fail(req, "invalid variables reference")
+		return
+	}
+
+	variables := []Variable{}
+	switch container := container.(type) {
+	case *object.Environment:
+		for _, name := range container.Names() {
+			value, _ := container.Get(name)
+			variables = append(variables, s.variable(name, value))
+		}
+	case *object.Array:
+		for idx, el := range container.Elements {
+			variables = append(variables, s.variable(strconv.Itoa(idx), el))
+		}
+	case *object.Hash:
+		// The pairs are listed in the order of insertion, like the hash is printed
+		for _, pair := range container.Items() {
+			variables = append(variables, s.variable(pair.Key.Inspect(), pair.Value))
+		}
+	}
+
+	s.respond(req, map[string]interface{}{"variables": variables})
+}
+
+func (s *Server) evaluate(req *Request) {
+	var args EvaluateArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		s.fail(req, err.Error())
+		return
+	}
+
+	if !s.isPaused() {
+		s.fail(req, "the program is not paused")
+		return
+	}
+
+	frame := len(s.debugger.Frames()) - 1
+	if args.FrameID != nil {
+		frame = *args.FrameID
+	}
+
+	result, err := s.debugger.Evaluate(args.Expression, frame)
+	if err != nil {
+		s.fail(req, err.Error())
+		return
+	}
+
+	if result.Type() == object.ERROR_OBJ {
+		s.fail(req, result.Inspect())
+		return
+	}
+
+	v := s.variable("", result)
+	s.respond(req, map[string]interface{}{
+		"result":             v.Value,
+		"type":               v.Type,
+		"variablesReference": v.VariablesReference,
+	})
+}
+
+func (s *Server) variable(name string, value object.Object) Variable {
+	v := Variable{Name: name, Value: value.Inspect(), Type: string(value.Type())}
+
+	switch value := value.(type) {
+	case *object.String:
+		v.Value = strconv.Quote(string(value.Value))
+	case *object.Array:
+		if len(value.Elements) > 0 {
+			v.VariablesReference = s.reference(value)
+		}
+	case *object.Hash:
+		if value.Len() > 0 {
+			v.VariablesReference = s.reference(value)
+		}
+	}
+
+	return v
+}
+
+func (s *Server) reference(container interface{}) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ref := len(s.refs) + 1
+	s.refs[ref] = container
+	return ref
+}
+
+func (s *Server) respond(req *Request, body interface{}) {
+	s.send(func(seq int) interface{} {
+		return &Response{Seq: seq, Type: "response", RequestSeq: req.Seq, Success: true, Command: req.Command, Body: body}
+	})
+}
+
+func (s *Server) fail(req *Request, message string) {
+	s.send(func(seq int) interface{} {
+		return &Response{Seq: seq, Type: "response", RequestSeq: req.Seq, Success: false, Command: req.Command, Message: message}
+	})
+}
+
+func (s *Server) event(event string, body interface{}) {
+	s.send(func(seq int) interface{} {
+		return &Event{Seq: seq, Type: "event", Event: event, Body: body}
+	})
+}
+
+// The responses and the events are sent from both the request loop and the program
+func (s *Server) send(build func(seq int) interface{}) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.seq++
+	_ = transport.WriteMessage(s.out, build(s.seq))
+}
+
+// outputWriter sends the output of the program as the output events
+type outputWriter struct {
+	server *Server
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	w.server.event("output", map[string]interface{}{"category": "stdout", "output": string(p)})
+	return len(p), nil
+}
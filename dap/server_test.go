@@ -0,0 +1,312 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/lxdlam/monkey-plus/evaluator"
+	"github.com/lxdlam/monkey-plus/transport"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const program = `let make = fn(base) {
+  let items = [base, {"k": base * 2, "b": 1, "a": 2}];
+  fn(x) {
+    let sum = x + base;
+    sum
+  }
+};
+let adder = make(10);
+let result = adder(5);
+puts(result);
+`
+
+type message struct {
+	Seq        int                    `json:"seq"`
+	Type       string                 `json:"type"`
+	Command    string                 `json:"command"`
+	Event      string                 `json:"event"`
+	RequestSeq int                    `json:"request_seq"`
+	Success    bool                   `json:"success"`
+	Message    string                 `json:"message"`
+	Body       map[string]interface{} `json:"body"`
+}
+
+// client is a scripted fake client, the messages are read in background like the real clients
+type client struct {
+	t        *testing.T
+	w        io.Writer
+	seq      int
+	messages chan *message
+	pending  []*message
+}
+
+func newClient(t *testing.T, w io.Writer, r io.Reader) *client {
+	c := &client{t: t, w: w, messages: make(chan *message, 64)}
+
+	go func() {
+		reader := bufio.NewReader(r)
+		for {
+			content, err := transport.ReadMessage(reader)
+			if err != nil {
+				close(c.messages)
+				return
+			}
+
+			var m message
+			if err := json.Unmarshal(content, &m); err != nil {
+				close(c.messages)
+				return
+			}
+			c.messages <- &m
+		}
+	}()
+
+	return c
+}
+
+func (c *client) request(command string, args interface{}) *message {
+	c.seq++
+	req := map[string]interface{}{"seq": c.seq, "type": "request", "command": command}
+	if args != nil {
+		req["arguments"] = args
+	}
+
+	if err := transport.WriteMessage(c.w, req); err != nil {
+		c.t.Fatal(err)
+	}
+
+	seq := c.seq
+	return c.wait(func(m *message) bool { return m.Type == "response" && m.RequestSeq == seq })
+}
+
+func (c *client) success(command string, args interface{}) map[string]interface{} {
+	resp := c.request(command, args)
+	if !resp.Success {
+		c.t.Fatalf("%s failed: %s", command, resp.Message)
+	}
+	return resp.Body
+}
+
+func (c *client) event(event string) *message {
+	return c.wait(func(m *message) bool { return m.Type == "event" && m.Event == event })
+}
+
+func (c *client) wait(match func(m *message) bool) *message {
+	for idx, m := range c.pending {
+		if match(m) {
+			c.pending = append(c.pending[:idx], c.pending[idx+1:]...)
+			return m
+		}
+	}
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case m, ok := <-c.messages:
+			if !ok {
+				c.t.Fatalf("connection closed")
+			}
+
+			if match(m) {
+				return m
+			}
+			c.pending = append(c.pending, m)
+		case <-timeout:
+			c.t.Fatalf("timeout waiting for the message")
+		}
+	}
+}
+
+func startServer(t *testing.T) (*client, *Server, string) {
+	evaluator.InitBuiltins()
+
+	dir, err := ioutil.TempDir("", "monkey-dap")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "closure.mp")
+	if err := ioutil.WriteFile(path, []byte(program), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+
+	server := NewServer(serverReader, serverWriter)
+	go server.Serve()
+
+	return newClient(t, clientWriter, clientReader), server, path
+}
+
+func frames(body map[string]interface{}) []map[string]interface{} {
+	var result []map[string]interface{}
+	for _, f := range body["stackFrames"].([]interface{}) {
+		result = append(result, f.(map[string]interface{}))
+	}
+	return result
+}
+
+func variables(c *client, ref interface{}) map[string]map[string]interface{} {
+	body := c.success("variables", map[string]interface{}{"variablesReference": ref})
+	result := make(map[string]map[string]interface{})
+	for _, v := range body["variables"].([]interface{}) {
+		v := v.(map[string]interface{})
+		result[v["name"].(string)] = v
+	}
+	return result
+}
+
+func TestDebugSession(t *testing.T) {
+	c, server, path := startServer(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	caps := c.success("initialize", map[string]interface{}{"adapterID": "monkey"})
+	if caps["supportsConfigurationDoneRequest"] != true {
+		t.Errorf("wrong capabilities. got=%v", caps)
+	}
+	c.event("initialized")
+
+	c.success("launch", map[string]interface{}{"program": path})
+	bps := c.success("setBreakpoints", map[string]interface{}{
+		"source":      map[string]interface{}{"path": path},
+		"breakpoints": []map[string]interface{}{{"line": 4}},
+	})
+	if len(bps["breakpoints"].([]interface{})) != 1 {
+		t.Fatalf("wrong breakpoints. got=%v", bps)
+	}
+	c.success("configurationDone", nil)
+
+	stopped := c.event("stopped")
+	if stopped.Body["reason"] != "breakpoint" {
+		t.Fatalf("wrong stop reason. got=%v", stopped.Body)
+	}
+
+	threads := c.success("threads", nil)["threads"].([]interface{})
+	if len(threads) != 1 {
+		t.Errorf("wrong threads. got=%v", threads)
+	}
+
+	stack := frames(c.success("stackTrace", map[string]interface{}{"threadId": THREAD_ID}))
+	if len(stack) != 2 || stack[0]["name"] != "fn" || stack[0]["line"] != 4.0 || stack[1]["name"] != "main" {
+		t.Fatalf("wrong stack trace. got=%v", stack)
+	}
+
+	scopes := c.success("scopes", map[string]interface{}{"frameId": stack[0]["id"]})["scopes"].([]interface{})
+	if len(scopes) != 3 {
+		t.Fatalf("wrong number of scopes. got=%v", scopes)
+	}
+
+	locals := variables(c, scopes[0].(map[string]interface{})["variablesReference"])
+	if locals["x"]["value"] != "5" {
+		t.Errorf("wrong locals. got=%v", locals)
+	}
+
+	closure := variables(c, scopes[1].(map[string]interface{})["variablesReference"])
+	if closure["base"]["value"] != "10" || closure["items"]["variablesReference"] == 0.0 {
+		t.Fatalf("wrong closure scope. got=%v", closure)
+	}
+
+	items := variables(c, closure["items"]["variablesReference"])
+	if items["0"]["value"] != "10" {
+		t.Errorf("wrong array elements. got=%v", items)
+	}
+
+	hash := variables(c, items["1"]["variablesReference"])
+	if hash["k"]["value"] != "20" {
+		t.Errorf("wrong hash pairs. got=%v", hash)
+	}
+
+	// The pairs keep the order of insertion, like the hash is printed
+	names := []string{}
+	body := c.success("variables", map[string]interface{}{"variablesReference": items["1"]["variablesReference"]})
+	for _, v := range body["variables"].([]interface{}) {
+		names = append(names, v.(map[string]interface{})["name"].(string))
+	}
+	if strings.Join(names, " ") != "k b a" {
+		t.Errorf("wrong order of the hash pairs. got=%v", names)
+	}
+
+	globals := variables(c, scopes[2].(map[string]interface{})["variablesReference"])
+	if _, ok := globals["adder"]; !ok {
+		t.Errorf("wrong globals. got=%v", globals)
+	}
+
+	result := c.success("evaluate", map[string]interface{}{"expression": "x * base", "frameId": stack[0]["id"]})
+	if result["result"] != "50" {
+		t.Errorf("wrong evaluate result. got=%v", result)
+	}
+
+	if resp := c.request("evaluate", map[string]interface{}{"expression": "nope", "frameId": stack[0]["id"]}); resp.Success {
+		t.Errorf("evaluate should fail for unknown identifiers")
+	}
+
+	c.success("next", map[string]interface{}{"threadId": THREAD_ID})
+	c.event("stopped")
+	stack = frames(c.success("stackTrace", map[string]interface{}{"threadId": THREAD_ID}))
+	if stack[0]["line"] != 5.0 {
+		t.Errorf("next stops at wrong line. got=%v", stack[0])
+	}
+
+	c.success("stepOut", map[string]interface{}{"threadId": THREAD_ID})
+	c.event("stopped")
+	stack = frames(c.success("stackTrace", map[string]interface{}{"threadId": THREAD_ID}))
+	if len(stack) != 1 || stack[0]["line"] != 10.0 {
+		t.Errorf("stepOut stops at wrong frame. got=%v", stack)
+	}
+
+	c.success("continue", map[string]interface{}{"threadId": THREAD_ID})
+	output := c.event("output")
+	if output.Body["output"] != "15\n" {
+		t.Errorf("wrong output. got=%v", output.Body)
+	}
+
+	exited := c.event("exited")
+	if exited.Body["exitCode"] != 0.0 {
+		t.Errorf("wrong exit code. got=%v", exited.Body)
+	}
+	c.event("terminated")
+	<-server.Done()
+
+	c.success("disconnect", nil)
+}
+
+func TestStopOnEntryAndStepIn(t *testing.T) {
+	c, server, path := startServer(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	c.success("initialize", nil)
+	c.success("launch", map[string]interface{}{"program": path, "stopOnEntry": true})
+	c.success("configurationDone", nil)
+
+	if stopped := c.event("stopped"); stopped.Body["reason"] != "entry" {
+		t.Fatalf("wrong stop reason. got=%v", stopped.Body)
+	}
+
+	for _, expected := range []struct {
+		command string
+		name    string
+		line    float64
+	}{
+		{"next", "main", 8},
+		{"stepIn", "make", 2},
+		{"stepIn", "make", 3},
+	} {
+		c.success(expected.command, map[string]interface{}{"threadId": THREAD_ID})
+		c.event("stopped")
+
+		stack := frames(c.success("stackTrace", map[string]interface{}{"threadId": THREAD_ID}))
+		if stack[0]["name"] != expected.name || stack[0]["line"] != expected.line {
+			t.Errorf("%s stops at wrong position. got=%v", expected.command, stack[0])
+		}
+	}
+
+	c.success("disconnect", nil)
+	<-server.Done()
+}
@@ -0,0 +1,224 @@
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+const PROMPT = "(mdb) "
+
+// Console is the frontend reading the commands from the input line by line
+type Console struct {
+	in       *bufio.Scanner
+	out      io.Writer
+	sources  map[string][]string
+	selected int
+}
+
+func NewConsole(in io.Reader, out io.Writer) *Debugger {
+	return New(&Console{
+		in:      bufio.NewScanner(in),
+		out:     out,
+		sources: make(map[string][]string),
+	})
+}
+
+func (c *Console) Paused(d *Debugger, reason Reason) Action {
+	frames := d.Frames()
+	c.selected = len(frames) - 1
+
+	if reason == ReasonBreakpoint {
+		frame := frames[c.selected]
+		fmt.Fprintf(c.out, "breakpoint hit at %s:%d\n", frame.File, frame.Pos.Line)
+	}
+	c.printLocation(frames[c.selected])
+
+	for {
+		fmt.Fprint(c.out, PROMPT)
+		if !c.in.Scan() {
+			return Quit
+		}
+
+		line := strings.TrimSpace(c.in.Text())
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		cmd, args := fields[0], fields[1:]
+		switch cmd {
+		case "s", "step":
+			return Step
+		case "n", "next":
+			return Next
+		case "finish":
+			return Finish
+		case "c", "continue":
+			return Continue
+		case "q", "quit":
+			return Quit
+		case "b", "break":
+			c.breakCommand(d, args, true)
+		case "clear":
+			c.breakCommand(d, args, false)
+		case "p", "print":
+			c.printCommand(d, strings.TrimSpace(strings.TrimPrefix(line, cmd)))
+		case "bt", "backtrace":
+			c.backtrace(d)
+		case "frame":
+			c.frameCommand(d, args)
+		case "l", "list":
+			c.list(frames[c.selected])
+		case "h", "help":
+			c.help()
+		default:
+			fmt.Fprintf(c.out, "unknown command %q, try help\n", cmd)
+		}
+	}
+}
+
+func (c *Console) breakCommand(d *Debugger, args []string, set bool) {
+	if len(args) == 0 {
+		if set {
+			for idx, bp := range d.Breakpoints() {
+				fmt.Fprintf(c.out, "#%d %s\n", idx, bp)
+			}
+		} else {
+			d.ClearBreakpoints()
+			fmt.Fprintln(c.out, "all breakpoints cleared")
+		}
+		return
+	}
+
+	file, line, err := parseLocation(args[0])
+	if err != nil {
+		fmt.Fprintln(c.out, err)
+		return
+	}
+
+	if set {
+		fmt.Fprintf(c.out, "breakpoint set at %s\n", d.AddBreakpoint(file, line))
+	} else if d.ClearBreakpoint(file, line) {
+		fmt.Fprintf(c.out, "breakpoint cleared at %s:%d\n", d.ResolveFile(file), line)
+	} else {
+		fmt.Fprintf(c.out, "no breakpoint at %s:%d\n", d.ResolveFile(file), line)
+	}
+}
+
+func (c *Console) printCommand(d *Debugger, code string) {
+	if code == "" {
+		fmt.Fprintln(c.out, "usage: print <expression>")
+		return
+	}
+
+	result, err := d.Evaluate(code, c.selected)
+	if err != nil {
+		fmt.Fprintln(c.out, err)
+		return
+	}
+
+	fmt.Fprintln(c.out, result.Inspect())
+}
+
+func (c *Console) backtrace(d *Debugger) {
+	frames := d.Frames()
+	for i := len(frames) - 1; i >= 0; i-- {
+		marker := " "
+		if i == c.selected {
+			marker = "*"
+		}
+
+		frame := frames[i]
+		fmt.Fprintf(c.out, "%s#%d %s at %s:%d\n", marker, len(frames)-1-i, frame.Name, frame.File, frame.Pos.Line)
+	}
+}
+
+func (c *Console) frameCommand(d *Debugger, args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(c.out, "usage: frame <n>")
+		return
+	}
+
+	frames := d.Frames()
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 || n >= len(frames) {
+		fmt.Fprintf(c.out, "no frame %s\n", args[0])
+		return
+	}
+
+	c.selected = len(frames) - 1 - n
+	c.printLocation(frames[c.selected])
+}
+
+func (c *Console) printLocation(frame *Frame) {
+	line := ""
+	if lines := c.source(frame.File); frame.Pos.Line >= 1 && frame.Pos.Line <= len(lines) {
+		line = strings.TrimSpace(lines[frame.Pos.Line-1])
+	}
+
+	fmt.Fprintf(c.out, "> %s at %s:%d: %s\n", frame.Name, frame.File, frame.Pos.Line, line)
+}
+
+func (c *Console) list(frame *Frame) {
+	lines := c.source(frame.File)
+	for no := frame.Pos.Line - 5; no <= frame.Pos.Line+5; no++ {
+		if no < 1 || no > len(lines) {
+			continue
+		}
+
+		marker := "  "
+		if no == frame.Pos.Line {
+			marker = "=>"
+		}
+
+		fmt.Fprintf(c.out, "%s %4d  %s\n", marker, no, lines[no-1])
+	}
+}
+
+func (c *Console) help() {
+	fmt.Fprintln(c.out, `commands:
+  break [file:]line    set a breakpoint, list the breakpoints without argument
+  clear [file:]line    clear a breakpoint, clear all without argument
+  step                 step into the next statement
+  next                 step over to the next statement in this frame
+  finish               run until the current function returns
+  continue             run until the next breakpoint
+  print <expr>         evaluate the expression in the selected frame
+  backtrace            print the active calls
+  frame <n>            select the frame n of the backtrace
+  list                 print the source around the current line
+  quit                 stop the program`)
+}
+
+func (c *Console) source(file string) []string {
+	if lines, ok := c.sources[file]; ok {
+		return lines
+	}
+
+	var lines []string
+	if data, err := ioutil.ReadFile(file); err == nil {
+		lines = strings.Split(string(data), "\n")
+	}
+
+	c.sources[file] = lines
+	return lines
+}
+
+func parseLocation(location string) (string, int, error) {
+	file := ""
+	lineStr := location
+	if idx := strings.LastIndex(location, ":"); idx >= 0 {
+		file, lineStr = location[:idx], location[idx+1:]
+	}
+
+	line, err := strconv.Atoi(lineStr)
+	if err != nil || line <= 0 {
+		return "", 0, fmt.Errorf("invalid location %q", location)
+	}
+
+	return file, line, nil
+}
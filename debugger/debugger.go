@@ -0,0 +1,381 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lxdlam/monkey-plus/ast"
+	"github.com/lxdlam/monkey-plus/evaluator"
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/object"
+	"github.com/lxdlam/monkey-plus/parser"
+	"github.com/lxdlam/monkey-plus/token"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var ErrQuit = errors.New("debugger quit")
+
+type Action int
+
+const (
+	Continue Action = iota
+	Step
+	Next
+	Finish
+	Quit
+)
+
+type Reason string
+
+const (
+	ReasonEntry      Reason = "entry"
+	ReasonStep       Reason = "step"
+	ReasonBreakpoint Reason = "breakpoint"
+	ReasonPause      Reason = "pause"
+)
+
+// Frontend interacts with the user when the debugger pauses, and tells how to resume the execution
+type Frontend interface {
+	Paused(d *Debugger, reason Reason) Action
+}
+
+// Frame is an active call, the bottom one is the top level of the program
+type Frame struct {
+	Name string
+	File string
+	Pos  token.Position
+	Env  *object.Environment
+}
+
+type Breakpoint struct {
+	File string
+	Line int
+}
+
+func (b Breakpoint) String() string {
+	return fmt.Sprintf("%s:%d", b.File, b.Line)
+}
+
+// Debugger controls the execution of a program by the breakpoints and the stepping actions. It implements object.Hook.
+type Debugger struct {
+	Runtime  *object.Runtime
+	frontend Frontend
+
+	main  string
+	files map[ast.Statement]string
+	// The absolute paths of the files, breakpoints are matched by them
+	absPaths map[string]string
+
+	frames []*Frame
+
+	// The breakpoints and the stepping mode may be changed by the frontend while the program is running
+	mu          sync.Mutex
+	breakpoints []Breakpoint
+	action      Action
+	reason      Reason
+	stopDepth   int
+}
+
+func New(frontend Frontend) *Debugger {
+	d := &Debugger{
+		frontend: frontend,
+		files:    make(map[ast.Statement]string),
+		absPaths: make(map[string]string),
+		action:   Step,
+		reason:   ReasonEntry,
+	}
+
+	d.Runtime = &object.Runtime{}
+	d.Runtime.AddHook(d)
+
+	return d
+}
+
+// Run evaluates the program under the debugger, it returns ErrQuit if the frontend quits
+func (d *Debugger) Run(program *ast.Program) (result object.Object, err error) {
+	env := object.NewEnvironmentWithRuntime(d.Runtime)
+
+	d.main = program.File
+	d.frames = []*Frame{{Name: "main", File: program.File, Env: env}}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if r != ErrQuit {
+				panic(r)
+			}
+			err = ErrQuit
+		}
+	}()
+
+	result = evaluator.Eval(program, env)
+
+	return result, nil
+}
+
+// StopOnEntry sets whether the debugger pauses at the first statement, it's true by default
+func (d *Debugger) StopOnEntry(stop bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if stop {
+		d.action, d.reason = Step, ReasonEntry
+	} else {
+		d.action = Continue
+	}
+}
+
+// Pause requests the running program to pause at the next statement
+func (d *Debugger) Pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.action, d.reason = Step, ReasonPause
+}
+
+func (d *Debugger) AddBreakpoint(file string, line int) Breakpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	bp := Breakpoint{File: d.resolveFile(file), Line: line}
+	for _, b := range d.breakpoints {
+		if b == bp {
+			return bp
+		}
+	}
+
+	d.breakpoints = append(d.breakpoints, bp)
+	return bp
+}
+
+func (d *Debugger) ClearBreakpoint(file string, line int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	bp := Breakpoint{File: d.resolveFile(file), Line: line}
+	for idx, b := range d.breakpoints {
+		if b == bp {
+			d.breakpoints = append(d.breakpoints[:idx], d.breakpoints[idx+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetBreakpoints replaces all the breakpoints of the file
+func (d *Debugger) SetBreakpoints(file string, lines []int) []Breakpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	file = d.resolveFile(file)
+
+	var kept []Breakpoint
+	for _, bp := range d.breakpoints {
+		if bp.File != file {
+			kept = append(kept, bp)
+		}
+	}
+
+	var added []Breakpoint
+	for _, line := range lines {
+		bp := Breakpoint{File: file, Line: line}
+		kept = append(kept, bp)
+		added = append(added, bp)
+	}
+
+	d.breakpoints = kept
+	return added
+}
+
+func (d *Debugger) Breakpoints() []Breakpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]Breakpoint{}, d.breakpoints...)
+}
+
+func (d *Debugger) ClearBreakpoints() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.breakpoints = nil
+}
+
+// Frames returns the active calls, the innermost one is the last. It should only be called when paused.
+func (d *Debugger) Frames() []*Frame {
+	return d.frames
+}
+
+func (d *Debugger) LoadProgram(program *ast.Program) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	register := func(statements []ast.Statement) {
+		for _, stmt := range statements {
+			d.files[stmt] = program.File
+		}
+	}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Program:
+			register(node.Statements)
+		case *ast.BlockStatement:
+			register(node.Statements)
+		}
+		return true
+	})
+}
+
+func (d *Debugger) BeforeStatement(stmt ast.Statement, env *object.Environment) {
+	d.mu.Lock()
+	file := d.files[stmt]
+	d.mu.Unlock()
+
+	frame := d.frames[len(d.frames)-1]
+	lastLine := frame.Pos.Line
+	frame.File = file
+	frame.Pos = stmt.Pos()
+	frame.Env = env
+
+	if reason, ok := d.shouldPause(frame, lastLine); ok {
+		action := d.frontend.Paused(d, reason)
+		if action == Quit {
+			panic(ErrQuit)
+		}
+		d.resume(action)
+	}
+}
+
+func (d *Debugger) shouldPause(frame *Frame, lastLine int) (Reason, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	depth := len(d.frames) - 1
+	pause := false
+
+	switch d.action {
+	case Step:
+		pause = true
+	case Next:
+		pause = depth <= d.stopDepth
+	case Finish:
+		pause = depth < d.stopDepth
+	}
+
+	if pause {
+		return d.reason, true
+	}
+
+	if lastLine != frame.Pos.Line {
+		for _, bp := range d.breakpoints {
+			if bp.Line == frame.Pos.Line && d.absPath(bp.File) == d.absPath(frame.File) {
+				return ReasonBreakpoint, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func (d *Debugger) resume(action Action) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.action = action
+	d.reason = ReasonStep
+	d.stopDepth = len(d.frames) - 1
+}
+
+func (d *Debugger) EnterFunction(fn *object.Function, args []object.Object) {
+	name := fn.Name
+	if name == "" {
+		name = "fn"
+	}
+
+	d.frames = append(d.frames, &Frame{Name: name, Env: fn.Env})
+}
+
+func (d *Debugger) ExitFunction(fn *object.Function, result object.Object) {
+	if len(d.frames) <= 1 {
+		return
+	}
+
+	d.frames = d.frames[:len(d.frames)-1]
+}
+
+// Evaluate evaluates the code in the environment of the frame, the hooks are not triggered
+func (d *Debugger) Evaluate(code string, frame int) (object.Object, error) {
+	if frame < 0 || frame >= len(d.frames) {
+		return nil, fmt.Errorf("no frame #%d", frame)
+	}
+
+	p := parser.New(lexer.New(code))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, fmt.Errorf("parse error: %s", strings.Join(p.Errors(), "; "))
+	}
+
+	// The expressions from the frontend run without the hooks, so they are neither debugged nor
+	// registered as loaded files, e.g. by the coverage
+	env := d.frames[frame].Env
+	hooks := env.Runtime().Hooks
+	env.Runtime().Hooks = nil
+	defer func() { env.Runtime().Hooks = hooks }()
+
+	result := evaluator.Eval(program, env)
+	if result == nil {
+		result = evaluator.NULL
+	}
+
+	return result, nil
+}
+
+func (d *Debugger) absPath(file string) string {
+	if abs, ok := d.absPaths[file]; ok {
+		return abs
+	}
+
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		abs = filepath.Clean(file)
+	}
+
+	d.absPaths[file] = abs
+	return abs
+}
+
+// ResolveFile finds the loaded file for the file name of a breakpoint
+func (d *Debugger) ResolveFile(file string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.resolveFile(file)
+}
+
+// The file of a breakpoint can be omitted for the main file, or be the suffix of the loaded files
+func (d *Debugger) resolveFile(file string) string {
+	if file == "" {
+		return d.main
+	}
+
+	seen := map[string]bool{d.main: true}
+	known := []string{d.main}
+	for _, f := range d.files {
+		if !seen[f] {
+			seen[f] = true
+			known = append(known, f)
+		}
+	}
+	sort.Strings(known)
+
+	for _, f := range known {
+		if filepath.Clean(f) == filepath.Clean(file) || strings.HasSuffix(f, string(filepath.Separator)+file) {
+			return f
+		}
+	}
+
+	return file
+}
@@ -0,0 +1,131 @@
+package debugger
+
+import (
+	"bytes"
+	"github.com/lxdlam/monkey-plus/ast"
+	"github.com/lxdlam/monkey-plus/evaluator"
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/parser"
+	"strings"
+	"testing"
+)
+
+const input = `let add = fn(a, b) {
+  let c = a + b;
+  c
+};
+let x = add(1, 2);
+let y = add(x, 10);
+y;
+`
+
+func parse(t *testing.T) *ast.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	program.File = "add.mp"
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has errors: %v", p.Errors())
+	}
+
+	return program
+}
+
+func runScript(t *testing.T, commands ...string) string {
+	evaluator.InitBuiltins()
+
+	var out bytes.Buffer
+	d := NewConsole(strings.NewReader(strings.Join(commands, "\n")+"\n"), &out)
+	if _, err := d.Run(parse(t)); err == nil {
+		out.WriteString("program exited\n")
+	}
+
+	return out.String()
+}
+
+func expectOutput(t *testing.T, out string, expected ...string) {
+	rest := out
+	for _, e := range expected {
+		idx := strings.Index(rest, e)
+		if idx < 0 {
+			t.Fatalf("output does not contain %q in order. got=%q", e, out)
+		}
+		rest = rest[idx+len(e):]
+	}
+}
+
+func TestBreakpointAndPrint(t *testing.T) {
+	out := runScript(t, "break 3", "continue", "print c * 2", "backtrace", "frame 1", "print x", "continue", "print c", "continue")
+
+	expectOutput(t, out,
+		"> main at add.mp:1",
+		"breakpoint set at add.mp:3",
+		"breakpoint hit at add.mp:3",
+		"> add at add.mp:3",
+		"6\n",
+		"*#0 add at add.mp:3\n #1 main at add.mp:5\n",
+		"> main at add.mp:5",
+		"ERROR: identifier not found: x",
+		"breakpoint hit at add.mp:3",
+		"13\n",
+		"program exited",
+	)
+}
+
+func TestStepping(t *testing.T) {
+	out := runScript(t, "next", "step", "step", "finish", "step", "finish", "bt", "quit")
+
+	expectOutput(t, out,
+		"> main at add.mp:1",
+		"> main at add.mp:5",
+		"> add at add.mp:2",
+		"> add at add.mp:3",
+		"> main at add.mp:6",
+		"> add at add.mp:2",
+		"> main at add.mp:7",
+		"*#0 main at add.mp:7\n",
+	)
+
+	if strings.Contains(out, "program exited") {
+		t.Errorf("program should be stopped by quit")
+	}
+}
+
+func TestClearBreakpoint(t *testing.T) {
+	out := runScript(t, "break 3", "break 2", "clear 3", "break", "continue", "continue", "continue")
+
+	expectOutput(t, out,
+		"breakpoint cleared at add.mp:3",
+		"#0 add.mp:2\n",
+		"breakpoint hit at add.mp:2",
+		"breakpoint hit at add.mp:2",
+		"program exited",
+	)
+
+	if strings.Contains(out, "breakpoint hit at add.mp:3") {
+		t.Errorf("cleared breakpoint is hit")
+	}
+}
+
+func TestPrintSkipsHooks(t *testing.T) {
+	evaluator.InitBuiltins()
+
+	commands := []string{"break 3", "continue", "print add(c, 1)", "print c * 2", "continue", "continue"}
+	var out bytes.Buffer
+	d := NewConsole(strings.NewReader(strings.Join(commands, "\n")+"\n"), &out)
+	if _, err := d.Run(parse(t)); err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+
+	// The call in print neither hits the breakpoint nor adds a frame
+	expectOutput(t, out.String(),
+		"breakpoint hit at add.mp:3",
+		"4\n",
+		"6\n",
+		"breakpoint hit at add.mp:3",
+	)
+
+	// Only the statements of the program are registered, the printed expressions are not loaded
+	if len(d.files) != 6 {
+		t.Errorf("wrong number of the registered statements. got=%d, want=6", len(d.files))
+	}
+}
@@ -0,0 +1,55 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/object"
+	"github.com/lxdlam/monkey-plus/parser"
+	"testing"
+)
+
+var benchmarks = map[string]string{
+	"Fib": "let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } }; fib(18)",
+	"Closures": `
+let compose = fn(f, g) { fn(x) { f(g(x)) } };
+let inc = fn(x) { x + 1 };
+let double = fn(x) { x * 2 };
+let loop = fn(i, acc) {
+  if (i == 0) { return acc; }
+  let step = compose(inc, double);
+  loop(i - 1, step(acc) % 1000)
+};
+loop(20000, 0)`,
+	"Locals": `
+let sum = fn(a, b, c) {
+  let d = a + b;
+  let e = d * c;
+  let f = e - a;
+  f + d + e
+};
+let loop = fn(i, acc) { if (i == 0) { acc } else { loop(i - 1, sum(i, acc, 2) % 1000) } };
+loop(20000, 0)`,
+}
+
+// runBenchmark evaluates the program with and without the slots, the later is the lookup by name
+// the evaluator used to do for every name
+func runBenchmark(b *testing.B, name string, noSlots bool) {
+	InitBuiltins()
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		program := parser.New(lexer.New(benchmarks[name])).ParseProgram()
+		env := object.NewEnvironmentWithRuntime(&object.Runtime{NoSlots: noSlots})
+		b.StartTimer()
+
+		if result := Eval(program, env); isError(result) {
+			b.Fatal(result.Inspect())
+		}
+	}
+}
+
+func BenchmarkFibSlots(b *testing.B)       { runBenchmark(b, "Fib", false) }
+func BenchmarkFibByName(b *testing.B)      { runBenchmark(b, "Fib", true) }
+func BenchmarkClosuresSlots(b *testing.B)  { runBenchmark(b, "Closures", false) }
+func BenchmarkClosuresByName(b *testing.B) { runBenchmark(b, "Closures", true) }
+func BenchmarkLocalsSlots(b *testing.B)    { runBenchmark(b, "Locals", false) }
+func BenchmarkLocalsByName(b *testing.B)   { runBenchmark(b, "Locals", true) }
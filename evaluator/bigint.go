@@ -0,0 +1,129 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/object"
+	"math/big"
+	"strings"
+)
+
+// bigIntBuiltins convert between the integers and the big integers
+func bigIntBuiltins() map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"int":    {Fn: builtinInt},
+		"bigint": {Fn: builtinBigInt},
+	}
+}
+
+func isInteger(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.BIGINT_OBJ
+}
+
+// toBig converts an integer or a big integer to a *big.Int, the big integer is not copied
+func toBig(obj object.Object) *big.Int {
+	if integer, ok := obj.(*object.Integer); ok {
+		return big.NewInt(integer.Value)
+	}
+
+	return obj.(*object.BigInt).Value
+}
+
+// bigResult is the result of an operation on big integers, an integer if it fits back in int64
+func bigResult(value *big.Int) object.Object {
+	if value.IsInt64() {
+		return &object.Integer{Value: value.Int64()}
+	}
+
+	return &object.BigInt{Value: value}
+}
+
+// smallInteger returns the integer or the big integer fitting in int64 as an integer
+func smallInteger(obj object.Object) (*object.Integer, bool) {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return obj, true
+	case *object.BigInt:
+		if obj.Value.IsInt64() {
+			return &object.Integer{Value: obj.Value.Int64()}, true
+		}
+	}
+
+	return nil, false
+}
+
+// evalBigIntInfixExpression evaluates the operations on two integers with at least a big one, the
+// result is an integer again if it fits
+func evalBigIntInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := toBig(left)
+	rightVal := toBig(right)
+
+	switch operator {
+	case "+":
+		return bigResult(new(big.Int).Add(leftVal, rightVal))
+	case "-":
+		return bigResult(new(big.Int).Sub(leftVal, rightVal))
+	case "*":
+		return bigResult(new(big.Int).Mul(leftVal, rightVal))
+	case "/":
+		if rightVal.Sign() == 0 {
+			return newError("the right operand of / is 0")
+		}
+		// Quo and Rem truncate like the integer operators
+		return bigResult(new(big.Int).Quo(leftVal, rightVal))
+	case "%":
+		if rightVal.Sign() == 0 {
+			return newError("the right operand of %% is 0")
+		}
+		return bigResult(new(big.Int).Rem(leftVal, rightVal))
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// int(x) converts a big integer back to an integer, it fails if the value does not fit
+func builtinInt(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		return arg
+	case *object.BigInt:
+		if !arg.Value.IsInt64() {
+			return newError("%s does not fit in INTEGER", arg.Inspect())
+		}
+		return &object.Integer{Value: arg.Value.Int64()}
+	default:
+		return newError("argument to `int` must be INTEGER or BIGINT, got %s", arg.Type())
+	}
+}
+
+// bigint(x) converts an integer or the decimal digits of a string to a big integer
+func builtinBigInt(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		return &object.BigInt{Value: big.NewInt(arg.Value)}
+	case *object.BigInt:
+		return arg
+	case *object.String:
+		value, ok := new(big.Int).SetString(strings.TrimSpace(string(arg.Value)), 10)
+		if !ok {
+			return newError("invalid integer %q in `bigint`", string(arg.Value))
+		}
+		return &object.BigInt{Value: value}
+	default:
+		return newError("argument to `bigint` must be INTEGER, BIGINT or STRING, got %s", arg.Type())
+	}
+}
@@ -0,0 +1,109 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/object"
+	"testing"
+)
+
+func TestBigInt(t *testing.T) {
+	InitBuiltins()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"123456789012345678901234567890n", "123456789012345678901234567890"},
+		{"type(5n)", "BIGINT"},
+		{"100000000000000000000 + 1", "100000000000000000001"},
+		{"type(100000000000000000000)", "BIGINT"},
+		{"-9223372036854775808", "-9223372036854775808"},
+		{"type(-9223372036854775808)", "INTEGER"},
+		{"type(9223372036854775807 + 1)", "BIGINT"},
+		{"type(9223372036854775807 + 0)", "INTEGER"},
+		{"type(9223372036854775807 + 1 - 1)", "INTEGER"},
+		{"type(5n * 2)", "INTEGER"},
+		{"type(-(-9223372036854775807 - 1))", "BIGINT"},
+		{"type(abs(-5n))", "INTEGER"},
+		{"let n = 9223372036854775807 + 1 - 9223372036854775807; [1, 2, 3][n]", "2"},
+		{`[[1, 2, 3][1n], "abc"[2n], [1][100000000000000000000n]]`, "[2, c, null]"},
+		{`substr("hello", 1n, 3)`, "el"},
+		{"range(bigint(3))", "[0, 1, 2]"},
+		{`repeat("a", 100000000000000000000n)`, "argument to `repeat` must be INTEGER, got BIGINT"},
+		{"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(25)", "15511210043330985984000000"},
+		{"sum([9223372036854775807, 9223372036854775807, 2])", "18446744073709551616"},
+		// The operators
+		{"10n + 5", "15"},
+		{"10 - 15n", "-5"},
+		{"-7n * 3", "-21"},
+		{"-7n / 2", "-3"},
+		{"-7n % 2", "-1"},
+		{"-5n", "-5"},
+		{"5n == 5", "true"},
+		{"5 != 5n", "false"},
+		{"100000000000000000000n > 9223372036854775807", "true"},
+		{"1n < 0", "false"},
+		{"1n + 0.5", "1.5"},
+		{"5n / 0", "the right operand of / is 0"},
+		{"5n % 0n", "the right operand of % is 0"},
+		{`5n + "a"`, "type mismatch: BIGINT + STRING"},
+		{"5n && 1", "unknown operator: BIGINT && INTEGER"},
+		// The hash keys, a big integer fitting in int64 is the same key as the integer
+		{`let h = {1n: "a", 100000000000000000000n: "b"}; [h[1], h[100000000000000000000n], len(h)]`, "[a, b, 2]"},
+		{`let h = {1: "a"}; set(h, 1n, "b")[1]`, "b"},
+		// The conversions
+		{"int(5n)", "5"},
+		{"type(int(5n))", "INTEGER"},
+		{"int(9223372036854775807 + 1)", "9223372036854775808 does not fit in INTEGER"},
+		{"int(1.5)", "argument to `int` must be INTEGER or BIGINT, got FLOAT"},
+		{`bigint(" 123456789012345678901234567890 ")`, "123456789012345678901234567890"},
+		{"type(bigint(5))", "BIGINT"},
+		{`bigint("12a")`, "invalid integer \"12a\" in `bigint`"},
+		// The other builtins
+		{"pow(2, 100)", "1267650600228229401496703205376"},
+		{"pow(10n, 3)", "1000"},
+		{"pow(2, 100n)", "1267650600228229401496703205376"},
+		{"pow(-1, 100000000000000000001n)", "-1"},
+		{"pow(3, 10000000000000)", "result of `pow` is larger than 16777216 bits"},
+		{"pow(100000000000000000000n, 9223372036854775807)", "result of `pow` is larger than 16777216 bits"},
+		{"pow(2, 100000000000000000000n)", "result of `pow` is larger than 16777216 bits"},
+		{"pow(2, 8388608) > 0", "true"},
+		{"pow(2, 8388609)", "result of `pow` is larger than 16777216 bits"},
+		{"pow(1, 100000000000000000000n)", "1"},
+		{"abs(-100000000000000000000n)", "100000000000000000000"},
+		{"max(1, 100000000000000000000n, 2.5)", "100000000000000000000"},
+		{"sort([3n, 1, 2n])", "[1, 2, 3]"},
+		{"floor(5n)", "5"},
+		{"sqrt(100n)", "10.0"},
+		{"assert_eq(2n, 2)", "null"},
+		{`str(5n) + "!"`, "5!"},
+	}
+
+	for _, tt := range tests {
+		if got := evalWithRuntime(tt.input, &object.Runtime{}); got != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestBigIntChecked(t *testing.T) {
+	InitBuiltins()
+
+	// The big integers are not checked, only the integers overflowing are
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775807n + 1", "9223372036854775808"},
+		{"9223372036854775807 + 1", "integer overflow: 9223372036854775807 + 1"},
+		{"sum([9223372036854775807, 1])", "integer overflow: 9223372036854775807 + 1"},
+		{"sum([1, 9223372036854775807n])", "9223372036854775808"},
+		// A result fitting in int64 is an integer again, so it is checked
+		{"9223372036854775807n - 1 + 2", "integer overflow: 9223372036854775806 + 2"},
+	}
+
+	for _, tt := range tests {
+		if got := evalWithRuntime(tt.input, &object.Runtime{CheckOverflow: true}); got != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/lxdlam/monkey-plus/parser"
 	"io"
 	"os"
+	"sort"
 	"strings"
 )
 
@@ -138,8 +139,17 @@ func InitBuiltins() {
 					return newError("wrong number of arguments. got=%d, want=2", len(args))
 				}
 
+				// A string contains its substrings
+				if str, ok := args[0].(*object.String); ok {
+					sub, ok := args[1].(*object.String)
+					if !ok {
+						return newError("argument to `contains` must be STRING, got %s", args[1].Type())
+					}
+					return nativeBoolToBooleanObject(bytes.Contains(str.Value, sub.Value))
+				}
+
 				if args[0].Type() != object.HASH_OBJ {
-					return newError("argument to `contains` must be HASH, got %s", args[0].Type())
+					return newError("argument to `contains` must be HASH or STRING, got %s", args[0].Type())
 				}
 
 				if _, ok := args[1].(object.Hashable); !ok {
@@ -173,7 +183,7 @@ func InitBuiltins() {
 		"puts": &object.Builtin{
 			Fn: func(env *object.Environment, args ...object.Object) object.Object {
 				for _, arg := range args {
-					fmt.Println(arg.Inspect())
+					fmt.Fprintln(env.Runtime().Out(), arg.Inspect())
 				}
 				return NULL
 			},
@@ -188,7 +198,8 @@ func InitBuiltins() {
 					return newError("argument to `eval` must be STRING, got %s", args[0].Type())
 				}
 
-				return runCodeInner(strings.NewReader(string(args[0].(*object.String).Value)), object.NewEnvironment())
+				newEnv := object.NewEnvironmentWithRuntime(env.Runtime())
+				return runCodeInner(strings.NewReader(string(args[0].(*object.String).Value)), "", newEnv)
 			},
 		},
 		"load": &object.Builtin{
@@ -201,17 +212,22 @@ func InitBuiltins() {
 					return newError("argument to `load` must be STRING, got %s", args[0].Type())
 				}
 
-				newEnv := object.NewEnvironment()
+				newEnv := object.NewEnvironmentWithRuntime(env.Runtime())
 				path := string(args[0].(*object.String).Value)
 
-				file, err := os.Open(path)
+				resolved, err := env.Runtime().CheckPath(path, false)
+				if err != nil {
+					return newError("load %s failed: %s", path, err)
+				}
+
+				file, err := os.Open(resolved)
 				if err != nil {
 					return newError("load %s failed", path)
 				}
 
 				defer file.Close()
 
-				result := runCodeInner(bufio.NewReader(file), newEnv)
+				result := runCodeInner(bufio.NewReader(file), path, newEnv)
 
 				if isError(result) {
 					return newError("load %s failed. Inner error is: %s", path, result.(*object.Error).Message)
@@ -230,10 +246,166 @@ func InitBuiltins() {
 				return object.NewStringObject(string(args[0].Type()))
 			},
 		},
+		"assert": &object.Builtin{
+			Fn: func(env *object.Environment, args ...object.Object) object.Object {
+				if len(args) != 1 && len(args) != 2 {
+					return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+				}
+
+				if isTruthy(args[0]) {
+					return NULL
+				}
+
+				err := newFailure("assert", nil, args[0], "assertion failed")
+				if len(args) == 2 {
+					err.Message += ": " + args[1].Inspect()
+				}
+
+				return err
+			},
+		},
+		"assert_eq": &object.Builtin{
+			Fn: func(env *object.Environment, args ...object.Object) object.Object {
+				if len(args) != 2 && len(args) != 3 {
+					return newError("wrong number of arguments. got=%d, want=2 or 3", len(args))
+				}
+
+				actual, expected := args[0], args[1]
+				if objectsEqual(actual, expected) {
+					return NULL
+				}
+
+				var err *object.Error
+				if actual.Type() != expected.Type() {
+					err = newFailure("assert_eq", expected, actual, "assertion failed: got=%s (%s), want=%s (%s)",
+						actual.Inspect(), actual.Type(), expected.Inspect(), expected.Type())
+				} else {
+					err = newFailure("assert_eq", expected, actual, "assertion failed: got=%s, want=%s",
+						actual.Inspect(), expected.Inspect())
+				}
+
+				if len(args) == 3 {
+					err.Message += ": " + args[2].Inspect()
+				}
+
+				return err
+			},
+		},
+		"assert_error": &object.Builtin{
+			Fn: func(env *object.Environment, args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1", len(args))
+				}
+
+				if args[0].Type() != object.FUNC_OBJ && args[0].Type() != object.BUILTIN_OBJ {
+					return newError("argument to `assert_error` must be FUNCTION, got %s", args[0].Type())
+				}
+
+				result := applyFunction(args[0], []object.Object{}, env)
+				if errObj, ok := result.(*object.Error); ok {
+					return object.NewStringObject(errObj.Message)
+				}
+
+				if result == nil {
+					result = NULL
+				}
+
+				return newFailure("assert_error", nil, result, "assertion failed: expected an error, got=%s", result.Inspect())
+			},
+		},
+	}
+
+	for _, extra := range []map[string]*object.Builtin{collectionBuiltins(), stringBuiltins(), formatBuiltins(), regexBuiltins(), jsonBuiltins(), fileBuiltins(), inputBuiltins(), mathBuiltins(), bigIntBuiltins(), randomBuiltins(), timeBuiltins()} {
+		for name, builtin := range extra {
+			builtins[name] = builtin
+		}
+	}
+}
+
+// BuiltinNames returns the sorted names of the builtins and the constants, InitBuiltins must be
+// called first
+func BuiltinNames() []string {
+	names := []string{}
+	for name := range builtins {
+		names = append(names, name)
+	}
+	for name := range constants {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func newFailure(assertion string, expected, actual object.Object, format string, a ...interface{}) *object.Error {
+	err := newError(format, a...)
+	err.Failure = &object.Failure{Assertion: assertion, Expected: expected, Actual: actual}
+	return err
+}
+
+func objectsEqual(left, right object.Object) bool {
+	// An integer equals the big integer of the same value
+	if isInteger(left) && isInteger(right) {
+		return toBig(left).Cmp(toBig(right)) == 0
+	}
+
+	if left.Type() != right.Type() {
+		return false
+	}
+
+	switch left := left.(type) {
+	case *object.Integer:
+		return left.Value == right.(*object.Integer).Value
+	case *object.Float:
+		return left.Value == right.(*object.Float).Value
+	case *object.Boolean:
+		return left.Value == right.(*object.Boolean).Value
+	case *object.String:
+		return left.Compare(right.(*object.String)) == 0
+	case *object.Null:
+		return true
+	case *object.Regex:
+		return left.Value.String() == right.(*object.Regex).Value.String()
+	case *object.Time:
+		return left.Value.Equal(right.(*object.Time).Value)
+	case *object.Array:
+		other := right.(*object.Array)
+		if len(left.Elements) != len(other.Elements) {
+			return false
+		}
+
+		for idx, el := range left.Elements {
+			if !objectsEqual(el, other.Elements[idx]) {
+				return false
+			}
+		}
+
+		return true
+	case *object.Hash:
+		other := right.(*object.Hash)
+		if left.Len() != other.Len() {
+			return false
+		}
+
+		for _, pairs := range left.Pairs {
+			for _, pair := range pairs {
+				value, ok := other.Get(pair.Key)
+				if !ok || !objectsEqual(pair.Value, value) {
+					return false
+				}
+			}
+		}
+
+		return true
+	case *object.Error:
+		return left.Message == right.(*object.Error).Message
+	default:
+		return left == right
 	}
 }
 
-func runCodeInner(in io.Reader, env *object.Environment) object.Object {
+func runCodeInner(in io.Reader, file string, env *object.Environment) object.Object {
 	scanner := bufio.NewScanner(in)
 
 	var codes bytes.Buffer
@@ -251,6 +423,7 @@ func runCodeInner(in io.Reader, env *object.Environment) object.Object {
 	p := parser.New(l)
 
 	program := p.ParseProgram()
+	program.File = file
 
 	errorLength := len(p.Errors())
 	if errorLength != 0 {
@@ -0,0 +1,595 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/object"
+	"sort"
+)
+
+// collectionBuiltins are the higher-order builtins over the arrays, the strings and the hashes.
+// The callbacks may be Monkey functions or builtins, the strings are iterated by characters and
+// the hashes by their pairs.
+func collectionBuiltins() map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"map":     {Fn: builtinMap},
+		"filter":  {Fn: builtinFilter},
+		"reduce":  {Fn: builtinReduce},
+		"each":    {Fn: builtinEach},
+		"any":     {Fn: builtinAny},
+		"all":     {Fn: builtinAll},
+		"find":    {Fn: builtinFind},
+		"sort":    {Fn: builtinSort},
+		"reverse": {Fn: builtinReverse},
+		"zip":     {Fn: builtinZip},
+		"flatten": {Fn: builtinFlatten},
+		"range":   {Fn: builtinRange},
+		"sum":     {Fn: builtinSum},
+		"min":     {Fn: builtinMin},
+		"max":     {Fn: builtinMax},
+	}
+}
+
+// elements returns the elements of an array or the characters of a string
+func elements(name string, arg object.Object) ([]object.Object, *object.Error) {
+	switch arg := arg.(type) {
+	case *object.Array:
+		return arg.Elements, nil
+	case *object.String:
+		chars := make([]object.Object, len(arg.Value))
+		for i, c := range arg.Value {
+			chars[i] = object.NewString(string([]uint8{c}))
+		}
+		return chars, nil
+	default:
+		return nil, newError("argument to `%s` must be ARRAY or STRING, got %s", name, arg.Type())
+	}
+}
+
+func joinChars(chars []object.Object) *object.String {
+	value := []uint8{}
+	for _, c := range chars {
+		value = append(value, c.(*object.String).Value...)
+	}
+
+	return object.NewString(string(value))
+}
+
+func checkCallback(name string, fn object.Object) *object.Error {
+	if fn.Type() != object.FUNC_OBJ && fn.Type() != object.BUILTIN_OBJ {
+		return newError("argument to `%s` must be FUNCTION, got %s", name, fn.Type())
+	}
+
+	return nil
+}
+
+// forEach calls the callback with every element, or with the key and the value of every pair of
+// a hash, until it returns false or an error
+func forEach(name string, coll, fn object.Object, env *object.Environment, f func(args []object.Object, result object.Object) bool) *object.Error {
+	if err := checkCallback(name, fn); err != nil {
+		return err
+	}
+
+	if hash, ok := coll.(*object.Hash); ok {
+		for _, pair := range hash.Items() {
+			args := []object.Object{pair.Key, pair.Value}
+			result := applyFunction(fn, args, env)
+			if err, ok := result.(*object.Error); ok {
+				return err
+			}
+			if !f(args, result) {
+				return nil
+			}
+		}
+		return nil
+	}
+
+	elems, err := elements(name, coll)
+	if err != nil {
+		return newError("argument to `%s` must be ARRAY, STRING or HASH, got %s", name, coll.Type())
+	}
+
+	for _, el := range elems {
+		args := []object.Object{el}
+		result := applyFunction(fn, args, env)
+		if err, ok := result.(*object.Error); ok {
+			return err
+		}
+		if !f(args, result) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func builtinMap(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	if _, ok := args[0].(*object.Hash); ok {
+		hash := object.NewHash()
+		err := forEach("map", args[0], args[1], env, func(pair []object.Object, result object.Object) bool {
+			hash.Set(pair[0], result)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		return hash
+	}
+
+	results := []object.Object{}
+	err := forEach("map", args[0], args[1], env, func(_ []object.Object, result object.Object) bool {
+		results = append(results, result)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	return &object.Array{Elements: results}
+}
+
+func builtinFilter(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	if _, ok := args[0].(*object.Hash); ok {
+		hash := object.NewHash()
+		err := forEach("filter", args[0], args[1], env, func(pair []object.Object, result object.Object) bool {
+			if isTruthy(result) {
+				hash.Set(pair[0], pair[1])
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		return hash
+	}
+
+	kept := []object.Object{}
+	err := forEach("filter", args[0], args[1], env, func(el []object.Object, result object.Object) bool {
+		if isTruthy(result) {
+			kept = append(kept, el[0])
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	if args[0].Type() == object.STRING_OBJ {
+		return joinChars(kept)
+	}
+
+	return &object.Array{Elements: kept}
+}
+
+// reduce(coll, fn, initial) folds the elements from the left with fn(accumulated, element). The
+// first element is the initial value if it is not passed.
+func builtinReduce(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 && len(args) != 3 {
+		return newError("wrong number of arguments. got=%d, want=2 or 3", len(args))
+	}
+
+	elems, err := elements("reduce", args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := checkCallback("reduce", args[1]); err != nil {
+		return err
+	}
+
+	var accumulated object.Object
+	if len(args) == 3 {
+		accumulated = args[2]
+	} else if len(elems) == 0 {
+		return NULL
+	} else {
+		accumulated, elems = elems[0], elems[1:]
+	}
+
+	for _, el := range elems {
+		accumulated = applyFunction(args[1], []object.Object{accumulated, el}, env)
+		if isError(accumulated) {
+			return accumulated
+		}
+	}
+
+	return accumulated
+}
+
+func builtinEach(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	err := forEach("each", args[0], args[1], env, func(_ []object.Object, _ object.Object) bool {
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	return NULL
+}
+
+func builtinAny(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	found := false
+	err := forEach("any", args[0], args[1], env, func(_ []object.Object, result object.Object) bool {
+		found = isTruthy(result)
+		return !found
+	})
+	if err != nil {
+		return err
+	}
+
+	return nativeBoolToBooleanObject(found)
+}
+
+func builtinAll(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	all := true
+	err := forEach("all", args[0], args[1], env, func(_ []object.Object, result object.Object) bool {
+		all = isTruthy(result)
+		return all
+	})
+	if err != nil {
+		return err
+	}
+
+	return nativeBoolToBooleanObject(all)
+}
+
+// find returns the first element the callback is truthy for, null if there is none
+func builtinFind(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	if _, err := elements("find", args[0]); err != nil {
+		return err
+	}
+
+	var found object.Object = NULL
+	err := forEach("find", args[0], args[1], env, func(el []object.Object, result object.Object) bool {
+		if isTruthy(result) {
+			found = el[0]
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	return found
+}
+
+// compareObjects orders two numbers or two strings, an integer and a float are compared as floats
+func compareObjects(name string, left, right object.Object) (int, *object.Error) {
+	if isNumber(left) && isNumber(right) && (left.Type() == object.FLOAT_OBJ || right.Type() == object.FLOAT_OBJ) {
+		leftVal, rightVal := toFloat(left), toFloat(right)
+		switch {
+		case leftVal < rightVal:
+			return -1, nil
+		case leftVal > rightVal:
+			return 1, nil
+		default:
+			return 0, nil
+		}
+	}
+
+	if isInteger(left) && isInteger(right) && (left.Type() == object.BIGINT_OBJ || right.Type() == object.BIGINT_OBJ) {
+		return toBig(left).Cmp(toBig(right)), nil
+	}
+
+	switch left := left.(type) {
+	case *object.Integer:
+		if right, ok := right.(*object.Integer); ok {
+			switch {
+			case left.Value < right.Value:
+				return -1, nil
+			case left.Value > right.Value:
+				return 1, nil
+			default:
+				return 0, nil
+			}
+		}
+	case *object.String:
+		if right, ok := right.(*object.String); ok {
+			return left.Compare(right), nil
+		}
+	case *object.Time:
+		if right, ok := right.(*object.Time); ok {
+			switch {
+			case left.Value.Before(right.Value):
+				return -1, nil
+			case left.Value.After(right.Value):
+				return 1, nil
+			default:
+				return 0, nil
+			}
+		}
+	}
+
+	return 0, newError("unable to compare %s and %s in `%s`", left.Type(), right.Type(), name)
+}
+
+// sort(coll, less) returns the elements sorted stably. The integers and the strings are sorted
+// ascending, otherwise less(a, b) must tell whether a goes before b.
+func builtinSort(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	elems, err := elements("sort", args[0])
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 2 {
+		if err := checkCallback("sort", args[1]); err != nil {
+			return err
+		}
+	}
+
+	sorted := make([]object.Object, len(elems))
+	copy(sorted, elems)
+
+	// The first error stops the comparisons, the order is left unspecified then
+	var sortErr object.Object
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sortErr != nil {
+			return false
+		}
+
+		if len(args) == 2 {
+			result := applyFunction(args[1], []object.Object{sorted[i], sorted[j]}, env)
+			if isError(result) {
+				sortErr = result
+				return false
+			}
+			return isTruthy(result)
+		}
+
+		order, err := compareObjects("sort", sorted[i], sorted[j])
+		if err != nil {
+			sortErr = err
+			return false
+		}
+		return order < 0
+	})
+
+	if sortErr != nil {
+		return sortErr
+	}
+
+	if args[0].Type() == object.STRING_OBJ {
+		return joinChars(sorted)
+	}
+
+	return &object.Array{Elements: sorted}
+}
+
+func builtinReverse(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	elems, err := elements("reverse", args[0])
+	if err != nil {
+		return err
+	}
+
+	reversed := make([]object.Object, len(elems))
+	for i, el := range elems {
+		reversed[len(elems)-1-i] = el
+	}
+
+	if args[0].Type() == object.STRING_OBJ {
+		return joinChars(reversed)
+	}
+
+	return &object.Array{Elements: reversed}
+}
+
+// zip(a, b, ...) pairs up the elements at the same index, it stops at the end of the shortest
+func builtinZip(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) == 0 {
+		return newError("wrong number of arguments. got=0, want=1 or more")
+	}
+
+	columns := [][]object.Object{}
+	length := -1
+	for _, arg := range args {
+		elems, err := elements("zip", arg)
+		if err != nil {
+			return err
+		}
+
+		columns = append(columns, elems)
+		if length < 0 || len(elems) < length {
+			length = len(elems)
+		}
+	}
+
+	rows := make([]object.Object, length)
+	for i := range rows {
+		row := make([]object.Object, len(columns))
+		for j, column := range columns {
+			row[j] = column[i]
+		}
+		rows[i] = &object.Array{Elements: row}
+	}
+
+	return &object.Array{Elements: rows}
+}
+
+// flatten(a, depth) splices the nested arrays into a, all the levels if depth is not passed
+func builtinFlatten(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return newError("argument to `flatten` must be ARRAY, got %s", args[0].Type())
+	}
+
+	depth := int64(-1)
+	if len(args) == 2 {
+		d, ok := args[1].(*object.Integer)
+		if !ok || d.Value < 0 {
+			return newError("depth of `flatten` must be a non-negative INTEGER, got %s", args[1].Inspect())
+		}
+		depth = d.Value
+	}
+
+	return &object.Array{Elements: flatten([]object.Object{}, arr.Elements, depth)}
+}
+
+func flatten(result, elems []object.Object, depth int64) []object.Object {
+	for _, el := range elems {
+		if nested, ok := el.(*object.Array); ok && depth != 0 {
+			result = flatten(result, nested.Elements, depth-1)
+		} else {
+			result = append(result, el)
+		}
+	}
+
+	return result
+}
+
+// maxRangeLength keeps `range` from running out of memory
+const maxRangeLength = 1 << 24
+
+// range(end), range(start, end) and range(start, end, step) return the integers from start up to
+// but excluding end. start is 0 and step is 1 by default, a negative step counts down.
+func builtinRange(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 3 {
+		return newError("wrong number of arguments. got=%d, want=1 to 3", len(args))
+	}
+
+	bounds := []int64{}
+	for _, arg := range args {
+		integer, err := integerArg("range", arg)
+		if err != nil {
+			return err
+		}
+		bounds = append(bounds, integer)
+	}
+
+	start, end, step := int64(0), bounds[0], int64(1)
+	if len(bounds) > 1 {
+		start, end = bounds[0], bounds[1]
+	}
+	if len(bounds) > 2 {
+		step = bounds[2]
+	}
+
+	if step == 0 {
+		return newError("step of `range` must not be 0")
+	}
+
+	// The count is computed on unsigned integers, so neither it nor the elements overflow near the
+	// limits of int64
+	var count uint64
+	if step > 0 && start < end {
+		count = (uint64(end)-uint64(start)-1)/uint64(step) + 1
+	} else if step < 0 && start > end {
+		count = (uint64(start)-uint64(end)-1)/magnitude(step) + 1
+	}
+
+	if count > maxRangeLength {
+		return newError("result of `range` is longer than %d elements", maxRangeLength)
+	}
+
+	integers := make([]object.Object, count)
+	for i := range integers {
+		integers[i] = &object.Integer{Value: int64(uint64(start) + uint64(i)*uint64(step))}
+	}
+
+	return &object.Array{Elements: integers}
+}
+
+func builtinSum(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return newError("argument to `sum` must be ARRAY, got %s", args[0].Type())
+	}
+
+	// The sum overflows like the addition, and it is a float once a float is added
+	var sum object.Object = &object.Integer{Value: 0}
+	for _, el := range arr.Elements {
+		if !isNumber(el) {
+			return newError("elements of `sum` must be INTEGER, BIGINT or FLOAT, got %s", el.Type())
+		}
+		sum = evalInfixExpression("+", sum, el, env)
+		if isError(sum) {
+			return sum
+		}
+	}
+
+	return sum
+}
+
+func builtinMin(env *object.Environment, args ...object.Object) object.Object {
+	return extreme("min", -1, args)
+}
+
+func builtinMax(env *object.Environment, args ...object.Object) object.Object {
+	return extreme("max", 1, args)
+}
+
+// extreme returns the first of the smallest or the largest of the arguments, or of the elements
+// of a single array argument. It is null for an empty array.
+func extreme(name string, sign int, args []object.Object) object.Object {
+	if len(args) == 0 {
+		return newError("wrong number of arguments. got=0, want=1 or more")
+	}
+
+	candidates := args
+	if len(args) == 1 {
+		arr, ok := args[0].(*object.Array)
+		if !ok {
+			return newError("argument to `%s` must be ARRAY, got %s", name, args[0].Type())
+		}
+		candidates = arr.Elements
+	}
+
+	if len(candidates) == 0 {
+		return NULL
+	}
+
+	result := candidates[0]
+	if _, err := compareObjects(name, result, result); err != nil {
+		return err
+	}
+
+	for _, candidate := range candidates[1:] {
+		order, err := compareObjects(name, candidate, result)
+		if err != nil {
+			return err
+		}
+		if order*sign > 0 {
+			result = candidate
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,122 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/object"
+	"testing"
+)
+
+func TestCollectionBuiltins(t *testing.T) {
+	InitBuiltins()
+
+	tests := []struct {
+		input string
+		// expected is the inspected result, or the message if it is an error
+		expected string
+	}{
+		{"map([1, 2, 3], fn(x) { x * 2 })", "[2, 4, 6]"},
+		{"map([], fn(x) { x })", "[]"},
+		{`map("abc", fn(c) { c + c })`, "[aa, bb, cc]"},
+		{`map([[1], [2, 3]], len)`, "[1, 2]"},
+		{`map({"a": 1}, fn(k, v) { k + "=" })["a"]`, "a="},
+		{"map([1], fn(x) { x / 0 })", "the right operand of / is 0"},
+		{"map(1, fn(x) { x })", "argument to `map` must be ARRAY, STRING or HASH, got INTEGER"},
+		{"map([1], 1)", "argument to `map` must be FUNCTION, got INTEGER"},
+		{"map([1], fn(x, y) { x })", "wrong number of arguments. got=1, want=2"},
+		{"filter([1, 2, 3, 4], fn(x) { x % 2 == 0 })", "[2, 4]"},
+		{`filter("a1b2", fn(c) { c < "a" })`, "12"},
+		{`let h = filter({"a": 1, "b": 2, "c": 3}, fn(k, v) { v > 1 }); [len(h), h["b"], h["a"]]`, "[2, 2, null]"},
+		{"reduce([1, 2, 3, 4], fn(acc, x) { acc + x }, 10)", "20"},
+		{"reduce([1, 2, 3, 4], fn(acc, x) { acc * x })", "24"},
+		{"reduce([], fn(acc, x) { acc + x })", "null"},
+		{"reduce([], fn(acc, x) { acc + x }, 0)", "0"},
+		{`reduce("abc", fn(acc, c) { c + acc }, "")`, "cba"},
+		{"reduce([1], fn(acc, x) { acc }, 0, 1)", "wrong number of arguments. got=4, want=2 or 3"},
+		{"each([1, 2], fn(x) { x * 2 })", "null"},
+		{"each([1, 2], fn(x) { x / 0 })", "the right operand of / is 0"},
+		{`each({"a": 1}, fn(k, v) { k })`, "null"},
+		{"any([1, 3, 5, 7, 9], fn(x) { x % 2 == 0 })", "false"},
+		{"any([1, 3, 10], fn(x) { x % 2 == 0 })", "true"},
+		{"any([], fn(x) { true })", "false"},
+		{"any([2, 1], fn(x) { if (x == 1) { x / 0 } else { true } })", "true"},
+		{`any({"a": 1}, fn(k, v) { v == 1 })`, "true"},
+		{"all([2, 4], fn(x) { x % 2 == 0 })", "true"},
+		{"all([2, 3], fn(x) { x % 2 == 0 })", "false"},
+		{"all([], fn(x) { false })", "true"},
+		{"find([1, 4, 6], fn(x) { x > 3 })", "4"},
+		{"find([1, 2], fn(x) { x > 3 })", "null"},
+		{`find("monkey", fn(c) { c > "n" })`, "o"},
+		{`find({"a": 1}, fn(k, v) { true })`, "argument to `find` must be ARRAY or STRING, got HASH"},
+		{"sort([3, 1, 2])", "[1, 2, 3]"},
+		{`sort(["b", "c", "a"])`, "[a, b, c]"},
+		{`sort("monkey")`, "ekmnoy"},
+		{"sort([])", "[]"},
+		{"sort([3, 1, 2], fn(a, b) { a > b })", "[3, 2, 1]"},
+		{
+			`map(sort([[2, "a"], [1, "b"], [2, "c"], [1, "d"]], fn(a, b) { a[0] < b[0] }), fn(p) { p[1] })`,
+			"[b, d, a, c]",
+		},
+		{`sort([1, "a"])`, "unable to compare STRING and INTEGER in `sort`"},
+		{"sort([1, 2], fn(a, b) { a / 0 })", "the right operand of / is 0"},
+		{"let xs = [2, 1]; sort(xs); xs", "[2, 1]"},
+		{"reverse([1, 2, 3])", "[3, 2, 1]"},
+		{`reverse("abc")`, "cba"},
+		{"reverse({})", "argument to `reverse` must be ARRAY or STRING, got HASH"},
+		{`zip([1, 2, 3], ["a", "b"])`, "[[1, a], [2, b]]"},
+		{`zip([1, 2], "xy", [true, false])`, "[[1, x, true], [2, y, false]]"},
+		{"zip()", "wrong number of arguments. got=0, want=1 or more"},
+		{"flatten([1, [2, [3, [4]]], []])", "[1, 2, 3, 4]"},
+		{"flatten([1, [2, [3, [4]]]], 1)", "[1, 2, [3, [4]]]"},
+		{"flatten([[1]], 0)", "[[1]]"},
+		{"flatten([1], -1)", "depth of `flatten` must be a non-negative INTEGER, got -1"},
+		{"range(4)", "[0, 1, 2, 3]"},
+		{"range(2, 5)", "[2, 3, 4]"},
+		{"range(10, 0, -3)", "[10, 7, 4, 1]"},
+		{"range(0, 10, 4)", "[0, 4, 8]"},
+		{"range(5, 2)", "[]"},
+		{"range(9223372036854775800, 9223372036854775807, 10)", "[9223372036854775800]"},
+		{"range(9223372036854775800, 9223372036854775807, 3)", "[9223372036854775800, 9223372036854775803, 9223372036854775806]"},
+		{"range(-9223372036854775800, -9223372036854775807 - 1, -10)", "[-9223372036854775800]"},
+		{"range(-9223372036854775807 - 1, 9223372036854775807, 9223372036854775807)", "[-9223372036854775808, -1, 9223372036854775806]"},
+		{"range(9223372036854775807, -9223372036854775807 - 1, -9223372036854775807 - 1)", "[9223372036854775807, -1]"},
+		{"range(-9223372036854775807 - 1, 9223372036854775807)", "result of `range` is longer than 16777216 elements"},
+		{"range(0, 1, 0)", "step of `range` must not be 0"},
+		{`range("a")`, "argument to `range` must be INTEGER, got STRING"},
+		{"sum([1, 2, 3])", "6"},
+		{"sum([])", "0"},
+		{`sum([1, "a"])`, "elements of `sum` must be INTEGER, BIGINT or FLOAT, got STRING"},
+		{"sum([1.5, 2])", "3.5"},
+		{"sum([1, 2.0])", "3.0"},
+		{"sum(range(101))", "5050"},
+		{"min([3, 1, 2])", "1"},
+		{"max([3, 1, 2])", "3"},
+		{"min(3, 1, 2)", "1"},
+		{`max("b", "c", "a")`, "c"},
+		{"min([])", "null"},
+		{"min(1)", "argument to `min` must be ARRAY, got INTEGER"},
+		{`max(1, "a")`, "unable to compare STRING and INTEGER in `max`"},
+		{"max([fn() {}])", "unable to compare FUNCTION and FUNCTION in `max`"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		var got string
+		if err, ok := evaluated.(*object.Error); ok {
+			got = err.Message
+		} else {
+			got = evaluated.Inspect()
+		}
+
+		if got != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestCollectionBuiltinsTailCalls(t *testing.T) {
+	InitBuiltins()
+
+	// The callbacks run their tail calls like the other calls
+	evaluated := testEval("let count = fn(n) { if (n == 0) { true } else { count(n - 1) } }; all([100000], count)")
+	testBooleanObject(t, evaluated, true)
+}
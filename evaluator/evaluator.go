@@ -4,6 +4,11 @@ import (
 	"fmt"
 	"github.com/lxdlam/monkey-plus/ast"
 	"github.com/lxdlam/monkey-plus/object"
+	"github.com/lxdlam/monkey-plus/resolver"
+	"github.com/lxdlam/monkey-plus/suggest"
+	"github.com/lxdlam/monkey-plus/token"
+	"math"
+	"math/big"
 )
 
 var (
@@ -20,6 +25,10 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return Eval(node.Expression, env)
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+	case *ast.BigIntLiteral:
+		return &object.BigInt{Value: node.Value}
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 	case *ast.PrefixExpression:
@@ -27,7 +36,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(right) {
 			return right
 		}
-		return evalPrefixExpression(node.Operator, right)
+		return evalPrefixExpression(node.Operator, right, env)
 	case *ast.InfixExpression:
 		left := Eval(node.Left, env)
 		if isError(left) {
@@ -37,7 +46,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(right) {
 			return right
 		}
-		return evalInfixExpression(node.Operator, left, right)
+		return evalInfixExpression(node.Operator, left, right, env)
 	case *ast.BlockStatement:
 		return evalBlockStatements(node, env)
 	case *ast.IfExpression:
@@ -53,13 +62,17 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+		if node.Name.Slot != nil {
+			env.SetSlot(node.Name.Slot.Index, val)
+		} else {
+			env.Set(node.Name.Value, val)
+		}
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{Parameters: params, Body: body, Env: env}
+		return &object.Function{Parameters: params, Body: body, Env: env, Name: node.Name, Locals: node.Locals}
 	case *ast.CallExpression:
 		function := Eval(node.Function, env)
 		if isError(function) {
@@ -71,9 +84,16 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return args[0]
 		}
 
+		// The caller is left before running the tail calls, see applyFunction
+		if fn, ok := function.(*object.Function); ok && node.Tail {
+			return &tailCall{function: fn, arguments: args}
+		}
+
 		return applyFunction(function, args, env)
 	case *ast.StringLiteral:
 		return object.NewStringObject(node.Value)
+	case *ast.InterpolatedString:
+		return evalInterpolatedString(node, env)
 	case *ast.ArrayLiteral:
 		elements, ok := evalExpressions(node.Elements, env)
 		if !ok {
@@ -98,8 +118,19 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 }
 
 func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+	for _, hook := range env.Runtime().Hooks {
+		hook.LoadProgram(program)
+	}
+
+	if !env.Runtime().NoSlots {
+		resolver.AssignSlots(program)
+	}
+
 	var result object.Object
 	for _, statement := range program.Statements {
+		if err := beforeStatement(statement, env); err != nil {
+			return err
+		}
 		result = Eval(statement, env)
 
 		switch result := result.(type) {
@@ -121,12 +152,12 @@ func nativeBoolToBooleanObject(input bool) *object.Boolean {
 	return FALSE
 }
 
-func evalPrefixExpression(operator string, right object.Object) object.Object {
+func evalPrefixExpression(operator string, right object.Object, env *object.Environment) object.Object {
 	switch operator {
 	case "!":
 		return evalBangOperatorExpression(right)
 	case "-":
-		return evalMinusPrefixOperatorExpression(right)
+		return evalMinusPrefixOperatorExpression(right, env.Runtime().CheckOverflow)
 	default:
 		return newError("unknown operator: %s%s", operator, right.Type())
 	}
@@ -145,23 +176,39 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 	}
 }
 
-func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
+func evalMinusPrefixOperatorExpression(right object.Object, checked bool) object.Object {
+	switch right := right.(type) {
+	case *object.Integer:
+		if right.Value == math.MinInt64 {
+			if checked {
+				return newError("integer overflow: -%d", right.Value)
+			}
+			return &object.BigInt{Value: new(big.Int).Neg(big.NewInt(right.Value))}
+		}
+		return &object.Integer{Value: -right.Value}
+	case *object.BigInt:
+		return bigResult(new(big.Int).Neg(right.Value))
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
 		return newError("unknown operator: -%s", right.Type())
 	}
-
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
 }
 
-func evalInfixExpression(operator string, left, right object.Object) object.Object {
+func evalInfixExpression(operator string, left, right object.Object, env *object.Environment) object.Object {
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
-		return evalIntegerInfixExpression(operator, left, right)
+		return evalIntegerInfixExpression(operator, left, right, env.Runtime().CheckOverflow)
+	case isInteger(left) && isInteger(right):
+		return evalBigIntInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right) && (left.Type() == object.FLOAT_OBJ || right.Type() == object.FLOAT_OBJ):
+		return evalFloatInfixExpression(operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
 	case left.Type() == object.BOOLEAN_OBJ && right.Type() == object.BOOLEAN_OBJ:
 		return evalBooleanInfixExpression(operator, left, right)
+	case left.Type() == object.TIME_OBJ && (right.Type() == object.TIME_OBJ || right.Type() == object.INTEGER_OBJ):
+		return evalTimeInfixExpression(operator, left, right)
 	case operator == "==":
 		return nativeBoolToBooleanObject(left == right)
 	case operator == "!=":
@@ -173,20 +220,30 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
 	}
 }
 
-func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
+// evalIntegerInfixExpression promotes the result to a big integer on overflow, unless checked where
+// the overflow is an error
+func evalIntegerInfixExpression(operator string, left, right object.Object, checked bool) object.Object {
 	leftVal := left.(*object.Integer).Value
 	rightVal := right.(*object.Integer).Value
 
 	switch operator {
-	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
-	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
-	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
+	case "+", "-", "*":
+		result, ok := integerArithmetic(operator, leftVal, rightVal)
+		if !ok {
+			if checked {
+				return newError("integer overflow: %d %s %d", leftVal, operator, rightVal)
+			}
+			return evalBigIntInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: result}
 	case "/":
 		if rightVal == 0 {
 			return newError("the right operand of / is 0")
+		} else if leftVal == math.MinInt64 && rightVal == -1 {
+			if checked {
+				return newError("integer overflow: %d / %d", leftVal, rightVal)
+			}
+			return evalBigIntInfixExpression(operator, left, right)
 		} else {
 			return &object.Integer{Value: leftVal / rightVal}
 		}
@@ -209,6 +266,77 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 	}
 }
 
+// integerArithmetic computes + - or * and reports whether the result did not overflow
+func integerArithmetic(operator string, a, b int64) (int64, bool) {
+	switch operator {
+	case "+":
+		result := a + b
+		return result, (result > a) == (b > 0)
+	case "-":
+		result := a - b
+		return result, (result < a) == (b > 0)
+	default:
+		result := a * b
+		if a == 0 || b == 0 {
+			return result, true
+		}
+		return result, result/b == a && !(b == -1 && a == math.MinInt64)
+	}
+}
+
+func isNumber(obj object.Object) bool {
+	return isInteger(obj) || obj.Type() == object.FLOAT_OBJ
+}
+
+// toFloat converts a number to float64, the big integers out of range are infinite
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.BigInt:
+		value, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return value
+	default:
+		return obj.(*object.Float).Value
+	}
+}
+
+// evalFloatInfixExpression evaluates the operations on two numbers with at least a float, the
+// integer is converted to a float
+func evalFloatInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := toFloat(left)
+	rightVal := toFloat(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+	case "/":
+		if rightVal == 0 {
+			return newError("the right operand of / is 0")
+		}
+		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError("the right operand of %% is 0")
+		}
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(ie.Condition, env)
 
@@ -242,6 +370,9 @@ func evalBlockStatements(block *ast.BlockStatement, env *object.Environment) obj
 	var result object.Object
 
 	for _, statement := range block.Statements {
+		if err := beforeStatement(statement, env); err != nil {
+			return err
+		}
 		result = Eval(statement, env)
 
 		if result != nil {
@@ -252,9 +383,35 @@ func evalBlockStatements(block *ast.BlockStatement, env *object.Environment) obj
 		}
 	}
 
+	// The blocks are the values of the calls and the `if` expressions, an empty block or a let
+	// statement at the end has no value
+	if result == nil {
+		return NULL
+	}
+
 	return result
 }
 
+func beforeStatement(stmt ast.Statement, env *object.Environment) *object.Error {
+	runtime := env.Runtime()
+	if err := runtime.Err(); err != nil {
+		return newError("evaluation canceled: %s", err)
+	}
+
+	if runtime.MaxSteps > 0 {
+		if runtime.Steps >= runtime.MaxSteps {
+			return newError("step limit exceeded: %d statements", runtime.MaxSteps)
+		}
+		runtime.Steps++
+	}
+
+	for _, hook := range runtime.Hooks {
+		hook.BeforeStatement(stmt, env)
+	}
+
+	return nil
+}
+
 func newError(format string, a ...interface{}) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
@@ -267,6 +424,13 @@ func isError(obj object.Object) bool {
 }
 
 func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	// The slot is empty if its let statement did not run, then the outer names are looked up
+	if node.Slot != nil {
+		if val, ok := env.GetSlot(node.Slot.Depth, node.Slot.Index); ok {
+			return val
+		}
+	}
+
 	if val, ok := env.Get(node.Value); ok {
 		return val
 	}
@@ -275,7 +439,30 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 		return builtin
 	}
 
-	return newError("identifier not found: " + node.Value)
+	if constant, ok := constants[node.Value]; ok {
+		return constant
+	}
+
+	return newError("identifier not found: " + node.Value + suggest.DidYouMean(node.Value, candidates(env)))
+}
+
+// candidates lists the names visible from the environment, the builtins and the keywords
+func candidates(env *object.Environment) []string {
+	names := token.Keywords()
+
+	for e := env; e != nil; e = e.Outer() {
+		names = append(names, e.Names()...)
+	}
+
+	for name := range builtins {
+		names = append(names, name)
+	}
+
+	for name := range constants {
+		names = append(names, name)
+	}
+
+	return names
 }
 
 func evalExpressions(exps []ast.Expression, env *object.Environment) ([]object.Object, bool) {
@@ -296,9 +483,37 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) ([]object.O
 func applyFunction(fn object.Object, args []object.Object, globalEnv *object.Environment) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
-		extendedEnv := extendFunctionEnv(fn, args)
-		evaluated := Eval(fn.Body, extendedEnv)
-		return unwrapReturnValue(evaluated)
+		hooks := fn.Env.Runtime().Hooks
+
+		// The tail calls are returned by the body and run in this loop, so the tail recursion
+		// runs in constant stack
+		for {
+			if len(args) != len(fn.Parameters) {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), len(fn.Parameters))
+			}
+
+			for _, hook := range hooks {
+				hook.EnterFunction(fn, args)
+			}
+
+			extendedEnv := extendFunctionEnv(fn, args)
+			evaluated := unwrapReturnValue(Eval(fn.Body, extendedEnv))
+
+			call, ok := evaluated.(*tailCall)
+			if !ok {
+				for _, hook := range hooks {
+					hook.ExitFunction(fn, evaluated)
+				}
+
+				return evaluated
+			}
+
+			for _, hook := range hooks {
+				hook.ExitFunction(fn, nil)
+			}
+
+			fn, args = call.function, call.arguments
+		}
 	case *object.Builtin:
 		return fn.Fn(globalEnv, args...)
 	default:
@@ -306,11 +521,29 @@ func applyFunction(fn object.Object, args []object.Object, globalEnv *object.Env
 	}
 }
 
+// tailCall is a call in tail position evaluated by the function applying the current one
+type tailCall struct {
+	function  *object.Function
+	arguments []object.Object
+}
+
+func (tc *tailCall) Type() object.ObjectType { return "TAIL_CALL" }
+func (tc *tailCall) Inspect() string         { return "tail call" }
+
+// Call applies a function object from outside of the evaluator, e.g. the test runner
+func Call(fn object.Object, args []object.Object, env *object.Environment) object.Object {
+	return applyFunction(fn, args, env)
+}
+
 func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
-	env := object.NewEnclosedEnvironment(fn.Env)
+	env := object.NewFunctionEnvironment(fn.Env, fn.Locals)
 
 	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx])
+		if param.Slot != nil {
+			env.SetSlot(param.Slot.Index, args[paramIdx])
+		} else {
+			env.Set(param.Value, args[paramIdx])
+		}
 	}
 
 	return env
@@ -346,6 +579,10 @@ func evalStringInfixExpression(operator string, left, right object.Object) objec
 }
 
 func evalIndexExpression(left, index object.Object) object.Object {
+	if integer, ok := smallInteger(index); ok {
+		index = integer
+	}
+
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
@@ -353,6 +590,9 @@ func evalIndexExpression(left, index object.Object) object.Object {
 		return evalHashIndexExpression(left, index)
 	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalStringIndexExpression(left, index)
+	case (left.Type() == object.ARRAY_OBJ || left.Type() == object.STRING_OBJ) && index.Type() == object.BIGINT_OBJ:
+		// The big integers left do not fit in int64, they are out of range
+		return NULL
 	default:
 		return newError("index operator not supported: %s", left.Type())
 	}
@@ -373,7 +613,17 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
 	hash := object.NewHash()
 
-	for keyNode, valueNode := range node.Pairs {
+	// The pairs are evaluated in the source order, which the hash keeps
+	keys := node.Keys
+	if len(keys) != len(node.Pairs) {
+		keys = make([]ast.Expression, 0, len(node.Pairs))
+		for keyNode := range node.Pairs {
+			keys = append(keys, keyNode)
+		}
+	}
+
+	for _, keyNode := range keys {
+		valueNode := node.Pairs[keyNode]
 		key := Eval(keyNode, env)
 		if isError(key) {
 			return key
@@ -1,6 +1,7 @@
 package evaluator
 
 import (
+	"github.com/lxdlam/monkey-plus/ast"
 	"github.com/lxdlam/monkey-plus/lexer"
 	"github.com/lxdlam/monkey-plus/object"
 	"github.com/lxdlam/monkey-plus/parser"
@@ -181,6 +182,9 @@ func TestIfElseExpressions(t *testing.T) {
 		{"if (1 > 2) { 10 }", nil},
 		{"if (1 > 2) { 10 } else { 20 }", 20},
 		{"if (1 < 2) { 10 } else { 20 }", 10},
+		{"if (true) {}", nil},
+		{"if (true) { let a = 1; }", nil},
+		{"fn() {}()", nil},
 	}
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
@@ -228,6 +232,8 @@ if (10 > 1) {
 }
 
 func TestErrorHandling(t *testing.T) {
+	InitBuiltins()
+
 	tests := []struct {
 		input           string
 		expectedMessage string
@@ -270,6 +276,18 @@ if (10 > 1) {
 			"foobar",
 			"identifier not found: foobar",
 		},
+		{
+			"lenn([1])",
+			"identifier not found: lenn (did you mean `len`?)",
+		},
+		{
+			"let counter = 1; fn() { countr }()",
+			"identifier not found: countr (did you mean `counter`?)",
+		},
+		{
+			"retrun",
+			"identifier not found: retrun (did you mean `return`?)",
+		},
 		{
 			`"Hello" - "World"`,
 			"unknown operator: STRING - STRING",
@@ -278,6 +296,18 @@ if (10 > 1) {
 			`{"name": "Monkey"}[fn(x) { x }];`,
 			"unusable as hash key: FUNCTION",
 		},
+		{
+			"let f = fn(a, b) { a + b }; f(1)",
+			"wrong number of arguments. got=1, want=2",
+		},
+		{
+			"fn(x) { x }(1, 2)",
+			"wrong number of arguments. got=2, want=1",
+		},
+		{
+			"let f = fn() {}; f()(0)",
+			"not a function: NULL",
+		},
 	}
 
 	for _, tt := range tests {
@@ -328,7 +358,7 @@ func TestFunctionObject(t *testing.T) {
 		t.Fatalf("parameter is not 'x'. got=%q", fn.Parameters[0])
 	}
 
-	expectedBody := "(x + 2)"
+	expectedBody := "{ (x + 2) }"
 	if fn.Body.String() != expectedBody {
 		t.Fatalf("body is not %q. got=%q", expectedBody, fn.Body.String())
 	}
@@ -345,6 +375,9 @@ func TestFunctionApplication(t *testing.T) {
 		{"let add = fn(x, y) { x + y; }; add(5, 5);", 10},
 		{"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
 		{"fn(x) { x; }(5)", 5},
+		// The type annotations are not checked at runtime
+		{`let add = fn(x: int, y: string) -> bool { x + y; }; add(5, 5);`, 10},
+		{`let a: string = 5; a;`, 5},
 	}
 
 	for _, tt := range tests {
@@ -468,8 +501,8 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`contains({"a": 1}, "a")`, true},
 		{`contains({"b": 2}, "a")`, false},
 		{`contains({}, "a")`, false},
-		{`contains(1, "a")`, "argument to `contains` must be HASH, got INTEGER"},
-		{`contains([], "a")`, "argument to `contains` must be HASH, got ARRAY"},
+		{`contains(1, "a")`, "argument to `contains` must be HASH or STRING, got INTEGER"},
+		{`contains([], "a")`, "argument to `contains` must be HASH or STRING, got ARRAY"},
 		{`contains({}, "a", "b")`, "wrong number of arguments. got=3, want=2"},
 		{`contains({}, [1, 2, 3])`, "unusable as hash key: ARRAY"},
 		{
@@ -787,3 +820,156 @@ func testStringObject(t *testing.T, obj object.Object, expected string) bool {
 
 	return true
 }
+
+func TestAssertBuiltins(t *testing.T) {
+	InitBuiltins()
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`assert(1 < 2)`, nil},
+		{`assert(1 > 2)`, "assertion failed"},
+		{`assert(false, "boom")`, "assertion failed: boom"},
+		{`assert()`, "wrong number of arguments. got=0, want=1 or 2"},
+		{`assert_eq(1 + 1, 2)`, nil},
+		{`assert_eq([1, {"a": "b"}], [1, {"a": "b"}])`, nil},
+		{`assert_eq(1, 2)`, "assertion failed: got=1, want=2"},
+		{`assert_eq(1, "1")`, "assertion failed: got=1 (INTEGER), want=1 (STRING)"},
+		{`assert_eq([1, 2], [1, 3], "arrays")`, "assertion failed: got=[1, 2], want=[1, 3]: arrays"},
+		{`assert_error(fn() { 1 / 0 })`, "the right operand of / is 0"},
+		{`assert_error(fn() { 1 })`, "assertion failed: expected an error, got=1"},
+		{`assert_error(1)`, "argument to `assert_error` must be FUNCTION, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			switch evaluated := evaluated.(type) {
+			case *object.Error:
+				if evaluated.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, evaluated.Message)
+				}
+			case *object.String:
+				testStringObject(t, evaluated, expected)
+			default:
+				t.Errorf("object is not Error or String. got=%T (%+v)", evaluated, evaluated)
+			}
+		}
+	}
+
+	failure := testEval(`assert_eq(1, 2)`).(*object.Error).Failure
+	if failure == nil || failure.Assertion != "assert_eq" {
+		t.Fatalf("failure is not structured. got=%+v", failure)
+	}
+	testIntegerObject(t, failure.Actual, 1)
+	testIntegerObject(t, failure.Expected, 2)
+}
+
+func TestTailCalls(t *testing.T) {
+	InitBuiltins()
+
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let count = fn(n, acc) { if (n == 0) { acc } else { count(n - 1, acc + 1) } }; count(1000000, 0)", 1000000},
+		{"let count = fn(n) { if (n == 0) { return 0; } return count(n - 1); }; count(100000)", 0},
+		{`
+let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } };
+let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } };
+if (even(100001)) { 1 } else { 0 }`, 0},
+		{"let sum = fn(arr, i, acc) { if (i == len(arr)) { return acc; } sum(arr, i + 1, acc + arr[i]) }; sum([1, 2, 3, 4], 0, 0)", 10},
+		// The tail call of a builtin and the calls in non-tail position
+		{"let f = fn(arr) { len(arr) }; f([1, 2])", 2},
+		{"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(10)", 3628800},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+
+	evaluated := testEval("let f = fn(n) { if (n == 0) { g() } else { f(n - 1) } }; f(10)")
+	if err, ok := evaluated.(*object.Error); !ok || err.Message != "identifier not found: g" {
+		t.Errorf("wrong error from a tail call. got=%v", evaluated)
+	}
+}
+
+func TestSlots(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let f = fn(a, b) { let c = a * b; c + a }; f(3, 4)", 15},
+		{"let adder = fn(x) { fn(y) { fn(z) { x + y + z } } }; adder(1)(2)(3)", 6},
+		{"let f = fn(a) { let a = a + 1; let a = a * 2; a }; f(1)", 4},
+		// The local of a let statement that did not run falls back to the global
+		{"let v = 1; let f = fn(c) { if (c) { let v = 2; } v }; f(false) * 10 + f(true)", 12},
+		{"let v = 1; let f = fn() { let before = v; let v = 5; before + v }; f()", 6},
+		// Closures see the later bindings of the enclosing call
+		{"let f = fn() { let get = fn() { n }; let n = 7; get() }; f()", 7},
+		{"let f = fn(n) { if (n == 0) { 0 } else { n + f(n - 1) } }; f(10)", 55},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+
+	evaluated := testEval("let f = fn(c) { if (c) { let local = 1; } local }; f(false)")
+	if err, ok := evaluated.(*object.Error); !ok || err.Message != "identifier not found: local" {
+		t.Errorf("wrong error for an unbound local. got=%v", evaluated)
+	}
+}
+
+func TestSlotsDisabled(t *testing.T) {
+	input := "let adder = fn(x) { fn(y) { x + y } }; adder(1)(2)"
+
+	program := parser.New(lexer.New(input)).ParseProgram()
+	env := object.NewEnvironmentWithRuntime(&object.Runtime{NoSlots: true})
+	testIntegerObject(t, Eval(program, env), 3)
+
+	ast.Inspect(program, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Identifier); ok && ident.Slot != nil {
+			t.Errorf("identifier %s has a slot", ident.Value)
+		}
+		return true
+	})
+}
+
+func TestStepLimit(t *testing.T) {
+	tests := []struct {
+		input    string
+		maxSteps int
+		expected interface{}
+	}{
+		{"let loop = fn() { loop() }; loop()", 100, "step limit exceeded: 100 statements"},
+		{"let f = fn(n) { 1 + f(n) }; f(1)", 100, "step limit exceeded: 100 statements"},
+		{`let loop = fn() { loop() }; assert_error(fn() { loop() }); 1`, 100, "step limit exceeded: 100 statements"},
+		{"let a = 1; let b = 2; a + b", 3, 3},
+		{"let a = 1; let b = 2; a + b", 2, "step limit exceeded: 2 statements"},
+	}
+
+	InitBuiltins()
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		runtime := &object.Runtime{MaxSteps: tt.maxSteps}
+		evaluated := Eval(program, object.NewEnvironmentWithRuntime(runtime))
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if err, ok := evaluated.(*object.Error); !ok || err.Message != expected {
+				t.Errorf("wrong result for %q. got=%v, want=%q", tt.input, evaluated, expected)
+			}
+		}
+
+		if runtime.Steps > tt.maxSteps {
+			t.Errorf("%q ran %d statements over the limit of %d", tt.input, runtime.Steps, tt.maxSteps)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/object"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fileBuiltins access the file system, within the permissions of the runtime
+func fileBuiltins() map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"read_file":   {Fn: builtinReadFile},
+		"read_lines":  {Fn: builtinReadLines},
+		"write_file":  {Fn: fileWriter("write_file", os.O_TRUNC)},
+		"append_file": {Fn: fileWriter("append_file", os.O_APPEND)},
+		"list_dir":    {Fn: builtinListDir},
+		"exists":      {Fn: builtinExists},
+		"mkdir":       {Fn: builtinMkdir},
+		"remove":      {Fn: builtinRemove},
+		"path_join":   {Fn: builtinPathJoin},
+		"basename":    {Fn: pathMapper("basename", filepath.Base)},
+		"dirname":     {Fn: pathMapper("dirname", filepath.Dir)},
+	}
+}
+
+// pathArg checks the path argument against the permissions and returns the path to open
+func pathArg(name string, env *object.Environment, arg object.Object, write bool) (string, *object.Error) {
+	str, ok := arg.(*object.String)
+	if !ok {
+		return "", newError("argument to `%s` must be STRING, got %s", name, arg.Type())
+	}
+
+	path, err := env.Runtime().CheckPath(string(str.Value), write)
+	if err != nil {
+		return "", newError("%s: %s", name, err)
+	}
+
+	return path, nil
+}
+
+// fileError reports the error of a file operation on the path given by the script
+func fileError(name string, arg object.Object, err error) *object.Error {
+	switch e := err.(type) {
+	case *os.PathError:
+		err = e.Err
+	case *os.LinkError:
+		err = e.Err
+	}
+
+	return newError("%s: %s: %s", name, arg.Inspect(), err)
+}
+
+func readFile(name string, env *object.Environment, args []object.Object) (string, *object.Error) {
+	if len(args) != 1 {
+		return "", newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	path, err := pathArg(name, env, args[0], false)
+	if err != nil {
+		return "", err
+	}
+
+	content, readErr := ioutil.ReadFile(path)
+	if readErr != nil {
+		return "", fileError(name, args[0], readErr)
+	}
+
+	return string(content), nil
+}
+
+func builtinReadFile(env *object.Environment, args ...object.Object) object.Object {
+	content, err := readFile("read_file", env, args)
+	if err != nil {
+		return err
+	}
+
+	return object.NewString(content)
+}
+
+// read_lines returns the lines without the line endings, the last line may not end with one
+func builtinReadLines(env *object.Environment, args ...object.Object) object.Object {
+	content, err := readFile("read_lines", env, args)
+	if err != nil {
+		return err
+	}
+
+	if content == "" {
+		return &object.Array{Elements: []object.Object{}}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	return stringArray(lines)
+}
+
+// fileWriter makes write_file(path, s) and append_file(path, s), they create the file if needed
+func fileWriter(name string, flag int) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 2 {
+			return newError("wrong number of arguments. got=%d, want=2", len(args))
+		}
+
+		content, err := stringArgs(name, args[1:], 0)
+		if err != nil {
+			return err
+		}
+
+		path, err := pathArg(name, env, args[0], true)
+		if err != nil {
+			return err
+		}
+
+		file, openErr := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|flag, 0644)
+		if openErr != nil {
+			return fileError(name, args[0], openErr)
+		}
+
+		_, writeErr := file.WriteString(content[0])
+		if closeErr := file.Close(); writeErr == nil {
+			writeErr = closeErr
+		}
+		if writeErr != nil {
+			return fileError(name, args[0], writeErr)
+		}
+
+		return NULL
+	}
+}
+
+// list_dir returns the sorted names of the entries of a directory
+func builtinListDir(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	path, err := pathArg("list_dir", env, args[0], false)
+	if err != nil {
+		return err
+	}
+
+	entries, readErr := ioutil.ReadDir(path)
+	if readErr != nil {
+		return fileError("list_dir", args[0], readErr)
+	}
+
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+
+	return stringArray(names)
+}
+
+func builtinExists(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	path, err := pathArg("exists", env, args[0], false)
+	if err != nil {
+		return err
+	}
+
+	_, statErr := os.Stat(path)
+	return nativeBoolToBooleanObject(statErr == nil)
+}
+
+// mkdir creates a directory with the missing parents
+func builtinMkdir(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	path, err := pathArg("mkdir", env, args[0], true)
+	if err != nil {
+		return err
+	}
+
+	if mkdirErr := os.MkdirAll(path, 0755); mkdirErr != nil {
+		return fileError("mkdir", args[0], mkdirErr)
+	}
+
+	return NULL
+}
+
+// remove removes a file or an empty directory. The symbolic links are removed, not followed, and
+// the roots of the permissions cannot be removed since their parents are out of them.
+func builtinRemove(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	values, err := stringArgs("remove", args, 0)
+	if err != nil {
+		return err
+	}
+
+	abs, absErr := filepath.Abs(values[0])
+	if absErr != nil {
+		return fileError("remove", args[0], absErr)
+	}
+
+	dir, err := pathArg("remove", env, object.NewString(filepath.Dir(abs)), true)
+	if err != nil {
+		return newError("remove: permission denied to write %s", values[0])
+	}
+
+	if removeErr := os.Remove(filepath.Join(dir, filepath.Base(abs))); removeErr != nil {
+		return fileError("remove", args[0], removeErr)
+	}
+
+	return NULL
+}
+
+func builtinPathJoin(env *object.Environment, args ...object.Object) object.Object {
+	values, err := stringArgs("path_join", args, 0)
+	if err != nil {
+		return err
+	}
+
+	return object.NewString(filepath.Join(values...))
+}
+
+func pathMapper(name string, mapper func(path string) string) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		values, err := stringArgs(name, args, 0)
+		if err != nil {
+			return err
+		}
+
+		return object.NewString(mapper(values[0]))
+	}
+}
@@ -0,0 +1,104 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/object"
+	"github.com/lxdlam/monkey-plus/parser"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileBuiltins(t *testing.T) {
+	InitBuiltins()
+
+	dir := t.TempDir()
+	for _, sub := range []string{"data", "out"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		"data/lines.txt": "a,1\r\nb,2\n\nc,3",
+		"data/lib.mp":    "let double = fn(x) { x * 2 };",
+		"secret.txt":     "secret",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A dangling link must not create its target out of the permissions
+	if err := os.Symlink(filepath.Join(dir, "pwned"), filepath.Join(dir, "out", "evil")); err != nil {
+		t.Fatal(err)
+	}
+
+	permissions, err := object.NewPermissions([]string{filepath.Join(dir, "data")}, []string{filepath.Join(dir, "out")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input string
+		// expected is the inspected result, or the message if it is an error
+		expected string
+	}{
+		{`read_lines("DIR/data/lines.txt")`, "[a,1, b,2, , c,3]"},
+		{`len(read_file("DIR/data/lines.txt"))`, "13"},
+		{`list_dir("DIR/data")`, "[lib.mp, lines.txt]"},
+		{`exists("DIR/data/lib.mp")`, "true"},
+		{`exists("DIR/data/missing")`, "false"},
+		{`load("DIR/data/lib.mp"); double(21)`, "42"},
+		{`write_file("DIR/out/a.txt", "x"); append_file("DIR/out/a.txt", "y"); read_file("DIR/out/a.txt")`, "xy"},
+		{`write_file("DIR/out/a.txt", "z"); read_lines("DIR/out/a.txt")`, "[z]"},
+		{`mkdir("DIR/out/x/y"); write_file("DIR/out/x/y/f", ""); list_dir("DIR/out/x/y")`, "[f]"},
+		{`remove("DIR/out/x/y/f"); remove("DIR/out/x/y"); list_dir("DIR/out/x")`, "[]"},
+		{`read_file("DIR/data/missing")`, "read_file: DIR/data/missing: no such file or directory"},
+		{`remove("DIR/out/missing")`, "remove: DIR/out/missing: no such file or directory"},
+		// Out of the permissions
+		{`read_file("DIR/secret.txt")`, "read_file: permission denied to read DIR/secret.txt"},
+		{`read_file("DIR/data/../secret.txt")`, "read_file: permission denied to read DIR/data/../secret.txt"},
+		{`load("DIR/secret.txt")`, "load DIR/secret.txt failed: permission denied to read DIR/secret.txt"},
+		{`write_file("DIR/data/new", "x")`, "write_file: permission denied to write DIR/data/new"},
+		{`mkdir("DIR/data/new")`, "mkdir: permission denied to write DIR/data/new"},
+		{`remove("DIR/data/lib.mp")`, "remove: permission denied to write DIR/data/lib.mp"},
+		{`remove("DIR/out")`, "remove: permission denied to write DIR/out"},
+		{`exists("DIR/secret.txt")`, "exists: permission denied to read DIR/secret.txt"},
+		{`write_file("DIR/out/evil", "x")`, "write_file: permission denied to write DIR/out/evil"},
+		{`append_file("DIR/out/evil", "x")`, "append_file: permission denied to write DIR/out/evil"},
+		{`mkdir("DIR/out/evil")`, "mkdir: permission denied to write DIR/out/evil"},
+		{`read_file(1)`, "argument to `read_file` must be STRING, got INTEGER"},
+		{`write_file("DIR/out/a.txt", 1)`, "argument to `write_file` must be STRING, got INTEGER"},
+		// The paths
+		{`path_join("a", "b/", "../c", "d.txt")`, filepath.Join("a", "c", "d.txt")},
+		{`basename("/a/b.txt")`, "b.txt"},
+		{`dirname("/a/b.txt")`, filepath.Join("/", "a")},
+		{`path_join("a", 1)`, "argument to `path_join` must be STRING, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		input := strings.ReplaceAll(tt.input, "DIR", dir)
+		expected := strings.ReplaceAll(tt.expected, "DIR", dir)
+
+		program := parser.New(lexer.New(input)).ParseProgram()
+		evaluated := Eval(program, object.NewEnvironmentWithRuntime(&object.Runtime{Permissions: permissions}))
+
+		var got string
+		if err, ok := evaluated.(*object.Error); ok {
+			got = err.Message
+		} else {
+			got = evaluated.Inspect()
+		}
+
+		if got != expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", input, got, expected)
+		}
+	}
+
+	if _, err := os.Lstat(filepath.Join(dir, "pwned")); !os.IsNotExist(err) {
+		t.Errorf("the target of the dangling link was created, got=%v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/object"
+	"github.com/lxdlam/monkey-plus/parser"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const fuzzMaxSteps = 1000
+
+// addSeeds adds the inputs of the tests and the benchmark programs to the fuzzing corpus
+func addSeeds(f *testing.F) {
+	seeds := []string{
+		"5 + 5 + 5 + 5 - 10; 2 * (5 + 10); -50 + 100 + -50; 7 % 3; 1 / 0",
+		"1 < 2 == true; !!5; true && false || true; \"a\" < \"b\"",
+		"if (1 > 2) { 10 } else { 20 }; if (10 > 1) { if (10 > 1) { return 10; } return 1; }",
+		"let a = 5; let b = a; let c = a + b + 5; c",
+		"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5)); fn(x) { x; }(5)",
+		"let newAdder = fn(x) { fn(y) { x + y }; }; let addTwo = newAdder(2); addTwo(2);",
+		`"Hello" + " " + "World!"; "\t\"x\"\\"[1]; len("four"); len([1, 2, 3])`,
+		"let a = [1, 2 * 2, 3 + 3]; a[0] + a[1] + a[2]; first(a); last(a); rest(a); push(a, 4); a[-1]",
+		`let h = {"one": 10 - 9, "two": 1 + 1, 4: 4, true: 5}; h["one"]; h[4]; h[true]; {}[fn(x) { x }]`,
+		`let h = set({}, "a", 1); has(h, "a"); delete(h, "a"); set(h, [1], 2)`,
+		"let count = fn(n) { if (n == 0) { return 0; } count(n - 1) }; count(100000)",
+		"let loop = fn() { loop() }; loop()",
+		`let re = regex("(\\w+)=(\\d+)?"); find_all(re, "a=1 b="); replace_re(re, "a=1", fn(m) { m[1] }); split_re(",", "a,b"); match("(", "")`,
+		`json_stringify(json_parse("{\"a\": [1, 2.5e3, \"x\", true, null], \"b\": {}}"), 2); json_stringify({1: 2})`,
+		`load("x.mp"); read_file("/etc/passwd"); write_file("out", "x"); remove("."); mkdir("d"); path_join("a", basename("/b/c"))`,
+		`let n = 3; "n=${n} ${[n, "${n * 2}"]}"; format("%5d|%-4s|%q|%x|%.2f|%v%%", n, "a", "b", 255, 1, [n])`,
+		"1.5 * 2 - 0.25 / 3 % 2; 9223372036854775807 + 1; pow(3, 40); floor(pow(10, 19.0)); clamp(PI, 0, E); gcd(-9, 6); atan(1, 0)",
+		`let b = 123456789012345678901234567890n; b * b / -7 % 3; {b: 1, 1n: 2}[1]; int(b); bigint("42"); pow(2, 99) - 1n; sum([b, 1])`,
+		`seed(3); rand_int(-9223372036854775807 - 1, 9223372036854775807); rand_float(); choice(""); shuffle("abc"); sample(range(5), 2); sample([], 1)`,
+		`let t = now(); sleep(5); now() - t; unix(t + duration("1h")); format_time(t, "2006-01-02"); parse_time("2024", "2006") < t`,
+		"let f = fn(a, b) { a }; f(1); f(1, 2, 3)",
+		`eval("1 + 2"); assert_eq(1, 1); assert_error(fn() { 1 / 0 }); type(1); puts("x")`,
+		"foobar; 5 + true; -true; \"a\" - \"b\"",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	files, _ := filepath.Glob(filepath.Join("..", "benchmarks", "*.mp"))
+	for _, file := range files {
+		if source, err := ioutil.ReadFile(file); err == nil {
+			f.Add(string(source))
+		}
+	}
+}
+
+// FuzzEval checks that the evaluation does not panic, and that it stops under a step limit
+func FuzzEval(f *testing.F) {
+	InitBuiltins()
+	addSeeds(f)
+
+	f.Fuzz(func(t *testing.T, input string) {
+		p := parser.New(lexer.New(input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			return
+		}
+
+		// The inputs cannot access any file, the random builtins are reproducible and the sleeps
+		// return at once
+		runtime := &object.Runtime{Stdout: ioutil.Discard, MaxSteps: fuzzMaxSteps, Permissions: &object.Permissions{}, Stdin: strings.NewReader(""), Clock: object.NewFakeClock(time.Unix(0, 0))}
+		runtime.Seed(1)
+		Eval(program, object.NewEnvironmentWithRuntime(runtime))
+
+		if runtime.Steps > fuzzMaxSteps {
+			t.Fatalf("%d statements evaluated over the limit of %d", runtime.Steps, fuzzMaxSteps)
+		}
+	})
+}
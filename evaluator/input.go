@@ -0,0 +1,75 @@
+package evaluator
+
+import (
+	"fmt"
+	"github.com/lxdlam/monkey-plus/object"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// inputBuiltins read from the stdin of the runtime, they return null at the end of the input
+func inputBuiltins() map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"read_line": {Fn: builtinReadLine},
+		"read_all":  {Fn: builtinReadAll},
+		"input":     {Fn: builtinInput},
+	}
+}
+
+// readLine reads a line without its line ending, the last line may not end with one
+func readLine(name string, env *object.Environment) object.Object {
+	line, err := env.Runtime().In().ReadString('\n')
+	if err != nil && err != io.EOF {
+		return newError("%s: %s", name, err)
+	}
+
+	if err == io.EOF && line == "" {
+		return NULL
+	}
+
+	return object.NewString(strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+}
+
+func builtinReadLine(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError("wrong number of arguments. got=%d, want=0", len(args))
+	}
+
+	return readLine("read_line", env)
+}
+
+// read_all reads the rest of the input
+func builtinReadAll(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError("wrong number of arguments. got=%d, want=0", len(args))
+	}
+
+	content, err := ioutil.ReadAll(env.Runtime().In())
+	if err != nil {
+		return newError("read_all: %s", err)
+	}
+
+	if len(content) == 0 {
+		return NULL
+	}
+
+	return object.NewString(string(content))
+}
+
+// input(prompt) writes the prompt without a newline and reads a line
+func builtinInput(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) > 1 {
+		return newError("wrong number of arguments. got=%d, want=0 or 1", len(args))
+	}
+
+	if len(args) == 1 {
+		prompt, err := stringArgs("input", args, 0)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(env.Runtime().Out(), prompt[0])
+	}
+
+	return readLine("input", env)
+}
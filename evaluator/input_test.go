@@ -0,0 +1,58 @@
+package evaluator
+
+import (
+	"bytes"
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/object"
+	"github.com/lxdlam/monkey-plus/parser"
+	"strings"
+	"testing"
+)
+
+func TestInputBuiltins(t *testing.T) {
+	InitBuiltins()
+
+	tests := []struct {
+		input string
+		stdin string
+		// expected is the inspected result, or the message if it is an error
+		expected string
+		// output is what the program writes to the stdout
+		output string
+	}{
+		{`[read_line(), read_line(), read_line()]`, "a\r\nb\n\nc", "[a, b, ]", ""},
+		{`read_line(); read_line()`, "a\nb", "b", ""},
+		{`read_line(); read_line()`, "a\n", "null", ""},
+		{`read_line()`, "", "null", ""},
+		{`read_line(); read_all()`, "a\nb\nc\n", "b\nc\n", ""},
+		{`read_all(); read_all()`, "a", "null", ""},
+		{`let loop = fn(lines, line) { if (type(line) == "NULL") { lines } else { loop(push(lines, line), read_line()) } }; loop([], read_line())`, "x\ny\nz", "[x, y, z]", ""},
+		{`input("name? ")`, "monkey\n", "monkey", "name? "},
+		{`input()`, "", "null", ""},
+		{`input("a", "b")`, "", "wrong number of arguments. got=2, want=0 or 1", ""},
+		{`input(1)`, "", "argument to `input` must be STRING, got INTEGER", ""},
+		{`read_line(1)`, "", "wrong number of arguments. got=1, want=0", ""},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		runtime := &object.Runtime{Stdin: strings.NewReader(tt.stdin), Stdout: &out}
+
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		evaluated := Eval(program, object.NewEnvironmentWithRuntime(runtime))
+
+		var got string
+		if err, ok := evaluated.(*object.Error); ok {
+			got = err.Message
+		} else {
+			got = evaluated.Inspect()
+		}
+
+		if got != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, got, tt.expected)
+		}
+		if out.String() != tt.output {
+			t.Errorf("wrong output for %q. got=%q, want=%q", tt.input, out.String(), tt.output)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/lxdlam/monkey-plus/object"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// jsonBuiltins convert between the values and JSON. The hashes keep the order of their keys, so
+// a parsed document is written back in the same order.
+func jsonBuiltins() map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"json_parse":     {Fn: builtinJSONParse},
+		"json_stringify": {Fn: builtinJSONStringify},
+	}
+}
+
+func builtinJSONParse(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	values, err := stringArgs("json_parse", args, 0)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(strings.NewReader(values[0]))
+	dec.UseNumber()
+
+	value, err := decodeJSON(dec)
+	if err != nil {
+		return err
+	}
+
+	if _, tokenErr := dec.Token(); tokenErr != io.EOF {
+		return newError("invalid JSON: unexpected data after the value")
+	}
+
+	return value
+}
+
+// decodeJSON decodes the value starting at the next token, the decoder limits the nesting depth
+func decodeJSON(dec *json.Decoder) (object.Object, *object.Error) {
+	tok, tokenErr := dec.Token()
+	if tokenErr != nil {
+		return nil, jsonError(tokenErr)
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '[' {
+			elements := []object.Object{}
+			for dec.More() {
+				el, err := decodeJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				elements = append(elements, el)
+			}
+			if _, tokenErr := dec.Token(); tokenErr != nil {
+				return nil, jsonError(tokenErr)
+			}
+			return &object.Array{Elements: elements}, nil
+		}
+
+		hash := object.NewHash()
+		for dec.More() {
+			key, tokenErr := dec.Token()
+			if tokenErr != nil {
+				return nil, jsonError(tokenErr)
+			}
+			value, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(object.NewString(key.(string)), value)
+		}
+		if _, tokenErr := dec.Token(); tokenErr != nil {
+			return nil, jsonError(tokenErr)
+		}
+		return hash, nil
+	case string:
+		return object.NewString(tok), nil
+	case json.Number:
+		return jsonNumber(string(tok))
+	case bool:
+		return nativeBoolToBooleanObject(tok), nil
+	default:
+		return NULL, nil
+	}
+}
+
+// jsonNumber makes an integer of the numbers without a fraction or an exponent, a big integer if
+// it is out of range, and a float otherwise
+func jsonNumber(s string) (object.Object, *object.Error) {
+	if !strings.ContainsAny(s, ".eE") {
+		value, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			bigValue, _ := new(big.Int).SetString(s, 10)
+			return &object.BigInt{Value: bigValue}, nil
+		}
+		return &object.Integer{Value: value}, nil
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, newError("invalid JSON: number %s out of range", s)
+	}
+	return &object.Float{Value: value}, nil
+}
+
+func jsonError(err error) *object.Error {
+	if err == io.EOF {
+		return newError("invalid JSON: unexpected end of JSON input")
+	}
+
+	if syntaxErr, ok := err.(*json.SyntaxError); ok {
+		return newError("invalid JSON at offset %d: %s", syntaxErr.Offset, syntaxErr)
+	}
+
+	return newError("invalid JSON: %s", err)
+}
+
+// json_stringify(v, indent) writes v as JSON, compact unless indent gives the number of spaces
+// or the string to indent with
+func builtinJSONStringify(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	e := &jsonEncoder{seen: map[object.Object]bool{}}
+	if len(args) == 2 {
+		switch indent := args[1].(type) {
+		case *object.Integer:
+			if indent.Value < 0 || indent.Value > 16 {
+				return newError("indent of `json_stringify` must be between 0 and 16, got %d", indent.Value)
+			}
+			e.indent = strings.Repeat(" ", int(indent.Value))
+		case *object.String:
+			e.indent = string(indent.Value)
+		default:
+			return newError("argument to `json_stringify` must be INTEGER or STRING, got %s", args[1].Type())
+		}
+	}
+
+	if err := e.encode(args[0], 0); err != nil {
+		return err
+	}
+
+	return object.NewString(e.out.String())
+}
+
+type jsonEncoder struct {
+	out    bytes.Buffer
+	indent string
+	// seen holds the arrays and the hashes being encoded, to find the cycles
+	seen map[object.Object]bool
+}
+
+func (e *jsonEncoder) encode(obj object.Object, depth int) *object.Error {
+	switch obj := obj.(type) {
+	case *object.Null:
+		e.out.WriteString("null")
+	case *object.Boolean:
+		e.out.WriteString(strconv.FormatBool(obj.Value))
+	case *object.Integer:
+		e.out.WriteString(strconv.FormatInt(obj.Value, 10))
+	case *object.BigInt:
+		e.out.WriteString(obj.Inspect())
+	case *object.Float:
+		if math.IsNaN(obj.Value) || math.IsInf(obj.Value, 0) {
+			return newError("cannot encode %s in JSON", obj.Inspect())
+		}
+		e.out.WriteString(obj.Inspect())
+	case *object.String:
+		e.quote(string(obj.Value))
+	case *object.Time:
+		e.quote(obj.Inspect())
+	case *object.Array:
+		if e.seen[obj] {
+			return newError("cannot encode a cyclic ARRAY in JSON")
+		}
+		e.seen[obj] = true
+		defer delete(e.seen, obj)
+
+		e.out.WriteByte('[')
+		for i, el := range obj.Elements {
+			e.separate(i, depth+1)
+			if err := e.encode(el, depth+1); err != nil {
+				return err
+			}
+		}
+		e.close(len(obj.Elements), depth, ']')
+	case *object.Hash:
+		if e.seen[obj] {
+			return newError("cannot encode a cyclic HASH in JSON")
+		}
+		e.seen[obj] = true
+		defer delete(e.seen, obj)
+
+		e.out.WriteByte('{')
+		for i, pair := range obj.Items() {
+			key, ok := pair.Key.(*object.String)
+			if !ok {
+				return newError("cannot encode a hash key of %s in JSON, the keys must be STRING", pair.Key.Type())
+			}
+
+			e.separate(i, depth+1)
+			e.quote(string(key.Value))
+			e.out.WriteByte(':')
+			if e.indent != "" {
+				e.out.WriteByte(' ')
+			}
+			if err := e.encode(pair.Value, depth+1); err != nil {
+				return err
+			}
+		}
+		e.close(obj.Len(), depth, '}')
+	default:
+		return newError("cannot encode %s in JSON", obj.Type())
+	}
+
+	return nil
+}
+
+// separate starts the i-th element of an array or a hash
+func (e *jsonEncoder) separate(i, depth int) {
+	if i > 0 {
+		e.out.WriteByte(',')
+	}
+	e.newline(depth)
+}
+
+func (e *jsonEncoder) close(length, depth int, delim byte) {
+	if length > 0 {
+		e.newline(depth)
+	}
+	e.out.WriteByte(delim)
+}
+
+func (e *jsonEncoder) newline(depth int) {
+	if e.indent == "" {
+		return
+	}
+
+	e.out.WriteByte('\n')
+	for i := 0; i < depth; i++ {
+		e.out.WriteString(e.indent)
+	}
+}
+
+// quote writes a JSON string, the invalid UTF-8 bytes are replaced by U+FFFD
+func (e *jsonEncoder) quote(s string) {
+	enc := json.NewEncoder(&e.out)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+
+	// Encode ends the value with a newline
+	e.out.Truncate(e.out.Len() - 1)
+}
@@ -0,0 +1,79 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/object"
+	"testing"
+)
+
+func TestJSONBuiltins(t *testing.T) {
+	InitBuiltins()
+
+	tests := []struct {
+		input string
+		// expected is the inspected result, or the message if it is an error
+		expected string
+	}{
+		{`json_parse("{\"b\": [1, 2.5, -3e2, true, null], \"a\": {\"x\": \"y\"}}")`, "{b: [1, 2.5, -300.0, true, null], a: {x: y}}"},
+		{`json_parse("\"caf\\u00e9 \\n\"")`, "café \n"},
+		{`json_parse(" 42 ")`, "42"},
+		{`type(json_parse("1.0"))`, "FLOAT"},
+		{`json_parse("{\"a\": 1, \"a\": 2}")`, "{a: 2}"},
+		{`json_parse("{\"a\": }")`, "invalid JSON at offset 7: missing value after object key"},
+		{`json_parse("[1, 2")`, "invalid JSON at offset 5: unexpected end of JSON input"},
+		{`json_parse("")`, "invalid JSON: unexpected end of JSON input"},
+		{`json_parse("1 2")`, "invalid JSON: unexpected data after the value"},
+		{`json_parse("99999999999999999999")`, "99999999999999999999"},
+		{`type(json_parse("[-99999999999999999999]")[0])`, "BIGINT"},
+		{`json_stringify([pow(10, 20), 5n])`, "[100000000000000000000,5]"},
+		{`json_parse("1e999")`, "invalid JSON: number 1e999 out of range"},
+		{`json_parse(repeat("[", 10002))`, "invalid JSON at offset 10001: exceeded max depth"},
+		{`json_parse(1)`, "argument to `json_parse` must be STRING, got INTEGER"},
+		{`json_stringify({"b": [1, "x", true], "a": {}})`, `{"b":[1,"x",true],"a":{}}`},
+		{`json_stringify("<a href=\"x\">\n</a>")`, `"<a href=\"x\">\n</a>"`},
+		{`json_stringify({"a": [1, []], "b": 2}, 2)`, "{\n  \"a\": [\n    1,\n    []\n  ],\n  \"b\": 2\n}"},
+		{`json_stringify([1], "\t")`, "[\n\t1\n]"},
+		{`json_stringify({1: 2})`, "cannot encode a hash key of INTEGER in JSON, the keys must be STRING"},
+		{`json_stringify([fn(x) { x }])`, "cannot encode FUNCTION in JSON"},
+		{`json_stringify(1, -1)`, "indent of `json_stringify` must be between 0 and 16, got -1"},
+		{`json_stringify(1, true)`, "argument to `json_stringify` must be INTEGER or STRING, got BOOLEAN"},
+		{`json_stringify()`, "wrong number of arguments. got=0, want=1 or 2"},
+		// The documents are written back in the same order
+		{`let s = "{\"z\":1,\"y\":[2.5,{\"x\":null}],\"w\":\"v\"}"; json_stringify(json_parse(s)) == s`, "true"},
+		{`let config = json_parse("{\"port\": 80}"); json_stringify(set(config, "host", "x"))`, `{"port":80,"host":"x"}`},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		var got string
+		if err, ok := evaluated.(*object.Error); ok {
+			got = err.Message
+		} else {
+			got = evaluated.Inspect()
+		}
+
+		if got != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestJSONStringifyCycle(t *testing.T) {
+	array := &object.Array{}
+	hash := object.NewHash()
+	hash.Set(object.NewString("array"), array)
+	array.Elements = []object.Object{hash}
+
+	result := builtinJSONStringify(nil, hash)
+	err, ok := result.(*object.Error)
+	if !ok || err.Message != "cannot encode a cyclic HASH in JSON" {
+		t.Errorf("wrong result for a cyclic hash. got=%s", result.Inspect())
+	}
+
+	// The same value twice is not a cycle
+	shared := &object.Array{Elements: []object.Object{&object.Integer{Value: 1}}}
+	result = builtinJSONStringify(nil, &object.Array{Elements: []object.Object{shared, shared}})
+	if result.Inspect() != "[[1],[1]]" {
+		t.Errorf("wrong result for a shared array. got=%s", result.Inspect())
+	}
+}
@@ -0,0 +1,262 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/object"
+	"math"
+	"math/big"
+)
+
+// constants are the predeclared values, looked up after the builtins
+var constants = map[string]object.Object{
+	"PI": &object.Float{Value: math.Pi},
+	"E":  &object.Float{Value: math.E},
+}
+
+// mathBuiltins take the integers and the floats, min and max are the collection builtins. The
+// integer results overflow like the arithmetic, promoted to big integers or an error if the runtime
+// checks it.
+func mathBuiltins() map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"abs":   {Fn: builtinAbs},
+		"pow":   {Fn: builtinPow},
+		"sqrt":  {Fn: builtinSqrt},
+		"floor": {Fn: rounder("floor", math.Floor)},
+		"ceil":  {Fn: rounder("ceil", math.Ceil)},
+		"gcd":   {Fn: builtinGcd},
+		"clamp": {Fn: builtinClamp},
+		"sin":   {Fn: floatMapper("sin", math.Sin)},
+		"cos":   {Fn: floatMapper("cos", math.Cos)},
+		"tan":   {Fn: floatMapper("tan", math.Tan)},
+		"asin":  {Fn: floatMapper("asin", math.Asin)},
+		"acos":  {Fn: floatMapper("acos", math.Acos)},
+		"atan":  {Fn: builtinAtan},
+	}
+}
+
+func numberArg(name string, arg object.Object) (float64, *object.Error) {
+	if !isNumber(arg) {
+		return 0, newError("argument to `%s` must be INTEGER, BIGINT or FLOAT, got %s", name, arg.Type())
+	}
+
+	return toFloat(arg), nil
+}
+
+func builtinAbs(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		if arg.Value >= 0 {
+			return arg
+		}
+		return evalMinusPrefixOperatorExpression(arg, env.Runtime().CheckOverflow)
+	case *object.BigInt:
+		return bigResult(new(big.Int).Abs(arg.Value))
+	case *object.Float:
+		return &object.Float{Value: math.Abs(arg.Value)}
+	default:
+		return newError("argument to `abs` must be INTEGER, BIGINT or FLOAT, got %s", arg.Type())
+	}
+}
+
+// maxPowBits keeps `pow` from running out of memory and time
+const maxPowBits = 1 << 24
+
+// pow(a, b) is an integer if a and b are integers and b is not negative, a float otherwise
+func builtinPow(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	if isInteger(args[0]) && isInteger(args[1]) && toBig(args[1]).Sign() >= 0 {
+		base, ok1 := args[0].(*object.Integer)
+		exp, ok2 := smallInteger(args[1])
+		if !ok1 || !ok2 {
+			return bigPow(toBig(args[0]), toBig(args[1]))
+		}
+
+		result, overflow := int64(1), false
+		for b, e := base.Value, exp.Value; e > 0; e >>= 1 {
+			var ok bool
+			if e&1 == 1 {
+				result, ok = integerArithmetic("*", result, b)
+				overflow = overflow || !ok
+			}
+			if e > 1 {
+				b, ok = integerArithmetic("*", b, b)
+				overflow = overflow || !ok
+			}
+		}
+
+		if overflow {
+			if env.Runtime().CheckOverflow {
+				return newError("integer overflow: pow(%d, %d)", base.Value, exp.Value)
+			}
+			return bigPow(toBig(base), toBig(exp))
+		}
+		return &object.Integer{Value: result}
+	}
+
+	x, err := numberArg("pow", args[0])
+	if err != nil {
+		return err
+	}
+	y, err := numberArg("pow", args[1])
+	if err != nil {
+		return err
+	}
+
+	return &object.Float{Value: math.Pow(x, y)}
+}
+
+func builtinSqrt(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	x, err := numberArg("sqrt", args[0])
+	if err != nil {
+		return err
+	}
+
+	if x < 0 {
+		return newError("argument to `sqrt` must not be negative, got %s", args[0].Inspect())
+	}
+
+	return &object.Float{Value: math.Sqrt(x)}
+}
+
+// bigPow fails before computing a result of more than maxPowBits bits
+func bigPow(base, exp *big.Int) object.Object {
+	// The result has at most bitlen(base) * exp bits, the bases 0, 1 and -1 stay small
+	if base.CmpAbs(big.NewInt(1)) > 0 && exp.Cmp(big.NewInt(maxPowBits/int64(base.BitLen()))) > 0 {
+		return newError("result of `pow` is larger than %d bits", maxPowBits)
+	}
+
+	return bigResult(new(big.Int).Exp(base, exp, nil))
+}
+
+// rounder makes floor(x) and ceil(x), they convert a float to an integer
+func rounder(name string, round func(float64) float64) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		switch arg := args[0].(type) {
+		case *object.Integer, *object.BigInt:
+			return arg
+		case *object.Float:
+			value := round(arg.Value)
+			// MaxInt64 rounds up to 2^63 as a float64, the first value out of range
+			if math.IsNaN(value) || value < math.MinInt64 || value >= math.MaxInt64 {
+				return newError("cannot convert %s to INTEGER in `%s`", arg.Inspect(), name)
+			}
+			return &object.Integer{Value: int64(value)}
+		default:
+			return newError("argument to `%s` must be INTEGER, BIGINT or FLOAT, got %s", name, arg.Type())
+		}
+	}
+}
+
+// gcd(a, b) is the greatest common divisor of the absolute values, gcd(0, 0) is 0
+func builtinGcd(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	a, err := integerArg("gcd", args[0])
+	if err != nil {
+		return err
+	}
+	b, err := integerArg("gcd", args[1])
+	if err != nil {
+		return err
+	}
+
+	x, y := magnitude(a), magnitude(b)
+	for y != 0 {
+		x, y = y, x%y
+	}
+
+	// Only gcd(MinInt64, MinInt64) or with 0 is 2^63
+	if x > math.MaxInt64 {
+		if env.Runtime().CheckOverflow {
+			return newError("integer overflow: gcd(%d, %d)", a, b)
+		}
+		return &object.BigInt{Value: new(big.Int).SetUint64(x)}
+	}
+	return &object.Integer{Value: int64(x)}
+}
+
+func magnitude(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+
+	return uint64(n)
+}
+
+// clamp(x, lo, hi) returns lo if x is less than lo, hi if x is greater than hi and x otherwise
+func builtinClamp(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return newError("wrong number of arguments. got=%d, want=3", len(args))
+	}
+
+	for _, arg := range args {
+		if _, err := numberArg("clamp", arg); err != nil {
+			return err
+		}
+	}
+
+	x, lo, hi := args[0], args[1], args[2]
+	if order, _ := compareObjects("clamp", lo, hi); order > 0 {
+		return newError("bounds of `clamp` must be in order, got %s > %s", lo.Inspect(), hi.Inspect())
+	}
+
+	if order, _ := compareObjects("clamp", x, lo); order < 0 {
+		return lo
+	}
+	if order, _ := compareObjects("clamp", x, hi); order > 0 {
+		return hi
+	}
+	return x
+}
+
+func floatMapper(name string, f func(float64) float64) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		x, err := numberArg(name, args[0])
+		if err != nil {
+			return err
+		}
+
+		return &object.Float{Value: f(x)}
+	}
+}
+
+// atan(x) is the arctangent of x, atan(y, x) is the angle of the point (x, y) like atan2 in C
+func builtinAtan(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	y, err := numberArg("atan", args[0])
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		return &object.Float{Value: math.Atan(y)}
+	}
+
+	x, err := numberArg("atan", args[1])
+	if err != nil {
+		return err
+	}
+	return &object.Float{Value: math.Atan2(y, x)}
+}
@@ -0,0 +1,143 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/object"
+	"github.com/lxdlam/monkey-plus/parser"
+	"testing"
+)
+
+// evalWithRuntime evaluates the input and returns the inspected result, or the message if it is
+// an error
+func evalWithRuntime(input string, runtime *object.Runtime) string {
+	program := parser.New(lexer.New(input)).ParseProgram()
+	evaluated := Eval(program, object.NewEnvironmentWithRuntime(runtime))
+
+	if err, ok := evaluated.(*object.Error); ok {
+		return err.Message
+	}
+	return evaluated.Inspect()
+}
+
+func TestFloatArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1.5", "1.5"},
+		{"1.5 + 1", "2.5"},
+		{"1 - 0.25", "0.75"},
+		{"2.5 * 2", "5.0"},
+		{"10 / 4.0", "2.5"},
+		{"10 / 4", "2"},
+		{"7.5 % 2", "1.5"},
+		{"-0.5", "-0.5"},
+		{"0.1 + 0.2", "0.30000000000000004"},
+		{"1.0 == 1", "true"},
+		{"0.5 < 1", "true"},
+		{"1.5 > 2.5", "false"},
+		{"1.5 / 0", "the right operand of / is 0"},
+		{"1.5 % 0.0", "the right operand of % is 0"},
+		{`1.5 + "a"`, "type mismatch: FLOAT + STRING"},
+		{"1.5 && 2.5", "unknown operator: FLOAT && FLOAT"},
+	}
+
+	for _, tt := range tests {
+		if got := evalWithRuntime(tt.input, &object.Runtime{}); got != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestMathBuiltins(t *testing.T) {
+	InitBuiltins()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"abs(-3)", "3"},
+		{"abs(-2.5)", "2.5"},
+		{"abs(-9223372036854775807 - 1)", "9223372036854775808"},
+		{"pow(2, 10)", "1024"},
+		{"pow(-3, 3)", "-27"},
+		{"pow(2, 0)", "1"},
+		{"pow(2, -1)", "0.5"},
+		{"pow(4, 0.5)", "2.0"},
+		{"pow(-2, 63)", "-9223372036854775808"},
+		{"pow(2, 64)", "18446744073709551616"},
+		{"sqrt(16)", "4.0"},
+		{"sqrt(2)", "1.4142135623730951"},
+		{"sqrt(-1)", "argument to `sqrt` must not be negative, got -1"},
+		{"floor(2.7)", "2"},
+		{"floor(-2.5)", "-3"},
+		{"ceil(2.1)", "3"},
+		{"ceil(7)", "7"},
+		{"floor(pow(10, 19.0))", "cannot convert 1e+19 to INTEGER in `floor`"},
+		{"floor(sqrt(-1.0 * 0))", "0"},
+		{"min(3, 1.5, 2)", "1.5"},
+		{"max([1, 2.5, 2])", "2.5"},
+		{"gcd(12, -18)", "6"},
+		{"gcd(0, 0)", "0"},
+		{"gcd(1.5, 3)", "argument to `gcd` must be INTEGER, got FLOAT"},
+		{"clamp(15, 0, 10)", "10"},
+		{"clamp(-1, 0.5, 10)", "0.5"},
+		{"clamp(5, 0, 10)", "5"},
+		{"clamp(5, 10, 0)", "bounds of `clamp` must be in order, got 10 > 0"},
+		{`clamp("a", 0, 1)`, "argument to `clamp` must be INTEGER, BIGINT or FLOAT, got STRING"},
+		{"sin(0)", "0.0"},
+		{"cos(PI)", "-1.0"},
+		{"floor(tan(PI / 4) + 0.5)", "1"},
+		{"asin(1) * 2 == PI", "true"},
+		{"acos(1)", "0.0"},
+		{"atan(1) * 4 == PI", "true"},
+		{"atan(-1, -1)", "-2.356194490192345"},
+		{"E", "2.718281828459045"},
+		{`sin("a")`, "argument to `sin` must be INTEGER, BIGINT or FLOAT, got STRING"},
+		{"pow(2)", "wrong number of arguments. got=1, want=2"},
+		{"atan()", "wrong number of arguments. got=0, want=1 or 2"},
+	}
+
+	for _, tt := range tests {
+		if got := evalWithRuntime(tt.input, &object.Runtime{}); got != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestCheckedArithmetic(t *testing.T) {
+	InitBuiltins()
+
+	tests := []struct {
+		input string
+		// promoted is the result without checking the overflows, checked is the result with it
+		promoted string
+		checked  string
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808", "integer overflow: 9223372036854775807 + 1"},
+		{"-9223372036854775807 - 2", "-9223372036854775809", "integer overflow: -9223372036854775807 - 2"},
+		{"4611686018427387904 * 2", "9223372036854775808", "integer overflow: 4611686018427387904 * 2"},
+		{"let min = -9223372036854775807 - 1; min * -1", "9223372036854775808", "integer overflow: -9223372036854775808 * -1"},
+		{"let min = -9223372036854775807 - 1; min / -1", "9223372036854775808", "integer overflow: -9223372036854775808 / -1"},
+		{"let min = -9223372036854775807 - 1; min % -1", "0", "0"},
+		{"let min = -9223372036854775807 - 1; -min", "9223372036854775808", "integer overflow: --9223372036854775808"},
+		{"abs(-9223372036854775807 - 1)", "9223372036854775808", "integer overflow: --9223372036854775808"},
+		{"pow(3, 40)", "12157665459056928801", "integer overflow: pow(3, 40)"},
+		{"gcd(-9223372036854775807 - 1, 0)", "9223372036854775808", "integer overflow: gcd(-9223372036854775808, 0)"},
+		// Close to the limits
+		{"9223372036854775806 + 1", "9223372036854775807", "9223372036854775807"},
+		{"-9223372036854775807 - 1", "-9223372036854775808", "-9223372036854775808"},
+		{"-3037000499 * 3037000499", "-9223372030926249001", "-9223372030926249001"},
+		{"pow(-2, 63)", "-9223372036854775808", "-9223372036854775808"},
+		{"9223372036854775807 + 1.0", "9.223372036854776e+18", "9.223372036854776e+18"},
+	}
+
+	for _, tt := range tests {
+		if got := evalWithRuntime(tt.input, &object.Runtime{}); got != tt.promoted {
+			t.Errorf("wrong promoted result for %q. got=%q, want=%q", tt.input, got, tt.promoted)
+		}
+		if got := evalWithRuntime(tt.input, &object.Runtime{CheckOverflow: true}); got != tt.checked {
+			t.Errorf("wrong checked result for %q. got=%q, want=%q", tt.input, got, tt.checked)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package evaluator
+
+import (
+	"fmt"
+	"github.com/lxdlam/monkey-plus/ast"
+	"github.com/lxdlam/monkey-plus/object"
+	"strings"
+)
+
+// formatBuiltins are the builtins converting the values to strings
+func formatBuiltins() map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"format": {Fn: builtinFormat},
+		"str":    {Fn: builtinStr},
+	}
+}
+
+// toString converts a value to a string like `puts` prints it
+func toString(obj object.Object) *object.String {
+	if str, ok := obj.(*object.String); ok {
+		return str
+	}
+
+	if obj == nil {
+		obj = NULL
+	}
+
+	return object.NewString(obj.Inspect())
+}
+
+func evalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+	value := []uint8{}
+
+	for _, part := range node.Parts {
+		obj := Eval(part, env)
+		if isError(obj) {
+			return obj
+		}
+
+		value = append(value, toString(obj).Value...)
+	}
+
+	return object.NewString(string(value))
+}
+
+func builtinStr(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	return toString(args[0])
+}
+
+// builtinFormat is format(fmt, args...). The verbs are like the ones of Go:
+//
+//	%d  an integer
+//	%s  a string
+//	%v  any value, like `str` converts it
+//	%q  a quoted string
+//	%x  an integer or a string in hexadecimal, %X in upper case
+//	%f  a number as a decimal number
+//	%%  a percent sign
+//
+// The flags `-+# 0`, the width and the precision are supported, e.g. `%-8s` or `%.2f`.
+func builtinFormat(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) == 0 {
+		return newError("wrong number of arguments. got=0, want=1 or more")
+	}
+
+	format, ok := args[0].(*object.String)
+	if !ok {
+		return newError("argument to `format` must be STRING, got %s", args[0].Type())
+	}
+
+	var out strings.Builder
+	values := args[1:]
+	used := 0
+
+	spec := string(format.Value)
+	for i := 0; i < len(spec); i++ {
+		if spec[i] != '%' {
+			out.WriteByte(spec[i])
+			continue
+		}
+
+		// The directive runs from the % up to the verb
+		start := i
+		i++
+		for i < len(spec) && strings.IndexByte("-+# 0123456789.", spec[i]) >= 0 {
+			i++
+		}
+		if i == len(spec) {
+			return newError("missing verb at the end of the format %q", spec)
+		}
+
+		directive, verb := spec[start:i+1], spec[i]
+		if verb == '%' {
+			out.WriteByte('%')
+			continue
+		}
+
+		if used == len(values) {
+			return newError("missing argument for %s in `format`", directive)
+		}
+		value := values[used]
+		used++
+
+		formatted, err := formatValue(directive, verb, value)
+		if err != nil {
+			return err
+		}
+		out.WriteString(formatted)
+	}
+
+	if used != len(values) {
+		return newError("too many arguments for `format`. got=%d, want=%d", len(values), used)
+	}
+
+	return object.NewString(out.String())
+}
+
+func formatValue(directive string, verb byte, value object.Object) (string, *object.Error) {
+	switch verb {
+	case 'd':
+		switch value := value.(type) {
+		case *object.Integer:
+			return fmt.Sprintf(directive, value.Value), nil
+		case *object.BigInt:
+			return fmt.Sprintf(directive, value.Value), nil
+		}
+		return "", newError("%s in `format` needs INTEGER, got %s", directive, value.Type())
+	case 'f':
+		if !isNumber(value) {
+			return "", newError("%s in `format` needs INTEGER or FLOAT, got %s", directive, value.Type())
+		}
+		return fmt.Sprintf(directive, toFloat(value)), nil
+	case 's', 'q':
+		str, ok := value.(*object.String)
+		if !ok {
+			return "", newError("%s in `format` needs STRING, got %s", directive, value.Type())
+		}
+		return fmt.Sprintf(directive, string(str.Value)), nil
+	case 'v':
+		return fmt.Sprintf(directive[:len(directive)-1]+"s", string(toString(value).Value)), nil
+	case 'x', 'X':
+		switch value := value.(type) {
+		case *object.Integer:
+			return fmt.Sprintf(directive, value.Value), nil
+		case *object.BigInt:
+			return fmt.Sprintf(directive, value.Value), nil
+		case *object.String:
+			return fmt.Sprintf(directive, string(value.Value)), nil
+		}
+		return "", newError("%s in `format` needs INTEGER or STRING, got %s", directive, value.Type())
+	default:
+		return "", newError("unknown verb %s in `format`", directive)
+	}
+}
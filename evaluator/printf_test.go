@@ -0,0 +1,117 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/object"
+	"testing"
+)
+
+func TestFormatBuiltins(t *testing.T) {
+	InitBuiltins()
+
+	tests := []struct {
+		input string
+		// expected is the inspected result, or the message if it is an error
+		expected string
+	}{
+		{`str(42)`, "42"},
+		{`str("a")`, "a"},
+		{`str([1, "a", true])`, "[1, a, true]"},
+		{`str(if (false) { 1 })`, "null"},
+		{`str()`, "wrong number of arguments. got=0, want=1"},
+		{`format("%d + %d = %d", 1, 2, 3)`, "1 + 2 = 3"},
+		{`format("[%5d|%-5d|%05d|%+d]", 42, 42, 42, 42)`, "[   42|42   |00042|+42]"},
+		{`format("[%s|%6s|%-6s|%.2s]", "abc", "abc", "abc", "abc")`, "[abc|   abc|abc   |ab]"},
+		{`format("%q", "a\"b\n")`, `"a\"b\n"`},
+		{`format("%v and %v", [1, 2], {"a": 1})`, "[1, 2] and {a: 1}"},
+		{`format("%x %X %#x %x", 255, 255, 255, "hi")`, "ff FF 0xff 6869"},
+		{`format("%f %.2f %8.3f", 1, 2, -3)`, "1.000000 2.00   -3.000"},
+		{`format("100%%")`, "100%"},
+		{`format("no verbs")`, "no verbs"},
+		{`format("%d")`, "missing argument for %d in `format`"},
+		{`format("%d", 1, 2)`, "too many arguments for `format`. got=2, want=1"},
+		{`format("%d", "1")`, "%d in `format` needs INTEGER, got STRING"},
+		{`format("%25d|%x|%.3f", pow(2, 70), 255n, 2.5)`, "   1180591620717411303424|ff|2.500"},
+		{`format("%f", "1")`, "%f in `format` needs INTEGER or FLOAT, got STRING"},
+		{`format("%s", 1)`, "%s in `format` needs STRING, got INTEGER"},
+		{`format("%x", true)`, "%x in `format` needs INTEGER or STRING, got BOOLEAN"},
+		{`format("%y", 1)`, "unknown verb %y in `format`"},
+		{`format("50%")`, "missing verb at the end of the format \"50%\""},
+		{`format(1)`, "argument to `format` must be STRING, got INTEGER"},
+		{`format()`, "wrong number of arguments. got=0, want=1 or more"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		var got string
+		if err, ok := evaluated.(*object.Error); ok {
+			got = err.Message
+		} else {
+			got = evaluated.Inspect()
+		}
+
+		if got != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestInterpolatedString(t *testing.T) {
+	InitBuiltins()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let name = "Monkey"; let items = [1, 2]; "Hello ${name}, you have ${len(items)} items"`, "Hello Monkey, you have 2 items"},
+		{`"${1 + 2}${true}${[1]}${"}"}"`, "3true[1]}"},
+		{`"tab\t${"in\tner"}\${x}"`, "tab\tin\tner${x}"},
+		{`let f = fn(x) { "<${x}>" }; "${f(f(1))}"`, "<<1>>"},
+		{`"${if (false) { 1 }}"`, "null"},
+		{`"a${x}b"`, "identifier not found: x"},
+		{`"a${1 + true}b"`, "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		var got string
+		if err, ok := evaluated.(*object.Error); ok {
+			got = err.Message
+		} else {
+			got = string(evaluated.(*object.String).Value)
+		}
+
+		if got != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// The escape sequences are decoded the same way with or without an interpolation, also in the
+// printed string
+func TestEscapedString(t *testing.T) {
+	InitBuiltins()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"\${x}"`, "${x}"},
+		{`let a = 1; "${a}\${x}"`, "1${x}"},
+		{`"a\tb\\c"`, "a\tb\\c"},
+		{`str(len("\${x}"))`, "4"},
+		{`"\${x}" == "$" + "{x}"`, "true"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		if got := evaluated.Inspect(); got != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, got, tt.expected)
+		}
+		if str, ok := evaluated.(*object.String); ok && string(str.Value) != str.Inspect() {
+			t.Errorf("value and inspect differ for %q. value=%q, inspect=%q", tt.input, str.Value, str.Inspect())
+		}
+	}
+}
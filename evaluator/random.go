@@ -0,0 +1,164 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/object"
+	"math"
+	"math/rand"
+)
+
+// randomBuiltins draw from the source of the runtime, seed(n) makes the following values
+// reproducible
+func randomBuiltins() map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"rand_int":   {Fn: builtinRandInt},
+		"rand_float": {Fn: builtinRandFloat},
+		"choice":     {Fn: builtinChoice},
+		"shuffle":    {Fn: builtinShuffle},
+		"sample":     {Fn: builtinSample},
+		"seed":       {Fn: builtinSeed},
+	}
+}
+
+// rand_int(lo, hi) returns an integer from lo to hi, both included
+func builtinRandInt(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	lo, err := integerArg("rand_int", args[0])
+	if err != nil {
+		return err
+	}
+	hi, err := integerArg("rand_int", args[1])
+	if err != nil {
+		return err
+	}
+
+	if lo > hi {
+		return newError("bounds of `rand_int` must be in order, got %d > %d", lo, hi)
+	}
+
+	// The number of the values wraps around to 0 for the whole range of int64
+	n := uint64(hi-lo) + 1
+	return &object.Integer{Value: lo + int64(uint64n(env.Runtime().Random(), n))}
+}
+
+// uint64n returns a uniform value in [0, n), or any uint64 if n is 0
+func uint64n(r *rand.Rand, n uint64) uint64 {
+	if n == 0 {
+		return r.Uint64()
+	}
+
+	if n <= math.MaxInt64 {
+		return uint64(r.Int63n(int64(n)))
+	}
+
+	// More than half of the values are below n, the loop ends quickly
+	for {
+		if v := r.Uint64(); v < n {
+			return v
+		}
+	}
+}
+
+// rand_float() returns a float from 0 included to 1 excluded
+func builtinRandFloat(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError("wrong number of arguments. got=%d, want=0", len(args))
+	}
+
+	return &object.Float{Value: env.Runtime().Random().Float64()}
+}
+
+// choice(coll) returns a random element of an array or character of a string, null if it is empty
+func builtinChoice(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	elems, err := elements("choice", args[0])
+	if err != nil {
+		return err
+	}
+
+	if len(elems) == 0 {
+		return NULL
+	}
+
+	return elems[env.Runtime().Random().Intn(len(elems))]
+}
+
+// shuffle(coll) returns the elements in a random order, the argument is not changed
+func builtinShuffle(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	elems, err := elements("shuffle", args[0])
+	if err != nil {
+		return err
+	}
+
+	shuffled := make([]object.Object, len(elems))
+	copy(shuffled, elems)
+	env.Runtime().Random().Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	return sameSequence(args[0], shuffled)
+}
+
+// sample(coll, k) returns k elements at distinct positions, in a random order
+func builtinSample(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	elems, err := elements("sample", args[0])
+	if err != nil {
+		return err
+	}
+	k, err := integerArg("sample", args[1])
+	if err != nil {
+		return err
+	}
+
+	if k < 0 || k > int64(len(elems)) {
+		return newError("k of `sample` must be between 0 and %d, got %d", len(elems), k)
+	}
+
+	// The first k steps of a Fisher-Yates shuffle
+	picked := make([]object.Object, len(elems))
+	copy(picked, elems)
+	r := env.Runtime().Random()
+	for i := 0; i < int(k); i++ {
+		j := i + r.Intn(len(picked)-i)
+		picked[i], picked[j] = picked[j], picked[i]
+	}
+
+	return sameSequence(args[0], picked[:k])
+}
+
+// sameSequence makes a string of the characters if the original sequence is a string
+func sameSequence(original object.Object, elems []object.Object) object.Object {
+	if original.Type() == object.STRING_OBJ {
+		return joinChars(elems)
+	}
+
+	return &object.Array{Elements: elems}
+}
+
+// seed(n) reseeds the source of the runtime
+func builtinSeed(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	seed, err := integerArg("seed", args[0])
+	if err != nil {
+		return err
+	}
+
+	env.Runtime().Seed(seed)
+	return NULL
+}
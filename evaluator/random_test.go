@@ -0,0 +1,74 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/object"
+	"testing"
+)
+
+func TestRandomBuiltins(t *testing.T) {
+	InitBuiltins()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"all(map(range(200), fn(i) { rand_int(1, 6) }), fn(x) { x > 0 && x < 7 })", "true"},
+		{"sort(reduce(map(range(200), fn(i) { rand_int(1, 3) }), fn(seen, x) { if (any(seen, fn(y) { y == x })) { seen } else { push(seen, x) } }, []))", "[1, 2, 3]"},
+		{"rand_int(5, 5)", "5"},
+		{"type(rand_int(-9223372036854775807 - 1, 9223372036854775807))", "INTEGER"},
+		{"all(map(range(200), fn(i) { rand_float() }), fn(x) { !(x < 0) && x < 1 })", "true"},
+		{"let x = choice([1, 2, 3]); any([1, 2, 3], fn(y) { y == x })", "true"},
+		{`contains("abc", choice("abc"))`, "true"},
+		{"choice([])", "null"},
+		{"let xs = range(20); let s = shuffle(xs); [str(sort(s)) == str(xs), len(s), str(xs) == str(range(20))]", "[true, 20, true]"},
+		{`len(shuffle("hello"))`, "5"},
+		{"shuffle([])", "[]"},
+		{"let s = sample(range(10), 4); [len(s), len(sort(s)), all(s, fn(x) { x > -1 && x < 10 })]", "[4, 4, true]"},
+		{"sort(sample([1, 2, 3], 3))", "[1, 2, 3]"},
+		{"sample([1, 2, 3], 0)", "[]"},
+		{"seed(7); let a = [rand_int(0, 1000000), rand_float(), shuffle(range(10))]; seed(7); str(a) == str([rand_int(0, 1000000), rand_float(), shuffle(range(10))])", "true"},
+		{"rand_int(6, 1)", "bounds of `rand_int` must be in order, got 6 > 1"},
+		{"rand_int(1, 1.5)", "argument to `rand_int` must be INTEGER, got FLOAT"},
+		{"rand_float(1)", "wrong number of arguments. got=1, want=0"},
+		{"choice(1)", "argument to `choice` must be ARRAY or STRING, got INTEGER"},
+		{"sample([1, 2], 3)", "k of `sample` must be between 0 and 2, got 3"},
+		{"sample([1, 2], -1)", "k of `sample` must be between 0 and 2, got -1"},
+		{`seed("a")`, "argument to `seed` must be INTEGER, got STRING"},
+	}
+
+	for _, tt := range tests {
+		if got := evalWithRuntime(tt.input, &object.Runtime{}); got != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestRandomSeed(t *testing.T) {
+	InitBuiltins()
+
+	input := "[rand_int(0, 1000000), rand_float(), choice(range(100)), shuffle(range(10)), sample(range(100), 3)]"
+
+	seeded := func(seed int64) string {
+		runtime := &object.Runtime{}
+		runtime.Seed(seed)
+		return evalWithRuntime(input, runtime)
+	}
+
+	if first, second := seeded(42), seeded(42); first != second {
+		t.Errorf("same seed gives different values.\nfirst=%s\nsecond=%s", first, second)
+	}
+
+	if first, second := seeded(42), seeded(43); first == second {
+		t.Errorf("different seeds give the same values: %s", first)
+	}
+
+	// seed in one interpreter does not change the source of another one
+	reseeded := &object.Runtime{}
+	reseeded.Seed(42)
+	other := &object.Runtime{}
+	other.Seed(42)
+	evalWithRuntime("seed(1); rand_int(0, 10)", reseeded)
+	if got, want := evalWithRuntime(input, other), seeded(42); got != want {
+		t.Errorf("interpreters share their random state.\ngot=%s\nwant=%s", got, want)
+	}
+}
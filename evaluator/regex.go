@@ -0,0 +1,177 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/object"
+	"regexp"
+	"regexp/syntax"
+)
+
+// regexBuiltins are the builtins of the regular expressions. They take a regex made by `regex`, or
+// a pattern string compiled on each call.
+func regexBuiltins() map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"regex":      {Fn: builtinRegex},
+		"match":      {Fn: builtinMatch},
+		"find_all":   {Fn: builtinFindAll},
+		"replace_re": {Fn: builtinReplaceRe},
+		"split_re":   {Fn: builtinSplitRe},
+	}
+}
+
+func compileRegex(pattern string) (*regexp.Regexp, *object.Error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		if syntaxErr, ok := err.(*syntax.Error); ok {
+			return nil, newError("invalid regex %q: %s", pattern, syntaxErr.Code)
+		}
+		return nil, newError("invalid regex %q: %s", pattern, err)
+	}
+
+	return re, nil
+}
+
+// regexArgs checks the regex and the string of the builtins taking (re, s, ...)
+func regexArgs(name string, args []object.Object) (*regexp.Regexp, string, *object.Error) {
+	var re *regexp.Regexp
+	switch arg := args[0].(type) {
+	case *object.Regex:
+		re = arg.Value
+	case *object.String:
+		var err *object.Error
+		if re, err = compileRegex(string(arg.Value)); err != nil {
+			return nil, "", err
+		}
+	default:
+		return nil, "", newError("argument to `%s` must be REGEX or STRING, got %s", name, args[0].Type())
+	}
+
+	values, err := stringArgs(name, args[1:2], 0)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return re, values[0], nil
+}
+
+// groups makes the array of a match and its capture groups, the groups not taking part in the
+// match are null
+func groups(s string, loc []int) *object.Array {
+	elements := make([]object.Object, len(loc)/2)
+	for i := range elements {
+		if loc[2*i] < 0 {
+			elements[i] = NULL
+		} else {
+			elements[i] = object.NewString(s[loc[2*i]:loc[2*i+1]])
+		}
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+func builtinRegex(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	values, err := stringArgs("regex", args, 0)
+	if err != nil {
+		return err
+	}
+
+	re, err := compileRegex(values[0])
+	if err != nil {
+		return err
+	}
+
+	return &object.Regex{Value: re}
+}
+
+// match(re, s) reports whether s contains a match of re
+func builtinMatch(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	re, s, err := regexArgs("match", args)
+	if err != nil {
+		return err
+	}
+
+	return nativeBoolToBooleanObject(re.MatchString(s))
+}
+
+// find_all(re, s) returns every match and its groups
+func builtinFindAll(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	re, s, err := regexArgs("find_all", args)
+	if err != nil {
+		return err
+	}
+
+	matches := []object.Object{}
+	for _, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+		matches = append(matches, groups(s, loc))
+	}
+
+	return &object.Array{Elements: matches}
+}
+
+// replace_re(re, s, repl) replaces every match. repl is a string where `$1` or `${name}` stands for
+// a group, written `\${name}` in a literal, or a function called with the array of the match and
+// its groups returning the string.
+func builtinReplaceRe(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return newError("wrong number of arguments. got=%d, want=3", len(args))
+	}
+
+	re, s, err := regexArgs("replace_re", args)
+	if err != nil {
+		return err
+	}
+
+	if repl, ok := args[2].(*object.String); ok {
+		return object.NewString(re.ReplaceAllString(s, string(repl.Value)))
+	}
+
+	if args[2].Type() != object.FUNC_OBJ && args[2].Type() != object.BUILTIN_OBJ {
+		return newError("argument to `replace_re` must be STRING or FUNCTION, got %s", args[2].Type())
+	}
+
+	value := []uint8{}
+	last := 0
+	for _, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+		result := applyFunction(args[2], []object.Object{groups(s, loc)}, env)
+		if isError(result) {
+			return result
+		}
+
+		repl, ok := result.(*object.String)
+		if !ok {
+			return newError("function of `replace_re` must return STRING, got %s", result.Type())
+		}
+
+		value = append(value, s[last:loc[0]]...)
+		value = append(value, repl.Value...)
+		last = loc[1]
+	}
+	value = append(value, s[last:]...)
+
+	return object.NewString(string(value))
+}
+
+// split_re(re, s) splits s around every match
+func builtinSplitRe(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	re, s, err := regexArgs("split_re", args)
+	if err != nil {
+		return err
+	}
+
+	return stringArray(re.Split(s, -1))
+}
@@ -0,0 +1,59 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/object"
+	"testing"
+)
+
+func TestRegexBuiltins(t *testing.T) {
+	InitBuiltins()
+
+	tests := []struct {
+		input string
+		// expected is the inspected result, or the message if it is an error
+		expected string
+	}{
+		{`regex("a+b")`, "/a+b/"},
+		{`type(regex("a"))`, "REGEX"},
+		{`regex("(a")`, "invalid regex \"(a\": missing closing )"},
+		{`regex("a**")`, "invalid regex \"a**\": invalid nested repetition operator"},
+		{`regex(1)`, "argument to `regex` must be STRING, got INTEGER"},
+		{`let re = regex("a+"); assert_eq(re, regex("a+")); re == re`, "true"},
+		{`match(regex("^[0-9]+$"), "12345")`, "true"},
+		{`match(regex("^[0-9]+$"), "123a")`, "false"},
+		{`match("b.t", "a bit")`, "true"},
+		{`match("(", "a")`, "invalid regex \"(\": missing closing )"},
+		{`match(1, "a")`, "argument to `match` must be REGEX or STRING, got INTEGER"},
+		{`match(regex("a"), 1)`, "argument to `match` must be STRING, got INTEGER"},
+		{`match(regex("a"))`, "wrong number of arguments. got=1, want=2"},
+		{`find_all(regex("(\\w+)=(\\d+)"), "a=1, b=22, c=x")`, "[[a=1, a, 1], [b=22, b, 22]]"},
+		{`find_all(regex("a(x)?"), "a ax")`, "[[a, null], [ax, x]]"},
+		{`find_all(regex("z"), "abc")`, "[]"},
+		{`replace_re(regex("(\\w+)@(\\w+)"), "bob@example", "$2 at \${1}")`, "example at bob"},
+		{`replace_re("[aeiou]", "monkey", "_")`, "m_nk_y"},
+		{`replace_re(regex("\\d+"), "a1b22", fn(m) { str(parse_int(m[0]) * 2) })`, "a2b44"},
+		{`replace_re(regex("x"), "axb", upper)`, "argument to `upper` must be STRING, got ARRAY"},
+		{`replace_re(regex("x"), "axb", fn(m) { 1 })`, "function of `replace_re` must return STRING, got INTEGER"},
+		{`replace_re(regex("x"), "axb", 1)`, "argument to `replace_re` must be STRING or FUNCTION, got INTEGER"},
+		{`replace_re(regex("x"), "abc", fn(m) { 1 })`, "abc"},
+		{`split_re(regex("\\s*,\\s*"), "a , b,c")`, "[a, b, c]"},
+		{`split_re("-", "")`, "[]"},
+		// Parsing a log line
+		{`let m = find_all(regex("(\\w+)=(\\S+)"), "level=warn msg=disk")[1]; m[2]`, "disk"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		var got string
+		if err, ok := evaluated.(*object.Error); ok {
+			got = err.Message
+		} else {
+			got = evaluated.Inspect()
+		}
+
+		if got != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, got, tt.expected)
+		}
+	}
+}
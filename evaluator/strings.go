@@ -0,0 +1,376 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/object"
+	"strconv"
+	"strings"
+)
+
+// stringBuiltins are the builtins manipulating the strings. The strings are byte strings like
+// `len` and `[]` see them, so the indexes and the characters are bytes.
+func stringBuiltins() map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"split":       {Fn: builtinSplit},
+		"join":        {Fn: builtinJoin},
+		"trim":        {Fn: trimmer("trim", strings.Trim)},
+		"trim_left":   {Fn: trimmer("trim_left", strings.TrimLeft)},
+		"trim_right":  {Fn: trimmer("trim_right", strings.TrimRight)},
+		"replace":     {Fn: builtinReplace},
+		"starts_with": {Fn: stringPredicate("starts_with", strings.HasPrefix)},
+		"ends_with":   {Fn: stringPredicate("ends_with", strings.HasSuffix)},
+		"index_of":    {Fn: builtinIndexOf},
+		"upper":       {Fn: stringMapper("upper", strings.ToUpper)},
+		"lower":       {Fn: stringMapper("lower", strings.ToLower)},
+		"repeat":      {Fn: builtinRepeat},
+		"substr":      {Fn: builtinSlice("substr")},
+		"slice":       {Fn: builtinSlice("slice")},
+		"chars":       {Fn: builtinChars},
+		"ord":         {Fn: builtinOrd},
+		"chr":         {Fn: builtinChr},
+		"parse_int":   {Fn: builtinParseInt},
+	}
+}
+
+// stringArgs checks that the arguments from the first are strings and returns their values
+func stringArgs(name string, args []object.Object, first int) ([]string, *object.Error) {
+	values := []string{}
+	for _, arg := range args[first:] {
+		str, ok := arg.(*object.String)
+		if !ok {
+			return nil, newError("argument to `%s` must be STRING, got %s", name, arg.Type())
+		}
+		values = append(values, string(str.Value))
+	}
+
+	return values, nil
+}
+
+func integerArg(name string, arg object.Object) (int64, *object.Error) {
+	integer, ok := smallInteger(arg)
+	if !ok {
+		return 0, newError("argument to `%s` must be INTEGER, got %s", name, arg.Type())
+	}
+
+	return integer.Value, nil
+}
+
+func stringArray(values []string) *object.Array {
+	elements := make([]object.Object, len(values))
+	for i, value := range values {
+		elements[i] = object.NewString(value)
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+// split(s, sep) splits s around every sep, into the characters if sep is empty
+func builtinSplit(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	values, err := stringArgs("split", args, 0)
+	if err != nil {
+		return err
+	}
+
+	if values[1] == "" {
+		return builtinChars(env, args[0])
+	}
+
+	return stringArray(strings.Split(values[0], values[1]))
+}
+
+func builtinJoin(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return newError("argument to `join` must be ARRAY, got %s", args[0].Type())
+	}
+
+	sep, err := stringArgs("join", args, 1)
+	if err != nil {
+		return err
+	}
+
+	values, err := stringArgs("join", arr.Elements, 0)
+	if err != nil {
+		return newError("elements of `join` must be STRING, got %s", firstNotString(arr.Elements).Type())
+	}
+
+	return object.NewString(strings.Join(values, sep[0]))
+}
+
+func firstNotString(elements []object.Object) object.Object {
+	for _, el := range elements {
+		if el.Type() != object.STRING_OBJ {
+			return el
+		}
+	}
+
+	return nil
+}
+
+// trimmer makes trim(s, cutset), the cutset is the whitespaces by default
+func trimmer(name string, trim func(s, cutset string) string) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 1 && len(args) != 2 {
+			return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+		}
+
+		values, err := stringArgs(name, args, 0)
+		if err != nil {
+			return err
+		}
+
+		cutset := " \t\n\r\f\v"
+		if len(values) == 2 {
+			cutset = values[1]
+		}
+
+		return object.NewString(trim(values[0], cutset))
+	}
+}
+
+func stringPredicate(name string, predicate func(s, t string) bool) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 2 {
+			return newError("wrong number of arguments. got=%d, want=2", len(args))
+		}
+
+		values, err := stringArgs(name, args, 0)
+		if err != nil {
+			return err
+		}
+
+		return nativeBoolToBooleanObject(predicate(values[0], values[1]))
+	}
+}
+
+func stringMapper(name string, mapper func(s string) string) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		values, err := stringArgs(name, args, 0)
+		if err != nil {
+			return err
+		}
+
+		return object.NewString(mapper(values[0]))
+	}
+}
+
+// replace(s, old, new, n) replaces the first n occurrences of old, all of them by default
+func builtinReplace(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 3 && len(args) != 4 {
+		return newError("wrong number of arguments. got=%d, want=3 or 4", len(args))
+	}
+
+	values, err := stringArgs("replace", args[:3], 0)
+	if err != nil {
+		return err
+	}
+
+	n := int64(-1)
+	if len(args) == 4 {
+		if n, err = integerArg("replace", args[3]); err != nil {
+			return err
+		}
+	}
+
+	return object.NewString(strings.Replace(values[0], values[1], values[2], int(n)))
+}
+
+// index_of(s, sub) returns the index of the first sub in s, -1 if there is none
+func builtinIndexOf(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	values, err := stringArgs("index_of", args, 0)
+	if err != nil {
+		return err
+	}
+
+	return &object.Integer{Value: int64(strings.Index(values[0], values[1]))}
+}
+
+// maxRepeatLength keeps `repeat` from running out of memory
+const maxRepeatLength = 1 << 30
+
+func builtinRepeat(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	values, err := stringArgs("repeat", args[:1], 0)
+	if err != nil {
+		return err
+	}
+
+	count, err := integerArg("repeat", args[1])
+	if err != nil {
+		return err
+	}
+
+	if count < 0 {
+		return newError("count of `repeat` must not be negative, got %d", count)
+	}
+
+	if count > 0 && int64(len(values[0])) > maxRepeatLength/count {
+		return newError("result of `repeat` is longer than %d bytes", maxRepeatLength)
+	}
+
+	return object.NewString(strings.Repeat(values[0], int(count)))
+}
+
+// builtinSlice makes slice(x, start, end), the part of a string or an array from start up to but
+// excluding end. end is the length by default, the negative indexes count from the end and the
+// indexes out of range are clamped like in Python. `substr` only takes the strings.
+func builtinSlice(name string) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 2 && len(args) != 3 {
+			return newError("wrong number of arguments. got=%d, want=2 or 3", len(args))
+		}
+
+		var length int64
+		switch arg := args[0].(type) {
+		case *object.String:
+			length = int64(len(arg.Value))
+		case *object.Array:
+			length = int64(len(arg.Elements))
+		}
+
+		if args[0].Type() != object.STRING_OBJ && (name == "substr" || args[0].Type() != object.ARRAY_OBJ) {
+			expected := "STRING"
+			if name == "slice" {
+				expected = "STRING or ARRAY"
+			}
+			return newError("argument to `%s` must be %s, got %s", name, expected, args[0].Type())
+		}
+
+		bounds := []int64{0, length}
+		for i, arg := range args[1:] {
+			index, err := integerArg(name, arg)
+			if err != nil {
+				return err
+			}
+			bounds[i] = clampIndex(index, length)
+		}
+
+		start, end := bounds[0], bounds[1]
+		if end < start {
+			end = start
+		}
+
+		if str, ok := args[0].(*object.String); ok {
+			return object.NewString(string(str.Value[start:end]))
+		}
+
+		elements := make([]object.Object, end-start)
+		copy(elements, args[0].(*object.Array).Elements[start:end])
+		return &object.Array{Elements: elements}
+	}
+}
+
+func clampIndex(index, length int64) int64 {
+	if index < 0 {
+		index += length
+	}
+
+	if index < 0 {
+		return 0
+	}
+	if index > length {
+		return length
+	}
+	return index
+}
+
+func builtinChars(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	if args[0].Type() != object.STRING_OBJ {
+		return newError("argument to `chars` must be STRING, got %s", args[0].Type())
+	}
+
+	chars, _ := elements("chars", args[0])
+	return &object.Array{Elements: chars}
+}
+
+// ord returns the byte of a string of one character
+func builtinOrd(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	str, ok := args[0].(*object.String)
+	if !ok {
+		return newError("argument to `ord` must be STRING, got %s", args[0].Type())
+	}
+
+	if len(str.Value) != 1 {
+		return newError("argument to `ord` must be a single character, got %d characters", len(str.Value))
+	}
+
+	return &object.Integer{Value: int64(str.Value[0])}
+}
+
+// chr is the inverse of ord
+func builtinChr(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	code, err := integerArg("chr", args[0])
+	if err != nil {
+		return err
+	}
+
+	if code < 0 || code > 255 {
+		return newError("argument to `chr` must be between 0 and 255, got %d", code)
+	}
+
+	return object.NewString(string([]uint8{uint8(code)}))
+}
+
+// parse_int(s, base) parses an integer in the base, 10 by default. The base 0 tells the base from
+// the prefix like `0x`.
+func builtinParseInt(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	values, err := stringArgs("parse_int", args[:1], 0)
+	if err != nil {
+		return err
+	}
+
+	base := int64(10)
+	if len(args) == 2 {
+		if base, err = integerArg("parse_int", args[1]); err != nil {
+			return err
+		}
+
+		if base != 0 && (base < 2 || base > 36) {
+			return newError("base of `parse_int` must be 0 or between 2 and 36, got %d", base)
+		}
+	}
+
+	value, parseErr := strconv.ParseInt(values[0], int(base), 64)
+	if parseErr != nil {
+		if parseErr.(*strconv.NumError).Err == strconv.ErrRange {
+			return newError("could not parse %q as integer: out of range", values[0])
+		}
+		return newError("could not parse %q as integer", values[0])
+	}
+
+	return &object.Integer{Value: value}
+}
@@ -0,0 +1,101 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/object"
+	"testing"
+)
+
+func TestStringBuiltins(t *testing.T) {
+	InitBuiltins()
+
+	tests := []struct {
+		input string
+		// expected is the inspected result, or the message if it is an error
+		expected string
+	}{
+		{`split("a,b,,c", ",")`, "[a, b, , c]"},
+		{`split("abc", "")`, "[a, b, c]"},
+		{`split("", ",")`, "[]"},
+		{`split("a b", 1)`, "argument to `split` must be STRING, got INTEGER"},
+		{`split("a")`, "wrong number of arguments. got=1, want=2"},
+		{`join(["a", "b", "c"], ", ")`, "a, b, c"},
+		{`join([], "-")`, ""},
+		{`join(split("1,2,3", ","), "+")`, "1+2+3"},
+		{`join(["a", 1], ",")`, "elements of `join` must be STRING, got INTEGER"},
+		{`join("abc", ",")`, "argument to `join` must be ARRAY, got STRING"},
+		{`trim("  monkey \t\n")`, "monkey"},
+		{`trim_left("  monkey  ")`, "monkey  "},
+		{`trim_right("  monkey  ")`, "  monkey"},
+		{`trim("xxmonkeyx", "x")`, "monkey"},
+		{`trim(1)`, "argument to `trim` must be STRING, got INTEGER"},
+		{`replace("banana", "a", "o")`, "bonono"},
+		{`replace("banana", "a", "o", 2)`, "bonona"},
+		{`replace("banana", "a", 1)`, "argument to `replace` must be STRING, got INTEGER"},
+		{`replace("banana", "a", "o", "2")`, "argument to `replace` must be INTEGER, got STRING"},
+		{`contains("monkey", "key")`, "true"},
+		{`contains("monkey", "Key")`, "false"},
+		{`contains("monkey", "")`, "true"},
+		{`contains("monkey", 1)`, "argument to `contains` must be STRING, got INTEGER"},
+		{`starts_with("monkey", "mon")`, "true"},
+		{`starts_with("monkey", "key")`, "false"},
+		{`ends_with("monkey", "key")`, "true"},
+		{`ends_with("monkey", 1)`, "argument to `ends_with` must be STRING, got INTEGER"},
+		{`index_of("monkey", "key")`, "3"},
+		{`index_of("monkey", "x")`, "-1"},
+		{`upper("Monkey+")`, "MONKEY+"},
+		{`lower("Monkey+")`, "monkey+"},
+		{`upper([])`, "argument to `upper` must be STRING, got ARRAY"},
+		{`repeat("ab", 3)`, "ababab"},
+		{`repeat("ab", 0)`, ""},
+		{`repeat("ab", -1)`, "count of `repeat` must not be negative, got -1"},
+		{`repeat("ab", 1000000000000)`, "result of `repeat` is longer than 1073741824 bytes"},
+		{`substr("monkey", 1, 3)`, "on"},
+		{`substr("monkey", 3)`, "key"},
+		{`substr("monkey", -3)`, "key"},
+		{`substr("monkey", 0, -1)`, "monke"},
+		{`substr("monkey", 4, 2)`, ""},
+		{`substr("monkey", -100, 100)`, "monkey"},
+		{`substr([1, 2], 1)`, "argument to `substr` must be STRING, got ARRAY"},
+		{`substr("monkey", "1")`, "argument to `substr` must be INTEGER, got STRING"},
+		{`slice([1, 2, 3, 4], 1, 3)`, "[2, 3]"},
+		{`slice([1, 2, 3, 4], -1)`, "[4]"},
+		{`slice("monkey", 2, 4)`, "nk"},
+		{`slice(1, 2)`, "argument to `slice` must be STRING or ARRAY, got INTEGER"},
+		{`chars("abc")`, "[a, b, c]"},
+		{`chars("")`, "[]"},
+		{`chars(["a"])`, "argument to `chars` must be STRING, got ARRAY"},
+		{`ord("A")`, "65"},
+		{`ord("\n")`, "10"},
+		{`ord("AB")`, "argument to `ord` must be a single character, got 2 characters"},
+		{`ord(65)`, "argument to `ord` must be STRING, got INTEGER"},
+		{`chr(97)`, "a"},
+		{`chr(ord("a") + 1)`, "b"},
+		{`chr(256)`, "argument to `chr` must be between 0 and 255, got 256"},
+		{`parse_int("42")`, "42"},
+		{`parse_int("-17")`, "-17"},
+		{`parse_int("ff", 16)`, "255"},
+		{`parse_int("0x1f", 0)`, "31"},
+		{`parse_int("4x2")`, "could not parse \"4x2\" as integer"},
+		{`parse_int("")`, "could not parse \"\" as integer"},
+		{`parse_int("99999999999999999999")`, "could not parse \"99999999999999999999\" as integer: out of range"},
+		{`parse_int("1", 1)`, "base of `parse_int` must be 0 or between 2 and 36, got 1"},
+		{`parse_int(1)`, "argument to `parse_int` must be STRING, got INTEGER"},
+		// Parsing a CSV line
+		{`sum(map(split(" 1, 2,3 ", ","), fn(field) { parse_int(trim(field)) }))`, "6"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		var got string
+		if err, ok := evaluated.(*object.Error); ok {
+			got = err.Message
+		} else {
+			got = evaluated.Inspect()
+		}
+
+		if got != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package evaluator
+
+import (
+	"github.com/lxdlam/monkey-plus/object"
+	"math"
+	"time"
+)
+
+// timeBuiltins tell the time of the clock of the runtime, the durations are integers of
+// milliseconds and the layouts are the ones of Go's time package
+func timeBuiltins() map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"now":         {Fn: builtinNow},
+		"unix":        {Fn: builtinUnix},
+		"sleep":       {Fn: builtinSleep},
+		"format_time": {Fn: builtinFormatTime},
+		"parse_time":  {Fn: builtinParseTime},
+		"duration":    {Fn: builtinDuration},
+	}
+}
+
+func timeArg(name string, arg object.Object) (time.Time, *object.Error) {
+	t, ok := arg.(*object.Time)
+	if !ok {
+		return time.Time{}, newError("argument to `%s` must be TIME, got %s", name, arg.Type())
+	}
+
+	return t.Value, nil
+}
+
+// milliseconds converts the milliseconds to a duration, it fails if the duration does not fit
+func milliseconds(ms int64) (time.Duration, *object.Error) {
+	if ms > math.MaxInt64/int64(time.Millisecond) || ms < math.MinInt64/int64(time.Millisecond) {
+		return 0, newError("duration out of range: %d ms", ms)
+	}
+
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// evalTimeInfixExpression adds or subtracts milliseconds to a time, subtracts two times to the
+// milliseconds between them and compares two times
+func evalTimeInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.Time).Value
+
+	if ms, ok := right.(*object.Integer); ok {
+		d, err := milliseconds(ms.Value)
+		if err != nil {
+			return err
+		}
+
+		switch operator {
+		case "+":
+			return &object.Time{Value: leftVal.Add(d)}
+		case "-":
+			return &object.Time{Value: leftVal.Add(-d)}
+		default:
+			return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		}
+	}
+
+	rightVal := right.(*object.Time).Value
+	switch operator {
+	case "-":
+		return &object.Integer{Value: leftVal.Sub(rightVal).Milliseconds()}
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.After(rightVal))
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Before(rightVal))
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Equal(rightVal))
+	case "!=":
+		return nativeBoolToBooleanObject(!leftVal.Equal(rightVal))
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func builtinNow(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError("wrong number of arguments. got=%d, want=0", len(args))
+	}
+
+	return &object.Time{Value: env.Runtime().Now()}
+}
+
+// unix() returns the seconds since the Unix epoch, unix(t) those of the time
+func builtinUnix(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) > 1 {
+		return newError("wrong number of arguments. got=%d, want=0 or 1", len(args))
+	}
+
+	if len(args) == 0 {
+		return &object.Integer{Value: env.Runtime().Now().Unix()}
+	}
+
+	t, err := timeArg("unix", args[0])
+	if err != nil {
+		return err
+	}
+
+	return &object.Integer{Value: t.Unix()}
+}
+
+// sleep(ms) waits on the clock of the runtime, it stops with an error if the runtime is canceled
+func builtinSleep(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	ms, err := integerArg("sleep", args[0])
+	if err != nil {
+		return err
+	}
+
+	if ms < 0 {
+		return newError("argument to `sleep` must not be negative, got %d", ms)
+	}
+
+	d, err := milliseconds(ms)
+	if err != nil {
+		return err
+	}
+
+	if err := env.Runtime().Sleep(d); err != nil {
+		return newError("sleep canceled: %s", err)
+	}
+
+	return NULL
+}
+
+// format_time(t, layout) formats the time like the reference time "2006-01-02 15:04:05" is
+func builtinFormatTime(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	t, err := timeArg("format_time", args[0])
+	if err != nil {
+		return err
+	}
+	layout, err := stringArgs("format_time", args, 1)
+	if err != nil {
+		return err
+	}
+
+	return object.NewString(t.Format(layout[0]))
+}
+
+// parse_time(s, layout) parses the time formatted with the layout, in UTC unless it has a zone
+func builtinParseTime(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	values, err := stringArgs("parse_time", args, 0)
+	if err != nil {
+		return err
+	}
+
+	t, parseErr := time.Parse(values[1], values[0])
+	if parseErr != nil {
+		return newError("invalid time %q for layout %q in `parse_time`", values[0], values[1])
+	}
+
+	return &object.Time{Value: t}
+}
+
+// duration(s) converts a duration like "1h30m" or "250ms" to milliseconds
+func builtinDuration(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	values, err := stringArgs("duration", args, 0)
+	if err != nil {
+		return err
+	}
+
+	d, parseErr := time.ParseDuration(values[0])
+	if parseErr != nil {
+		return newError("invalid duration %q in `duration`", values[0])
+	}
+
+	return &object.Integer{Value: d.Milliseconds()}
+}
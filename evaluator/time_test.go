@@ -0,0 +1,90 @@
+package evaluator
+
+import (
+	"context"
+	"github.com/lxdlam/monkey-plus/object"
+	"testing"
+	"time"
+)
+
+var fakeNow = time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+func TestTimeBuiltins(t *testing.T) {
+	InitBuiltins()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"now()", "2024-03-01T12:30:00Z"},
+		{"type(now())", "TIME"},
+		{"unix()", "1709296200"},
+		{`unix(parse_time("1970-01-02", "2006-01-02"))`, "86400"},
+		{"let t = now(); sleep(1500); now() - t", "1500"},
+		{"sleep(0); now()", "2024-03-01T12:30:00Z"},
+		{"now() + 90000", "2024-03-01T12:31:30Z"},
+		{`now() - duration("1h30m")`, "2024-03-01T11:00:00Z"},
+		{"let t = now(); [t + 1 > t, t < t + 1, t == now(), t != t + 1, t + 1 - 1 == t]", "[true, true, true, true, true]"},
+		{"str(sort([now() + 2, now(), now() + 1]))", "[2024-03-01T12:30:00Z, 2024-03-01T12:30:00.001Z, 2024-03-01T12:30:00.002Z]"},
+		{"assert_eq(now(), now() + 0)", "null"},
+		{`format_time(now(), "2006-01-02 15:04")`, "2024-03-01 12:30"},
+		{`format_time(parse_time("2023-12-25T08:00:00+02:00", "2006-01-02T15:04:05Z07:00"), "Jan 2 15:04 MST-07")`, "Dec 25 08:00 +0200+02"},
+		{`parse_time("2023-12-25T08:00:00+02:00", "2006-01-02T15:04:05Z07:00") == parse_time("2023-12-25 06:00", "2006-01-02 15:04")`, "true"},
+		{`json_stringify([now()])`, `["2024-03-01T12:30:00Z"]`},
+		{`duration("250ms")`, "250"},
+		{`duration("-2m")`, "-120000"},
+		{"now() - 9223372036854775807", "duration out of range: 9223372036854775807 ms"},
+		{"now() * 2", "unknown operator: TIME * INTEGER"},
+		{"now() + now()", "unknown operator: TIME + TIME"},
+		{"now() + 1.5", "type mismatch: TIME + FLOAT"},
+		{"1 + now()", "type mismatch: INTEGER + TIME"},
+		{"sleep(-1)", "argument to `sleep` must not be negative, got -1"},
+		{`sleep("1")`, "argument to `sleep` must be INTEGER, got STRING"},
+		{"unix(1)", "argument to `unix` must be TIME, got INTEGER"},
+		{"unix(now(), now())", "wrong number of arguments. got=2, want=0 or 1"},
+		{`format_time("2024", "2006")`, "argument to `format_time` must be TIME, got STRING"},
+		{`parse_time("yesterday", "2006-01-02")`, `invalid time "yesterday" for layout "2006-01-02" in ` + "`parse_time`"},
+		{`duration("soon")`, `invalid duration "soon" in ` + "`duration`"},
+	}
+
+	for _, tt := range tests {
+		runtime := &object.Runtime{Clock: object.NewFakeClock(fakeNow)}
+		if got := evalWithRuntime(tt.input, runtime); got != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestSleepCanceled(t *testing.T) {
+	InitBuiltins()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	got := evalWithRuntime("sleep(60000)", &object.Runtime{Context: ctx})
+
+	if got != "sleep canceled: context deadline exceeded" {
+		t.Errorf("wrong result. got=%q", got)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("sleep should stop once canceled, took %s", elapsed)
+	}
+}
+
+func TestEvalCanceled(t *testing.T) {
+	InitBuiltins()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	runtime := &object.Runtime{Context: ctx, Clock: object.NewFakeClock(fakeNow)}
+	if got := evalWithRuntime("1", runtime); got != "evaluation canceled: context canceled" {
+		t.Errorf("wrong result. got=%q", got)
+	}
+
+	// The fake clock does not move on a canceled sleep either
+	if err := runtime.Sleep(time.Second); err != context.Canceled || !runtime.Now().Equal(fakeNow) {
+		t.Errorf("wrong sleep. got=%v at %s", err, runtime.Now())
+	}
+}
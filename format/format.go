@@ -0,0 +1,318 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"github.com/lxdlam/monkey-plus/ast"
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/parser"
+	"strings"
+)
+
+const INDENT = "  "
+
+var precedences = map[string]int{
+	"||": parser.OR,
+	"&&": parser.AND,
+	"==": parser.EQUALS,
+	"!=": parser.EQUALS,
+	"<":  parser.LESSGREATER,
+	">":  parser.LESSGREATER,
+	"+":  parser.SUM,
+	"-":  parser.SUM,
+	"*":  parser.PRODUCT,
+	"/":  parser.PRODUCT,
+	"%":  parser.PRODUCT,
+}
+
+// Source formats Monkey source code, it fails if the code does not parse
+func Source(src string) (string, error) {
+	l := lexer.New(src)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	if errs := p.Errors(); len(errs) != 0 {
+		return "", errors.New(strings.Join(errs, "\n"))
+	}
+
+	return Program(program, l.Comments()), nil
+}
+
+// Program prints the program in the canonical style, placing the comments between the statements
+func Program(program *ast.Program, comments []lexer.Comment) string {
+	p := &printer{comments: comments}
+	p.statements(program.Statements, 0)
+
+	for p.next < len(p.comments) {
+		p.separate(p.comments[p.next].Pos.Line)
+		p.comment(0)
+	}
+
+	return p.buf.String()
+}
+
+// Expression prints a single expression in the canonical style
+func Expression(exp ast.Expression) string {
+	p := &printer{}
+	p.expression(exp, 0)
+
+	return p.buf.String()
+}
+
+// Signature prints the parameters and the return type of the function literal with their annotations
+func Signature(fl *ast.FunctionLiteral) string {
+	params := []string{}
+	for i, param := range fl.Parameters {
+		if t := fl.ParameterType(i); t != nil {
+			params = append(params, param.Value+": "+t.String())
+		} else {
+			params = append(params, param.Value)
+		}
+	}
+
+	signature := "fn(" + strings.Join(params, ", ") + ")"
+	if fl.ReturnType != nil {
+		signature += " -> " + fl.ReturnType.String()
+	}
+
+	return signature
+}
+
+type printer struct {
+	buf      bytes.Buffer
+	comments []lexer.Comment
+	next     int
+	// lastLine is the last source line printed, blank lines between statements are kept
+	lastLine int
+}
+
+func (p *printer) write(s string) {
+	p.buf.WriteString(s)
+}
+
+func (p *printer) indent(depth int) {
+	p.write(strings.Repeat(INDENT, depth))
+}
+
+// separate keeps one blank line if the source has any before line
+func (p *printer) separate(line int) {
+	if p.lastLine != 0 && line > p.lastLine+1 {
+		p.write("\n")
+	}
+}
+
+func (p *printer) comment(depth int) {
+	c := p.comments[p.next]
+	p.next++
+
+	p.indent(depth)
+	p.write(c.Text + "\n")
+	p.lastLine = c.Pos.Line
+}
+
+func (p *printer) statements(statements []ast.Statement, depth int) {
+	for _, stmt := range statements {
+		for p.next < len(p.comments) && p.comments[p.next].Pos.Before(stmt.Pos()) {
+			p.separate(p.comments[p.next].Pos.Line)
+			p.comment(depth)
+		}
+
+		p.separate(stmt.Pos().Line)
+		p.indent(depth)
+		p.statement(stmt, depth)
+
+		// Comments inside expressions spanning several lines are moved after the statement
+		end := endLine(stmt)
+		interior := []lexer.Comment{}
+		for p.next < len(p.comments) && p.comments[p.next].Pos.Line < end {
+			interior = append(interior, p.comments[p.next])
+			p.next++
+		}
+
+		if p.next < len(p.comments) && p.comments[p.next].Pos.Line == end {
+			p.write(" " + p.comments[p.next].Text)
+			p.next++
+		}
+		p.write("\n")
+
+		for _, c := range interior {
+			p.indent(depth)
+			p.write(c.Text + "\n")
+		}
+		p.lastLine = end
+	}
+}
+
+func (p *printer) statement(stmt ast.Statement, depth int) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		p.write("let " + stmt.Name.Value)
+		if stmt.Type != nil {
+			p.write(": " + stmt.Type.String())
+		}
+		p.write(" = ")
+		p.expression(stmt.Value, depth)
+		p.write(";")
+	case *ast.ReturnStatement:
+		p.write("return ")
+		p.expression(stmt.ReturnValue, depth)
+		p.write(";")
+	case *ast.ExpressionStatement:
+		p.expression(stmt.Expression, depth)
+		if _, ok := stmt.Expression.(*ast.IfExpression); !ok {
+			p.write(";")
+		}
+	case *ast.BlockStatement:
+		p.block(stmt, depth)
+	}
+}
+
+func (p *printer) block(block *ast.BlockStatement, depth int) {
+	hasComments := p.next < len(p.comments) && p.comments[p.next].Pos.Before(block.End)
+	if len(block.Statements) == 0 && !hasComments {
+		p.write("{}")
+		return
+	}
+
+	p.write("{\n")
+	p.lastLine = 0
+	p.statements(block.Statements, depth+1)
+
+	for p.next < len(p.comments) && p.comments[p.next].Pos.Before(block.End) {
+		p.separate(p.comments[p.next].Pos.Line)
+		p.comment(depth + 1)
+	}
+
+	p.indent(depth)
+	p.write("}")
+}
+
+func (p *printer) expression(exp ast.Expression, depth int) {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		p.write(exp.Value)
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.BigIntLiteral:
+		p.write(exp.TokenLiteral())
+	case *ast.Boolean:
+		p.write(exp.Token.Literal)
+	case *ast.StringLiteral:
+		p.write("\"" + exp.Token.Literal + "\"")
+	case *ast.InterpolatedString:
+		p.write("\"")
+		for _, part := range exp.Parts {
+			if text, ok := part.(*ast.StringLiteral); ok {
+				p.write(text.Token.Literal)
+			} else {
+				p.write("${")
+				p.expression(part, depth)
+				p.write("}")
+			}
+		}
+		p.write("\"")
+	case *ast.PrefixExpression:
+		p.write(exp.Operator)
+		p.operand(exp.Right, precedence(exp.Right) < parser.PREFIX, depth)
+	case *ast.InfixExpression:
+		prec := precedence(exp)
+		p.operand(exp.Left, precedence(exp.Left) < prec, depth)
+		p.write(" " + exp.Operator + " ")
+		// Infix operators are left associative
+		p.operand(exp.Right, precedence(exp.Right) <= prec, depth)
+	case *ast.IfExpression:
+		p.write("if (")
+		p.expression(exp.Condition, depth)
+		p.write(") ")
+		p.block(exp.Consequence, depth)
+		if exp.Alternative != nil {
+			p.write(" else ")
+			p.block(exp.Alternative, depth)
+		}
+	case *ast.FunctionLiteral:
+		p.write(Signature(exp) + " ")
+		p.block(exp.Body, depth)
+	case *ast.CallExpression:
+		p.operand(exp.Function, precedence(exp.Function) < parser.CALL, depth)
+		p.write("(")
+		p.list(exp.Arguments, depth)
+		p.write(")")
+	case *ast.IndexExpression:
+		p.operand(exp.Left, precedence(exp.Left) < parser.CALL, depth)
+		p.write("[")
+		p.expression(exp.Index, depth)
+		p.write("]")
+	case *ast.ArrayLiteral:
+		p.write("[")
+		p.list(exp.Elements, depth)
+		p.write("]")
+	case *ast.HashLiteral:
+		p.write("{")
+		for i, key := range exp.Keys {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.expression(key, depth)
+			p.write(": ")
+			p.expression(exp.Pairs[key], depth)
+		}
+		p.write("}")
+	}
+}
+
+func (p *printer) operand(exp ast.Expression, paren bool, depth int) {
+	if paren {
+		p.write("(")
+	}
+
+	p.expression(exp, depth)
+
+	if paren {
+		p.write(")")
+	}
+}
+
+func (p *printer) list(exps []ast.Expression, depth int) {
+	for i, exp := range exps {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.expression(exp, depth)
+	}
+}
+
+func precedence(exp ast.Expression) int {
+	switch exp := exp.(type) {
+	case *ast.InfixExpression:
+		return precedences[exp.Operator]
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.IntegerLiteral:
+		// Folded constants may be negative, they are printed like a prefix expression
+		if exp.Value < 0 {
+			return parser.PREFIX
+		}
+		return parser.INDEX + 1
+	case *ast.CallExpression, *ast.IndexExpression:
+		return parser.CALL
+	default:
+		return parser.INDEX + 1
+	}
+}
+
+// endLine returns the last source line of the node
+func endLine(node ast.Node) int {
+	line := node.Pos().Line
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n.Pos().Line > line {
+			line = n.Pos().Line
+		}
+
+		if block, ok := n.(*ast.BlockStatement); ok && block.End.Line > line {
+			line = block.End.Line
+		}
+
+		return true
+	})
+
+	return line
+}
@@ -0,0 +1,53 @@
+package format
+
+import (
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x=1+2*3", "let x = 1 + 2 * 3;\n"},
+		{"(1 + 2) * 3; 1 - (2 - 3); (1 - 2) - 3", "(1 + 2) * 3;\n1 - (2 - 3);\n1 - 2 - 3;\n"},
+		{"-(1 + 2); !-a; (-a)[0]; -a[0]; f(1)[0](2)", "-(1 + 2);\n!-a;\n(-a)[0];\n-a[0];\nf(1)[0](2);\n"},
+		{"a || b && c == d", "a || b && c == d;\n"},
+		{"let r=-1.50*2", "let r = -1.50 * 2;\n"},
+		{"let b=-12n*2", "let b = -12n * 2;\n"},
+		{`let h = {"b": [1,2], "a": fn(x){x}}`, "let h = {\"b\": [1, 2], \"a\": fn(x) {\n  x;\n}};\n"},
+		{"if(x){1}else{2}", "if (x) {\n  1;\n} else {\n  2;\n}\n"},
+		{"let f = fn() {}; f()", "let f = fn() {};\nf();\n"},
+		{"let f:fn(int)->int=fn(a:int,b)->[int]{[a]}", "let f: fn(int) -> int = fn(a: int, b) -> [int] {\n  [a];\n};\n"},
+		{`puts("a\"b")`, "puts(\"a\\\"b\");\n"},
+		{`"a${ x+1 }\${y}${f("}")}"`, "\"a${x + 1}\\${y}${f(\"}\")}\";\n"},
+		{
+			"# header\nlet a = 1; # one\n\n\nlet f = fn(x) {\n  # inside\n  return x;\n  # last\n};\n# trailer",
+			"# header\nlet a = 1; # one\n\nlet f = fn(x) {\n  # inside\n  return x;\n  # last\n};\n# trailer\n",
+		},
+		{"let h = {\n  1: 2, # one\n  3: 4\n};", "let h = {1: 2, 3: 4};\n# one\n"},
+	}
+
+	for _, tt := range tests {
+		got, err := Source(tt.input)
+		if err != nil {
+			t.Errorf("Source(%q) returned error: %s", tt.input, err)
+			continue
+		}
+
+		if got != tt.expected {
+			t.Errorf("Source(%q) wrong.\ngot=%q\nwant=%q", tt.input, got, tt.expected)
+		}
+
+		again, err := Source(got)
+		if err != nil || again != got {
+			t.Errorf("formatting is not idempotent.\nfirst=%q\nsecond=%q", got, again)
+		}
+	}
+}
+
+func TestSourceWithErrors(t *testing.T) {
+	if _, err := Source("let = 1;"); err == nil {
+		t.Errorf("expected an error for invalid source")
+	}
+}
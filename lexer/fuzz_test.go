@@ -0,0 +1,56 @@
+package lexer
+
+import (
+	"github.com/lxdlam/monkey-plus/token"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// addSeeds adds the inputs of the tests and the benchmark programs to the fuzzing corpus
+func addSeeds(f *testing.F) {
+	seeds := []string{
+		"let five = 5; let ten = 10; let add = fn(x, y) { x + y; }; add(five, ten);",
+		"!-/*5; 5 < 10 > 5; 10 == 10; 10 != 9; a && b || c; 7 % 3",
+		`"foobar" "foo bar" "\t, \b, \n, \r, \f, \", \\" "hello \"world\""`,
+		"# comment\n[1, 2]; {\"foo\": \"bar\"}",
+		"let f = fn(a: string, b: [int]) -> bool { true };",
+		`"unterminated`,
+		`"\`,
+		`"Hello ${name}, ${len(items)} items ${"}"} \${x}" "${" "${a"`,
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	files, _ := filepath.Glob(filepath.Join("..", "benchmarks", "*.mp"))
+	for _, file := range files {
+		if source, err := ioutil.ReadFile(file); err == nil {
+			f.Add(string(source))
+		}
+	}
+}
+
+func FuzzNextToken(f *testing.F) {
+	addSeeds(f)
+
+	f.Fuzz(func(t *testing.T, input string) {
+		l := New(input)
+
+		// Every token consumes at least one byte, so the lexer reaches EOF
+		last := token.Position{Line: 1, Column: 0}
+		for i := 0; i <= len(input); i++ {
+			tok := l.NextToken()
+			if tok.Type == token.EOF {
+				return
+			}
+
+			if !last.Before(tok.Pos) {
+				t.Fatalf("token %q at %s does not follow the previous one at %s", tok.Literal, tok.Pos, last)
+			}
+			last = tok.Pos
+		}
+
+		t.Fatalf("no EOF after %d tokens", len(input)+1)
+	})
+}
@@ -6,24 +6,44 @@ import (
 )
 
 var (
-	// \t, \b, \n, \r, \f, \", \\
-	ESCAPE_SEQUENCE = "tbnrf\"\\"
+	// \t, \b, \n, \r, \f, \", \\, \$
+	ESCAPE_SEQUENCE = "tbnrf\"\\$"
 )
 
+// Comment is a `#` comment skipped by the lexer
+type Comment struct {
+	Pos  token.Position
+	Text string
+}
+
 type Lexer struct {
 	input        string
 	position     int
 	readPosition int
 	ch           byte
+	line         int
+	column       int
+	comments     []Comment
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	return NewAt(input, token.Position{Line: 1, Column: 1})
+}
+
+// NewAt makes a lexer of a source starting at pos, e.g. of an expression embedded in a string
+func NewAt(input string, pos token.Position) *Lexer {
+	l := &Lexer{input: input, line: pos.Line, column: pos.Column - 1}
 	l.readChar()
 	return l
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.column++
+
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -46,6 +66,7 @@ func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
 	l.skipWhitespace()
+	pos := token.Position{Line: l.line, Column: l.column}
 
 	switch l.ch {
 	case '=':
@@ -72,7 +93,12 @@ func (l *Lexer) NextToken() token.Token {
 	case '}':
 		tok = newToken(token.RBRACE, l.ch)
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.Token{Type: token.ARROW, Literal: "->"}
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
 	case '!':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -91,12 +117,7 @@ func (l *Lexer) NextToken() token.Token {
 	case '>':
 		tok = newToken(token.GT, l.ch)
 	case '"':
-		var ok bool
-		if tok.Literal, ok = l.readString(); ok {
-			tok.Type = token.STRING
-		} else {
-			tok.Type = token.ILLEGAL
-		}
+		tok.Literal, tok.Type = l.readString()
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch)
 	case ']':
@@ -132,16 +153,18 @@ func (l *Lexer) NextToken() token.Token {
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Pos = pos
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
+			tok.Type, tok.Literal = l.readNumber()
+			tok.Pos = pos
 			return tok
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
 	}
 
+	tok.Pos = pos
 	l.readChar()
 	return tok
 }
@@ -155,14 +178,28 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) readNumber() string {
+// readNumber reads an INT, a FLOAT if the digits are followed by a dot and more digits, or a BIGINT
+// if they are followed by `n`
+func (l *Lexer) readNumber() (token.TokenType, string) {
 	position := l.position
+	tokenType := token.TokenType(token.INT)
 
 	for isDigit(l.ch) {
 		l.readChar()
 	}
 
-	return l.input[position:l.position]
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokenType = token.FLOAT
+		l.readChar()
+		for isDigit(l.ch) {
+			l.readChar()
+		}
+	} else if l.ch == 'n' {
+		tokenType = token.BIGINT
+		l.readChar()
+	}
+
+	return tokenType, l.input[position:l.position]
 }
 
 func (l *Lexer) skipWhitespace() {
@@ -172,13 +209,24 @@ func (l *Lexer) skipWhitespace() {
 }
 
 func (l *Lexer) skipComment() {
+	pos := token.Position{Line: l.line, Column: l.column}
+	position := l.position
+
 	for l.ch != '\n' && l.ch != 0 {
 		l.readChar()
 	}
+
+	l.comments = append(l.comments, Comment{Pos: pos, Text: strings.TrimRight(l.input[position:l.position], "\r")})
+}
+
+// Comments returns the comments skipped so far, in source order
+func (l *Lexer) Comments() []Comment {
+	return l.comments
 }
 
 func newToken(tokenType token.TokenType, ch byte) token.Token {
-	return token.Token{Type: tokenType, Literal: string(ch)}
+	// string(ch) would encode the bytes above 0x7f as runes
+	return token.Token{Type: tokenType, Literal: string([]byte{ch})}
 }
 
 func isLetter(ch byte) bool {
@@ -189,23 +237,102 @@ func isDigit(ch byte) bool {
 	return '0' <= ch && ch <= '9'
 }
 
-func (l *Lexer) readString() (string, bool) {
+// readString reads a string literal, it is INTERPOLATED if it has `${expression}` parts
+func (l *Lexer) readString() (string, token.TokenType) {
 	position := l.position + 1
+	var tokenType token.TokenType = token.STRING
 	for {
 		l.readChar()
 		if l.ch == '\\' {
 			l.readChar()
 			if l.ch == 0 || !strings.Contains(ESCAPE_SEQUENCE, string(l.ch)) {
-				return l.input[position:l.position], false
+				return l.input[position:l.position], token.ILLEGAL
+			}
+			continue
+		}
+		if l.ch == '$' && l.peekChar() == '{' {
+			l.readChar()
+			if !l.skipInterpolation() {
+				return l.input[position:l.position], token.ILLEGAL
 			}
+			tokenType = token.INTERPOLATED
 			continue
 		}
 		if l.ch == '"' {
 			break
 		}
 		if l.ch == 0 {
-			return l.input[position:l.position], false
+			return l.input[position:l.position], token.ILLEGAL
 		}
 	}
-	return l.input[position:l.position], true
+	return l.input[position:l.position], tokenType
+}
+
+// skipInterpolation skips from the `{` of an embedded expression to its `}`, it reports whether
+// the `}` is found. The braces and the strings in the expression may nest.
+func (l *Lexer) skipInterpolation() bool {
+	depth := 1
+	for depth > 0 {
+		l.readChar()
+		switch l.ch {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '"':
+			if _, tokenType := l.readString(); tokenType == token.ILLEGAL {
+				return false
+			}
+		case 0:
+			return false
+		}
+	}
+
+	return true
+}
+
+// Segment is a part of an interpolated string, the text of an embedded expression if Expression
+type Segment struct {
+	Text       string
+	Expression bool
+	Pos        token.Position
+}
+
+// Segments splits the literal of an INTERPOLATED token into the raw texts and the sources of the
+// embedded expressions, the empty texts are skipped
+func Segments(tok token.Token) []Segment {
+	// The content starts after the opening quote
+	l := &Lexer{input: tok.Literal, line: tok.Pos.Line, column: tok.Pos.Column}
+	l.readChar()
+
+	segments := []Segment{}
+	start, pos := l.position, token.Position{Line: l.line, Column: l.column}
+	addText := func() {
+		if start < l.position {
+			segments = append(segments, Segment{Text: l.input[start:l.position], Pos: pos})
+		}
+	}
+
+	for l.ch != 0 {
+		switch {
+		case l.ch == '\\':
+			l.readChar()
+			l.readChar()
+		case l.ch == '$' && l.peekChar() == '{':
+			addText()
+
+			l.readChar()
+			exprStart, exprPos := l.position+1, token.Position{Line: l.line, Column: l.column + 1}
+			l.skipInterpolation()
+			segments = append(segments, Segment{Text: l.input[exprStart:l.position], Expression: true, Pos: exprPos})
+
+			l.readChar()
+			start, pos = l.position, token.Position{Line: l.line, Column: l.column}
+		default:
+			l.readChar()
+		}
+	}
+	addText()
+
+	return segments
 }
@@ -1,6 +1,9 @@
 package lexer
 
 import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/lxdlam/monkey-plus/token"
@@ -39,6 +42,7 @@ if (5 < 10) {
 5 % 3
 true && false
 false || true
+fn(a: int) -> bool
 z
 `
 
@@ -145,6 +149,14 @@ z
 		{token.FALSE, "false"},
 		{token.OR, "||"},
 		{token.TRUE, "true"},
+		{token.FUNCTION, "fn"},
+		{token.LPAREN, "("},
+		{token.IDENT, "a"},
+		{token.COLON, ":"},
+		{token.IDENT, "int"},
+		{token.RPAREN, ")"},
+		{token.ARROW, "->"},
+		{token.IDENT, "bool"},
 		{token.IDENT, "z"},
 		{token.EOF, ""},
 	}
@@ -165,3 +177,136 @@ z
 		}
 	}
 }
+
+func TestTokenPosition(t *testing.T) {
+	input := `let a = 5;
+# comment
+  if (a) { "str" }`
+
+	tests := []struct {
+		expectedType token.TokenType
+		expectedPos  token.Position
+	}{
+		{token.LET, token.Position{Line: 1, Column: 1}},
+		{token.IDENT, token.Position{Line: 1, Column: 5}},
+		{token.ASSIGN, token.Position{Line: 1, Column: 7}},
+		{token.INT, token.Position{Line: 1, Column: 9}},
+		{token.SEMICOLON, token.Position{Line: 1, Column: 10}},
+		{token.IF, token.Position{Line: 3, Column: 3}},
+		{token.LPAREN, token.Position{Line: 3, Column: 6}},
+		{token.IDENT, token.Position{Line: 3, Column: 7}},
+		{token.RPAREN, token.Position{Line: 3, Column: 8}},
+		{token.LBRACE, token.Position{Line: 3, Column: 10}},
+		{token.STRING, token.Position{Line: 3, Column: 12}},
+		{token.RBRACE, token.Position{Line: 3, Column: 18}},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Pos != tt.expectedPos {
+			t.Fatalf("tests[%d] - position wrong. expected=%s, got=%s", i, tt.expectedPos, tok.Pos)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FLOAT, "1.5"},
+		{token.PLUS, "+"},
+		{token.FLOAT, "10.025"},
+		{token.INT, "3"},
+		{token.ILLEGAL, "."},
+		{token.IDENT, "x"},
+		{token.INT, "4"},
+		{token.ILLEGAL, "."},
+		{token.BIGINT, "12n"},
+		{token.BIGINT, "0n"},
+		{token.IDENT, "x"},
+		{token.EOF, ""},
+	}
+
+	l := New("1.5 + 10.025 3.x 4. 12n 0nx")
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token wrong. expected=%q %q, got=%q %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestInterpolatedString(t *testing.T) {
+	input := `"a${b}\${c}${"}" + f({})}d" "${x"`
+
+	tokens := []token.Token{
+		{Type: token.INTERPOLATED, Literal: `a${b}\${c}${"}" + f({})}d`, Pos: token.Position{Line: 1, Column: 1}},
+		{Type: token.ILLEGAL, Literal: `${x"`, Pos: token.Position{Line: 1, Column: 29}},
+	}
+
+	l := New(input)
+	for i, expected := range tokens {
+		if tok := l.NextToken(); tok != expected {
+			t.Fatalf("tokens[%d] wrong. expected=%+v, got=%+v", i, expected, tok)
+		}
+	}
+
+	segments := []Segment{
+		{Text: "a", Pos: token.Position{Line: 1, Column: 2}},
+		{Text: "b", Expression: true, Pos: token.Position{Line: 1, Column: 5}},
+		{Text: `\${c}`, Pos: token.Position{Line: 1, Column: 7}},
+		{Text: `"}" + f({})`, Expression: true, Pos: token.Position{Line: 1, Column: 14}},
+		{Text: "d", Pos: token.Position{Line: 1, Column: 26}},
+	}
+
+	got := Segments(tokens[0])
+	if len(got) != len(segments) {
+		t.Fatalf("wrong number of segments. expected=%d, got=%+v", len(segments), got)
+	}
+	for i, expected := range segments {
+		if got[i] != expected {
+			t.Errorf("segments[%d] wrong. expected=%+v, got=%+v", i, expected, got[i])
+		}
+	}
+}
+
+// corpus concatenates the Monkey programs under benchmarks/
+func corpus(b *testing.B) string {
+	files, err := filepath.Glob(filepath.Join("..", "benchmarks", "*.mp"))
+	if err != nil || len(files) == 0 {
+		b.Fatalf("no benchmark programs found: %v", err)
+	}
+
+	var sources []string
+	for _, file := range files {
+		source, err := ioutil.ReadFile(file)
+		if err != nil {
+			b.Fatal(err)
+		}
+		sources = append(sources, string(source))
+	}
+
+	return strings.Join(sources, "\n")
+}
+
+func BenchmarkNextToken(b *testing.B) {
+	input := corpus(b)
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		l := New(input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package lint
+
+import (
+	"fmt"
+	"github.com/lxdlam/monkey-plus/ast"
+	"github.com/lxdlam/monkey-plus/evaluator"
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/parser"
+	"github.com/lxdlam/monkey-plus/resolver"
+	"github.com/lxdlam/monkey-plus/suggest"
+	"github.com/lxdlam/monkey-plus/tester"
+	"github.com/lxdlam/monkey-plus/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	SYNTAX       = "syntax"
+	UNDEFINED    = "undefined"
+	UNUSED_LET   = "unused-let"
+	UNUSED_PARAM = "unused-param"
+	SHADOW       = "shadow"
+	UNREACHABLE  = "unreachable"
+)
+
+// Rules lists the rules that can be configured, syntax errors are always reported
+var Rules = []string{UNDEFINED, UNUSED_LET, UNUSED_PARAM, SHADOW, UNREACHABLE}
+
+type Issue struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (i *Issue) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s (%s)", i.File, i.Line, i.Column, i.Message, i.Rule)
+}
+
+// Config enables all the rules by default
+type Config struct {
+	disabled map[string]bool
+}
+
+func NewConfig() *Config {
+	return &Config{disabled: make(map[string]bool)}
+}
+
+func (c *Config) Enabled(rule string) bool {
+	return rule == SYNTAX || !c.disabled[rule]
+}
+
+func (c *Config) Disable(rules ...string) error {
+	for _, rule := range rules {
+		if !isRule(rule) {
+			return fmt.Errorf("unknown rule %q", rule)
+		}
+		c.disabled[rule] = true
+	}
+
+	return nil
+}
+
+// Only disables all the rules except the given ones
+func (c *Config) Only(rules ...string) error {
+	for _, rule := range rules {
+		if !isRule(rule) {
+			return fmt.Errorf("unknown rule %q", rule)
+		}
+	}
+
+	for _, rule := range Rules {
+		c.disabled[rule] = true
+	}
+
+	for _, rule := range rules {
+		delete(c.disabled, rule)
+	}
+
+	return nil
+}
+
+func isRule(rule string) bool {
+	for _, r := range Rules {
+		if r == rule {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Discover expands the paths into the Monkey files. Directories are walked recursively.
+func Discover(paths []string) ([]string, error) {
+	var files []string
+
+	for _, path := range paths {
+		err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if p == path && !info.IsDir() || !info.IsDir() && strings.HasSuffix(info.Name(), ".mp") {
+				files = append(files, p)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// Source parses and checks the source code of the file, the parse errors are reported as syntax issues
+func Source(file, src string, config *Config) []Issue {
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	program.File = file
+
+	if errs := p.ErrorList(); len(errs) != 0 {
+		issues := []Issue{}
+		for _, err := range errs {
+			issues = append(issues, newIssue(file, err.Token.Pos.Line, err.Token.Pos.Column, SYNTAX, err.Message))
+		}
+
+		return issues
+	}
+
+	return Check(program, resolver.Resolve(program, evaluator.BuiltinNames()), config)
+}
+
+// Check reports the issues of a program without parse errors, sorted by position
+func Check(program *ast.Program, info *resolver.Info, config *Config) []Issue {
+	c := &checker{file: program.File, config: config, issues: []Issue{}}
+
+	for _, ident := range info.Unresolved {
+		names := token.Keywords()
+		for _, b := range info.ScopeAt(ident.Pos()).Visible(ident.Pos()) {
+			names = append(names, b.Name)
+		}
+
+		c.report(ident, UNDEFINED, "identifier not found: "+ident.Value+suggest.DidYouMean(ident.Value, names))
+	}
+
+	c.bindings(info.Global)
+	c.unreachable(program)
+
+	sort.SliceStable(c.issues, func(i, j int) bool {
+		a, b := c.issues[i], c.issues[j]
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+
+	return c.issues
+}
+
+type checker struct {
+	file   string
+	config *Config
+	issues []Issue
+}
+
+func newIssue(file string, line, column int, rule, message string) Issue {
+	return Issue{File: file, Line: line, Column: column, Rule: rule, Message: message}
+}
+
+func (c *checker) report(node ast.Node, rule, message string) {
+	if c.config.Enabled(rule) {
+		c.issues = append(c.issues, newIssue(c.file, node.Pos().Line, node.Pos().Column, rule, message))
+	}
+}
+
+func (c *checker) bindings(scope *resolver.Scope) {
+	for _, b := range scope.Bindings {
+		// Names starting with an underscore are unused on purpose
+		if len(b.Uses) == 0 && !strings.HasPrefix(b.Name, "_") && !isTest(b, c.file) {
+			if b.Kind == resolver.Parameter {
+				c.report(b.Ident, UNUSED_PARAM, fmt.Sprintf("parameter %s is not used", b.Name))
+			} else {
+				c.report(b.Ident, UNUSED_LET, fmt.Sprintf("%s declared and not used", b.Name))
+			}
+		}
+
+		if outer := scope.Parent.Lookup(b.Name); outer != nil {
+			if outer.Kind == resolver.Predeclared {
+				c.report(b.Ident, SHADOW, fmt.Sprintf("%s shadows the builtin", b.Name))
+			} else {
+				c.report(b.Ident, SHADOW, fmt.Sprintf("%s shadows the binding at line %d", b.Name, outer.Ident.Pos().Line))
+			}
+		}
+	}
+
+	for _, child := range scope.Children {
+		c.bindings(child)
+	}
+}
+
+// The test and benchmark functions are called by the test runner
+func isTest(b *resolver.Binding, file string) bool {
+	return b.Scope.Parent.Node == nil && strings.HasSuffix(file, tester.TEST_FILE_SUFFIX) &&
+		(strings.HasPrefix(b.Name, tester.TEST_FUNC_PREFIX) || strings.HasPrefix(b.Name, tester.BENCH_FUNC_PREFIX))
+}
+
+// unreachable reports the first statement after a return statement of every block
+func (c *checker) unreachable(program *ast.Program) {
+	check := func(statements []ast.Statement) {
+		for i, stmt := range statements {
+			if _, ok := stmt.(*ast.ReturnStatement); ok && i+1 < len(statements) {
+				c.report(statements[i+1], UNREACHABLE, "unreachable code")
+				return
+			}
+		}
+	}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Program:
+			check(node.Statements)
+		case *ast.BlockStatement:
+			check(node.Statements)
+		}
+
+		return true
+	})
+}
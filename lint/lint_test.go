@@ -0,0 +1,110 @@
+package lint
+
+import (
+	"github.com/lxdlam/monkey-plus/evaluator"
+	"testing"
+)
+
+const input = `let unused = 1;
+let len = fn(arr, _skip) {
+  let unused = 2;
+  return missing;
+  puts(arr);
+};
+let f = fn(x, y) {
+  if (x) {
+    return 1;
+    2;
+  }
+  x
+};
+len(f(1, 2), 3);
+`
+
+func TestSource(t *testing.T) {
+	evaluator.InitBuiltins()
+
+	expected := []struct {
+		line   int
+		column int
+		rule   string
+	}{
+		{1, 5, UNUSED_LET},
+		{2, 5, SHADOW},
+		{3, 7, UNUSED_LET},
+		{3, 7, SHADOW},
+		{4, 10, UNDEFINED},
+		{5, 3, UNREACHABLE},
+		{7, 15, UNUSED_PARAM},
+		{10, 5, UNREACHABLE},
+	}
+
+	issues := Source("bad.mp", input, NewConfig())
+	if len(issues) != len(expected) {
+		t.Fatalf("wrong number of issues. got=%d, want=%d: %v", len(issues), len(expected), issues)
+	}
+
+	for i, tt := range expected {
+		issue := issues[i]
+		if issue.File != "bad.mp" || issue.Line != tt.line || issue.Column != tt.column || issue.Rule != tt.rule {
+			t.Errorf("issues[%d] wrong. got=%s, want=%d:%d %s", i, issue.String(), tt.line, tt.column, tt.rule)
+		}
+	}
+
+	if issues[3].Message != "unused shadows the binding at line 1" {
+		t.Errorf("wrong shadow message. got=%q", issues[3].Message)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	evaluator.InitBuiltins()
+
+	tests := []struct {
+		configure func(c *Config) error
+		expected  int
+	}{
+		{func(c *Config) error { return nil }, 8},
+		{func(c *Config) error { return c.Disable(SHADOW, UNREACHABLE) }, 4},
+		{func(c *Config) error { return c.Only(UNDEFINED) }, 1},
+	}
+
+	for _, tt := range tests {
+		config := NewConfig()
+		if err := tt.configure(config); err != nil {
+			t.Fatal(err)
+		}
+
+		if issues := Source("bad.mp", input, config); len(issues) != tt.expected {
+			t.Errorf("wrong number of issues. got=%d, want=%d", len(issues), tt.expected)
+		}
+	}
+
+	if err := NewConfig().Disable("unknown"); err == nil {
+		t.Errorf("expected an error for an unknown rule")
+	}
+}
+
+func TestSyntaxAndTests(t *testing.T) {
+	evaluator.InitBuiltins()
+
+	issues := Source("bad.mp", "let = 1;", NewConfig())
+	if len(issues) == 0 || issues[0].Rule != SYNTAX || issues[0].Line != 1 || issues[0].Column != 5 {
+		t.Errorf("wrong syntax issues. got=%v", issues)
+	}
+
+	issues = Source("bad.mp", "let counter = 1; puts(countr);", onlyUndefined())
+	if len(issues) != 1 || issues[0].Message != "identifier not found: countr (did you mean `counter`?)" {
+		t.Errorf("wrong undefined issue. got=%v", issues)
+	}
+
+	// The test functions are called by the test runner
+	if issues := Source("math_test.mp", "let test_add = fn() { assert(true) };", NewConfig()); len(issues) != 0 {
+		t.Errorf("test functions must not be reported. got=%v", issues)
+	}
+}
+
+func onlyUndefined() *Config {
+	config := NewConfig()
+	config.Only(UNDEFINED)
+	return config
+}
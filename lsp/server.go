@@ -0,0 +1,542 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/lxdlam/monkey-plus/ast"
+	"github.com/lxdlam/monkey-plus/evaluator"
+	"github.com/lxdlam/monkey-plus/format"
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/lint"
+	"github.com/lxdlam/monkey-plus/parser"
+	"github.com/lxdlam/monkey-plus/resolver"
+	"github.com/lxdlam/monkey-plus/token"
+	"github.com/lxdlam/monkey-plus/transport"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Server is a language server for Monkey, evaluator.InitBuiltins must be called before serving
+type Server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	docs     map[string]*document
+	shutdown bool
+}
+
+type document struct {
+	text     string
+	program  *ast.Program
+	comments []lexer.Comment
+	errors   []parser.Error
+	info     *resolver.Info
+}
+
+func parseDocument(uri, text string) *document {
+	l := lexer.New(text)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	program.File = strings.TrimPrefix(uri, "file://")
+
+	return &document{
+		text:     text,
+		program:  program,
+		comments: l.Comments(),
+		errors:   p.ErrorList(),
+		info:     resolver.Resolve(program, evaluator.BuiltinNames()),
+	}
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:   bufio.NewReader(in),
+		out:  out,
+		docs: make(map[string]*document),
+	}
+}
+
+// Serve handles the messages until the client sends exit or the input is closed
+func (s *Server) Serve() error {
+	for {
+		content, err := transport.ReadMessage(s.in)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var req Request
+		if err := json.Unmarshal(content, &req); err != nil {
+			s.fail(nil, PARSE_ERROR, err.Error())
+			continue
+		}
+
+		if req.Method == "exit" {
+			return nil
+		}
+
+		s.handle(&req)
+	}
+}
+
+func (s *Server) handle(req *Request) {
+	isNotification := len(req.ID) == 0
+
+	if s.shutdown && !isNotification {
+		s.fail(req.ID, INVALID_REQUEST, "server is shut down")
+		return
+	}
+
+	var result interface{}
+	var err error
+
+	switch req.Method {
+	case "initialize":
+		result = s.initialize()
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/didOpen":
+		err = s.didOpen(req.Params)
+	case "textDocument/didChange":
+		err = s.didChange(req.Params)
+	case "textDocument/didClose":
+		err = s.didClose(req.Params)
+	case "textDocument/definition":
+		result, err = s.definition(req.Params)
+	case "textDocument/references":
+		result, err = s.references(req.Params)
+	case "textDocument/hover":
+		result, err = s.hover(req.Params)
+	case "textDocument/completion":
+		result, err = s.completion(req.Params)
+	case "textDocument/documentSymbol":
+		result, err = s.documentSymbol(req.Params)
+	case "textDocument/formatting":
+		result, err = s.formatting(req.Params)
+	default:
+		if !isNotification {
+			s.fail(req.ID, METHOD_NOT_FOUND, fmt.Sprintf("unsupported method %q", req.Method))
+		}
+		return
+	}
+
+	if isNotification {
+		return
+	}
+
+	if err != nil {
+		s.fail(req.ID, INVALID_PARAMS, err.Error())
+	} else {
+		s.send(&Response{JSONRPC: "2.0", ID: req.ID, Result: result})
+	}
+}
+
+func (s *Server) fail(id json.RawMessage, code int, message string) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+
+	s.send(&ErrorResponse{JSONRPC: "2.0", ID: id, Error: ResponseError{Code: code, Message: message}})
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	s.send(&Notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *Server) send(message interface{}) {
+	_ = transport.WriteMessage(s.out, message)
+}
+
+func (s *Server) initialize() *InitializeResult {
+	return &InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:           1,
+			DefinitionProvider:         true,
+			ReferencesProvider:         true,
+			HoverProvider:              true,
+			CompletionProvider:         &CompletionOptions{TriggerCharacters: []string{}},
+			DocumentSymbolProvider:     true,
+			DocumentFormattingProvider: true,
+		},
+		ServerInfo: ServerInfo{Name: "monkey-lsp"},
+	}
+}
+
+func (s *Server) didOpen(raw json.RawMessage) error {
+	var params DidOpenTextDocumentParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return err
+	}
+
+	s.update(params.TextDocument.URI, params.TextDocument.Text)
+
+	return nil
+}
+
+func (s *Server) didChange(raw json.RawMessage) error {
+	var params DidChangeTextDocumentParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return err
+	}
+
+	if n := len(params.ContentChanges); n != 0 {
+		s.update(params.TextDocument.URI, params.ContentChanges[n-1].Text)
+	}
+
+	return nil
+}
+
+func (s *Server) didClose(raw json.RawMessage) error {
+	var params DidCloseTextDocumentParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return err
+	}
+
+	delete(s.docs, params.TextDocument.URI)
+	s.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+
+	return nil
+}
+
+func (s *Server) update(uri, text string) {
+	doc := parseDocument(uri, text)
+	s.docs[uri] = doc
+
+	diagnostics := []Diagnostic{}
+	for _, err := range doc.errors {
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    tokenRange(err.Token),
+			Severity: SEVERITY_ERROR,
+			Source:   "monkey",
+			Message:  err.Message,
+		})
+	}
+
+	// The lint issues would be noise while the code does not parse
+	if len(doc.errors) == 0 {
+		lines := strings.Split(text, "\n")
+		for _, issue := range lint.Check(doc.program, doc.info, lint.NewConfig()) {
+			diagnostics = append(diagnostics, Diagnostic{
+				Range:    wordRange(lines, token.Position{Line: issue.Line, Column: issue.Column}),
+				Severity: SEVERITY_WARNING,
+				Source:   "monkey-lint",
+				Message:  issue.Message,
+			})
+		}
+	}
+
+	s.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+}
+
+func (s *Server) document(uri string) (*document, error) {
+	doc, ok := s.docs[uri]
+	if !ok {
+		return nil, fmt.Errorf("document %s is not open", uri)
+	}
+
+	return doc, nil
+}
+
+// bindingAt returns the binding of the identifier under the cursor, the binding is nil if there is none
+func (s *Server) bindingAt(params *TextDocumentPositionParams) (*resolver.Binding, *ast.Identifier, error) {
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, ident := doc.info.BindingAt(fromPosition(params.Position))
+	return b, ident, nil
+}
+
+func (s *Server) definition(raw json.RawMessage) (interface{}, error) {
+	var params TextDocumentPositionParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+
+	b, _, err := s.bindingAt(&params)
+	if err != nil || b == nil || b.Ident == nil {
+		return nil, err
+	}
+
+	return &Location{URI: params.TextDocument.URI, Range: identRange(b.Ident)}, nil
+}
+
+func (s *Server) references(raw json.RawMessage) (interface{}, error) {
+	var params ReferenceParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+
+	b, _, err := s.bindingAt(&params.TextDocumentPositionParams)
+	if err != nil || b == nil {
+		return nil, err
+	}
+
+	locations := []Location{}
+	if params.Context.IncludeDeclaration && b.Ident != nil {
+		locations = append(locations, Location{URI: params.TextDocument.URI, Range: identRange(b.Ident)})
+	}
+
+	for _, use := range b.Uses {
+		locations = append(locations, Location{URI: params.TextDocument.URI, Range: identRange(use)})
+	}
+
+	return locations, nil
+}
+
+func (s *Server) hover(raw json.RawMessage) (interface{}, error) {
+	var params TextDocumentPositionParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+
+	b, ident, err := s.bindingAt(&params)
+	if err != nil || b == nil {
+		return nil, err
+	}
+
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: describe(b)},
+		Range:    identRange(ident),
+	}, nil
+}
+
+// describe shows the definition of the binding, function bodies are left out
+func describe(b *resolver.Binding) string {
+	var code, where string
+
+	switch b.Kind {
+	case resolver.Let:
+		let := b.Decl.(*ast.LetStatement)
+		code = "let " + b.Name
+		if let.Type != nil {
+			code += ": " + let.Type.String()
+		}
+		code += " = " + declaration(let.Value)
+	case resolver.Parameter:
+		code = "(parameter) " + b.Name
+		fl := b.Decl.(*ast.FunctionLiteral)
+		for i, param := range fl.Parameters {
+			if param == b.Ident && fl.ParameterType(i) != nil {
+				code += ": " + fl.ParameterType(i).String()
+			}
+		}
+		where = "\n\nparameter of `" + declaration(b.Decl.(*ast.FunctionLiteral)) + "`"
+	default:
+		code = "(builtin) " + b.Name
+	}
+
+	if b.Ident != nil && where == "" {
+		where = fmt.Sprintf("\n\ndefined at line %d", b.Ident.Pos().Line)
+	}
+
+	return "```monkey\n" + code + "\n```" + where
+}
+
+func declaration(exp ast.Expression) string {
+	if fl, ok := exp.(*ast.FunctionLiteral); ok && fl != nil {
+		return format.Signature(fl)
+	}
+
+	return format.Expression(exp)
+}
+
+func (s *Server) completion(raw json.RawMessage) (interface{}, error) {
+	var params TextDocumentPositionParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	pos := fromPosition(params.Position)
+	items := []CompletionItem{}
+
+	for _, b := range doc.info.ScopeAt(pos).Visible(pos) {
+		kind := COMPLETION_VARIABLE
+		if isFunction(b) {
+			kind = COMPLETION_FUNCTION
+		}
+
+		items = append(items, CompletionItem{Label: b.Name, Kind: kind, Detail: b.Kind.String()})
+	}
+
+	return items, nil
+}
+
+func isFunction(b *resolver.Binding) bool {
+	if b.Kind == resolver.Predeclared {
+		return true
+	}
+
+	if let, ok := b.Decl.(*ast.LetStatement); ok {
+		_, ok = let.Value.(*ast.FunctionLiteral)
+		return ok
+	}
+
+	return false
+}
+
+func (s *Server) documentSymbol(raw json.RawMessage) (interface{}, error) {
+	var params DocumentParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	return symbols(doc.info.Global), nil
+}
+
+// symbols lists the let bindings of the scope, the bindings of the functions they define are children
+func symbols(scope *resolver.Scope) []DocumentSymbol {
+	result := []DocumentSymbol{}
+
+	for _, b := range scope.Bindings {
+		if b.Kind != resolver.Let {
+			continue
+		}
+
+		symbol := DocumentSymbol{
+			Name:           b.Name,
+			Kind:           SYMBOL_VARIABLE,
+			Range:          nodeRange(b.Decl),
+			SelectionRange: identRange(b.Ident),
+		}
+
+		if isFunction(b) {
+			symbol.Kind = SYMBOL_FUNCTION
+
+			fl := b.Decl.(*ast.LetStatement).Value
+			for _, child := range scope.Children {
+				if child.Node == fl {
+					symbol.Children = symbols(child)
+				}
+			}
+		}
+
+		result = append(result, symbol)
+	}
+
+	return result
+}
+
+func (s *Server) formatting(raw json.RawMessage) (interface{}, error) {
+	var params DocumentParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	edits := []TextEdit{}
+
+	// Formatting would drop the code the parser skipped
+	if len(doc.errors) != 0 {
+		return edits, nil
+	}
+
+	formatted := format.Program(doc.program, doc.comments)
+	if formatted != doc.text {
+		edits = append(edits, TextEdit{Range: Range{End: endOfText(doc.text)}, NewText: formatted})
+	}
+
+	return edits, nil
+}
+
+func fromPosition(pos Position) token.Position {
+	return token.Position{Line: pos.Line + 1, Column: pos.Character + 1}
+}
+
+func toPosition(pos token.Position) Position {
+	return Position{Line: pos.Line - 1, Character: pos.Column - 1}
+}
+
+func tokenRange(tok token.Token) Range {
+	width := len(tok.Literal)
+	if width == 0 {
+		width = 1
+	}
+
+	if tok.Type == token.STRING || tok.Type == token.INTERPOLATED {
+		width += 2
+	}
+
+	start := toPosition(tok.Pos)
+	return Range{Start: start, End: Position{Line: start.Line, Character: start.Character + width}}
+}
+
+// wordRange spans the word starting at pos, or a single character if there is none
+func wordRange(lines []string, pos token.Position) Range {
+	start := toPosition(pos)
+	end := start.Character
+
+	if start.Line < len(lines) {
+		line := lines[start.Line]
+		for end < len(line) && (line[end] == '_' || unicode.IsLetter(rune(line[end])) || unicode.IsDigit(rune(line[end]))) {
+			end++
+		}
+	}
+
+	if end == start.Character {
+		end++
+	}
+
+	return Range{Start: start, End: Position{Line: start.Line, Character: end}}
+}
+
+func identRange(ident *ast.Identifier) Range {
+	return tokenRange(ident.Token)
+}
+
+// nodeRange spans from the start of the node to the end of its last token
+func nodeRange(node ast.Node) Range {
+	start := toPosition(node.Pos())
+	end := start
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		var last Position
+		switch n := n.(type) {
+		case *ast.BlockStatement:
+			last = toPosition(n.End)
+			last.Character++
+		case *ast.StringLiteral:
+			last = tokenRange(n.Token).End
+		case *ast.InterpolatedString:
+			// The string spans its parts
+			last = tokenRange(n.Token).End
+			if last.Line > end.Line || last.Line == end.Line && last.Character > end.Character {
+				end = last
+			}
+			return false
+		default:
+			last = tokenRange(token.Token{Literal: n.TokenLiteral(), Pos: n.Pos()}).End
+		}
+
+		if last.Line > end.Line || last.Line == end.Line && last.Character > end.Character {
+			end = last
+		}
+
+		return true
+	})
+
+	return Range{Start: start, End: end}
+}
+
+func endOfText(text string) Position {
+	line := strings.Count(text, "\n")
+	return Position{Line: line, Character: len(text) - strings.LastIndex(text, "\n") - 1}
+}
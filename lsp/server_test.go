@@ -0,0 +1,332 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/lxdlam/monkey-plus/evaluator"
+	"github.com/lxdlam/monkey-plus/transport"
+	"io"
+	"testing"
+	"time"
+)
+
+const URI = "file:///tmp/closure.mp"
+
+const program = `# makes an adder
+let make = fn(base) {
+  let add = fn(x) { x + base };
+  add
+};
+let adder = make(10);
+puts(adder(5));
+`
+
+type message struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *ResponseError  `json:"error"`
+}
+
+// client is a JSON-RPC client talking to the server in the same process
+type client struct {
+	t        *testing.T
+	w        io.Writer
+	id       int
+	messages chan *message
+	pending  []*message
+}
+
+func newClient(t *testing.T, w io.Writer, r io.Reader) *client {
+	c := &client{t: t, w: w, messages: make(chan *message, 64)}
+
+	go func() {
+		reader := bufio.NewReader(r)
+		for {
+			content, err := transport.ReadMessage(reader)
+			if err != nil {
+				close(c.messages)
+				return
+			}
+
+			var m message
+			if err := json.Unmarshal(content, &m); err != nil {
+				close(c.messages)
+				return
+			}
+			c.messages <- &m
+		}
+	}()
+
+	return c
+}
+
+func (c *client) notify(method string, params interface{}) {
+	if err := transport.WriteMessage(c.w, map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *client) call(method string, params interface{}) *message {
+	c.id++
+	req := map[string]interface{}{"jsonrpc": "2.0", "id": c.id, "method": method, "params": params}
+	if err := transport.WriteMessage(c.w, req); err != nil {
+		c.t.Fatal(err)
+	}
+
+	id := c.id
+	return c.wait(func(m *message) bool { return m.ID != nil && *m.ID == id })
+}
+
+// result calls the method and decodes its result into v
+func (c *client) result(method string, params interface{}, v interface{}) {
+	resp := c.call(method, params)
+	if resp.Error != nil {
+		c.t.Fatalf("%s failed: %s", method, resp.Error.Message)
+	}
+
+	if err := json.Unmarshal(resp.Result, v); err != nil {
+		c.t.Fatalf("%s returned invalid result %s: %s", method, resp.Result, err)
+	}
+}
+
+func (c *client) diagnostics() *PublishDiagnosticsParams {
+	m := c.wait(func(m *message) bool { return m.Method == "textDocument/publishDiagnostics" })
+
+	var params PublishDiagnosticsParams
+	if err := json.Unmarshal(m.Params, &params); err != nil {
+		c.t.Fatal(err)
+	}
+	return &params
+}
+
+func (c *client) wait(match func(m *message) bool) *message {
+	for idx, m := range c.pending {
+		if match(m) {
+			c.pending = append(c.pending[:idx], c.pending[idx+1:]...)
+			return m
+		}
+	}
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case m, ok := <-c.messages:
+			if !ok {
+				c.t.Fatalf("connection closed")
+			}
+
+			if match(m) {
+				return m
+			}
+			c.pending = append(c.pending, m)
+		case <-timeout:
+			c.t.Fatalf("timeout waiting for the message")
+		}
+	}
+}
+
+func startServer(t *testing.T) (*client, chan error) {
+	evaluator.InitBuiltins()
+
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer(serverReader, serverWriter).Serve()
+	}()
+
+	c := newClient(t, clientWriter, clientReader)
+
+	var result InitializeResult
+	c.result("initialize", map[string]interface{}{"capabilities": map[string]interface{}{}}, &result)
+	if !result.Capabilities.DefinitionProvider || !result.Capabilities.DocumentFormattingProvider {
+		t.Fatalf("wrong capabilities. got=%+v", result.Capabilities)
+	}
+	c.notify("initialized", map[string]interface{}{})
+
+	return c, done
+}
+
+func open(c *client, text string) *PublishDiagnosticsParams {
+	c.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": URI, "languageId": "monkey", "version": 1, "text": text},
+	})
+	return c.diagnostics()
+}
+
+func at(line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": URI},
+		"position":     map[string]interface{}{"line": line, "character": character},
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	c, _ := startServer(t)
+
+	diags := open(c, "let x = 1;\nlet = 2;\n")
+	if diags.URI != URI || len(diags.Diagnostics) == 0 {
+		t.Fatalf("wrong diagnostics. got=%+v", diags)
+	}
+
+	d := diags.Diagnostics[0]
+	expected := Range{Start: Position{Line: 1, Character: 4}, End: Position{Line: 1, Character: 5}}
+	if d.Range != expected || d.Severity != SEVERITY_ERROR {
+		t.Errorf("wrong diagnostic. got=%+v", d)
+	}
+
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": URI, "version": 2},
+		"contentChanges": []map[string]interface{}{{"text": "let x = 1;\nlet y = 2;\nputs(x);\n"}},
+	})
+
+	// The parse errors are cleared and the lint issues are reported
+	diags = c.diagnostics()
+	expected = Range{Start: Position{Line: 1, Character: 4}, End: Position{Line: 1, Character: 5}}
+	if len(diags.Diagnostics) != 1 || diags.Diagnostics[0].Range != expected || diags.Diagnostics[0].Severity != SEVERITY_WARNING {
+		t.Errorf("wrong diagnostics. got=%+v", diags)
+	}
+}
+
+func TestNavigation(t *testing.T) {
+	c, _ := startServer(t)
+	open(c, program)
+
+	// base in the body of add
+	var loc Location
+	c.result("textDocument/definition", at(2, 25), &loc)
+	if loc.URI != URI || loc.Range.Start != (Position{Line: 1, Character: 14}) {
+		t.Errorf("wrong definition. got=%+v", loc)
+	}
+
+	var builtin *Location
+	c.result("textDocument/definition", at(6, 1), &builtin)
+	if builtin != nil {
+		t.Errorf("builtins have no definition. got=%+v", builtin)
+	}
+
+	params := at(1, 5)
+	params["context"] = map[string]interface{}{"includeDeclaration": true}
+	var refs []Location
+	c.result("textDocument/references", params, &refs)
+	if len(refs) != 2 || refs[0].Range.Start != (Position{Line: 1, Character: 4}) || refs[1].Range.Start != (Position{Line: 5, Character: 12}) {
+		t.Errorf("wrong references. got=%+v", refs)
+	}
+
+	var hover Hover
+	c.result("textDocument/hover", at(5, 14), &hover)
+	if hover.Contents.Value != "```monkey\nlet make = fn(base)\n```\n\ndefined at line 2" {
+		t.Errorf("wrong hover. got=%q", hover.Contents.Value)
+	}
+
+	c.result("textDocument/hover", at(2, 20), &hover)
+	if hover.Contents.Value != "```monkey\n(parameter) x\n```\n\nparameter of `fn(x)`" {
+		t.Errorf("wrong hover. got=%q", hover.Contents.Value)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	c, _ := startServer(t)
+	open(c, program)
+
+	tests := []struct {
+		line, character int
+		expected        map[string]int
+		unexpected      []string
+	}{
+		{0, 0, map[string]int{"len": COMPLETION_FUNCTION}, []string{"make", "base"}},
+		{2, 20, map[string]int{"x": COMPLETION_VARIABLE, "base": COMPLETION_VARIABLE, "add": COMPLETION_FUNCTION}, []string{}},
+		{6, 0, map[string]int{"make": COMPLETION_FUNCTION, "adder": COMPLETION_VARIABLE}, []string{"base", "x"}},
+	}
+
+	for _, tt := range tests {
+		var items []CompletionItem
+		c.result("textDocument/completion", at(tt.line, tt.character), &items)
+
+		kinds := make(map[string]int)
+		for _, item := range items {
+			kinds[item.Label] = item.Kind
+		}
+
+		for name, kind := range tt.expected {
+			if kinds[name] != kind {
+				t.Errorf("wrong completion of %s at %d:%d. got=%d, want=%d", name, tt.line, tt.character, kinds[name], kind)
+			}
+		}
+
+		for _, name := range tt.unexpected {
+			if _, ok := kinds[name]; ok {
+				t.Errorf("%s should not be completed at %d:%d", name, tt.line, tt.character)
+			}
+		}
+	}
+}
+
+func TestDocumentSymbol(t *testing.T) {
+	c, _ := startServer(t)
+	open(c, program)
+
+	var symbols []DocumentSymbol
+	c.result("textDocument/documentSymbol", map[string]interface{}{"textDocument": map[string]interface{}{"uri": URI}}, &symbols)
+
+	if len(symbols) != 2 || symbols[0].Name != "make" || symbols[0].Kind != SYMBOL_FUNCTION || symbols[1].Name != "adder" {
+		t.Fatalf("wrong symbols. got=%+v", symbols)
+	}
+
+	expected := Range{Start: Position{Line: 1, Character: 0}, End: Position{Line: 4, Character: 1}}
+	if symbols[0].Range != expected {
+		t.Errorf("wrong range of make. got=%+v, want=%+v", symbols[0].Range, expected)
+	}
+
+	children := symbols[0].Children
+	if len(children) != 1 || children[0].Name != "add" || children[0].Kind != SYMBOL_FUNCTION {
+		t.Errorf("wrong children of make. got=%+v", children)
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	c, _ := startServer(t)
+	open(c, "let x=1 # one\nputs(x)")
+
+	var edits []TextEdit
+	c.result("textDocument/formatting", map[string]interface{}{"textDocument": map[string]interface{}{"uri": URI}}, &edits)
+
+	expected := Range{End: Position{Line: 1, Character: 7}}
+	if len(edits) != 1 || edits[0].Range != expected || edits[0].NewText != "let x = 1; # one\nputs(x);\n" {
+		t.Errorf("wrong edits. got=%+v", edits)
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	c, done := startServer(t)
+
+	if resp := c.call("textDocument/unknown", map[string]interface{}{}); resp.Error == nil || resp.Error.Code != METHOD_NOT_FOUND {
+		t.Errorf("expected method not found error. got=%+v", resp)
+	}
+
+	if resp := c.call("textDocument/hover", at(0, 0)); resp.Error == nil || resp.Error.Code != INVALID_PARAMS {
+		t.Errorf("expected an error for a document not opened. got=%+v", resp)
+	}
+
+	if resp := c.call("shutdown", nil); resp.Error != nil || string(resp.Result) != "null" {
+		t.Errorf("wrong shutdown response. got=%+v", resp)
+	}
+
+	if resp := c.call("textDocument/hover", at(0, 0)); resp.Error == nil || resp.Error.Code != INVALID_REQUEST {
+		t.Errorf("requests must fail after shutdown. got=%+v", resp)
+	}
+
+	c.notify("exit", nil)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("server failed: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("server did not exit")
+	}
+}
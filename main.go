@@ -10,11 +10,28 @@ import (
 	user2 "os/user"
 )
 
+var commands = map[string]func(args []string) int{
+	"run":       bin.RunScript,
+	"test":      bin.Test,
+	"bench":     bin.Bench,
+	"debug":     bin.Debug,
+	"dap":       bin.DAP,
+	"lsp":       bin.LSP,
+	"lint":      bin.Lint,
+	"typecheck": bin.Typecheck,
+}
+
 func init() {
 	evaluator.InitBuiltins()
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[2:]))
+		}
+	}
+
 	user, err := user2.Current()
 	if err != nil {
 		panic(err)
@@ -23,18 +40,25 @@ func main() {
 	var code, path string
 	flag.StringVar(&code, "c", "", "the code should run")
 	flag.StringVar(&path, "f", "", "the source code file path")
+	options := bin.RunFlags(flag.CommandLine)
 	flag.Parse()
 
+	opts, err := options()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
 	if len(os.Args) == 1 {
 		fmt.Printf("Hello %s! This is the Monkey programming language!\n", user.Username)
 		fmt.Printf("Feel free to type in commands\n")
 		repl.Start(os.Stdin, os.Stdout)
 	} else if code != "" {
-		bin.RunCode(code)
+		bin.RunCode(code, opts)
 	} else if path != "" {
-		bin.RunFile(path)
-	} else if len(os.Args) == 2 {
-		bin.RunFile(os.Args[1])
+		bin.RunFile(path, opts)
+	} else if flag.NArg() == 1 {
+		bin.RunFile(flag.Arg(0), opts)
 	} else {
 		fmt.Println("Parsing command line parameter failed!")
 	}
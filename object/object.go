@@ -5,35 +5,124 @@ import (
 	"fmt"
 	"github.com/lxdlam/monkey-plus/ast"
 	"hash/fnv"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// Environment binds the names to the values. The names local to a function are resolved to
+// slots before running, see ast.Slot, and the others are stored by name.
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store   map[string]Object
+	outer   *Environment
+	runtime *Runtime
+	// names holds the names of the slots, a slot is nil until its name is bound
+	names []string
+	slots []Object
 }
 
 func NewEnvironment() *Environment {
+	return NewEnvironmentWithRuntime(&Runtime{})
+}
+
+// NewEnvironmentWithRuntime creates a top level environment sharing the runtime with other ones
+func NewEnvironmentWithRuntime(runtime *Runtime) *Environment {
 	s := make(map[string]Object)
-	return &Environment{store: s, outer: nil}
+	return &Environment{store: s, outer: nil, runtime: runtime}
 }
 
 func NewEnclosedEnvironment(outer *Environment) *Environment {
-	env := NewEnvironment()
-	env.outer = outer
-	return env
+	s := make(map[string]Object)
+	return &Environment{store: s, outer: outer, runtime: outer.runtime}
+}
+
+// NewFunctionEnvironment creates the environment of a function call with a slot for each name
+func NewFunctionEnvironment(outer *Environment, names []string) *Environment {
+	return &Environment{outer: outer, runtime: outer.runtime, names: names, slots: make([]Object, len(names))}
+}
+
+func (e *Environment) Runtime() *Runtime {
+	return e.runtime
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
-	obj, ok := e.store[name]
-	if !ok && e.outer != nil {
-		obj, ok = e.outer.Get(name)
+	if obj, ok := e.getLocal(name); ok {
 		return obj, ok
 	}
+
+	if e.outer != nil {
+		return e.outer.Get(name)
+	}
+
+	return nil, false
+}
+
+func (e *Environment) getLocal(name string) (Object, bool) {
+	for i, n := range e.names {
+		if n == name {
+			return e.slots[i], e.slots[i] != nil
+		}
+	}
+
+	obj, ok := e.store[name]
 	return obj, ok
 }
 
+// GetSlot returns the value of a slot, it is not found if the name is not bound yet
+func (e *Environment) GetSlot(depth, index int) (Object, bool) {
+	for ; depth > 0 && e != nil; depth-- {
+		e = e.outer
+	}
+
+	if e == nil || index >= len(e.slots) || e.slots[index] == nil {
+		return nil, false
+	}
+
+	return e.slots[index], true
+}
+
+func (e *Environment) SetSlot(index int, val Object) Object {
+	e.slots[index] = val
+	return val
+}
+
+func (e *Environment) Outer() *Environment {
+	return e.outer
+}
+
+// Names returns the sorted names bound in this environment, excluding the outer ones
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.store)+len(e.slots))
+	for name := range e.store {
+		names = append(names, name)
+	}
+
+	for i, name := range e.names {
+		if e.slots[i] != nil {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Set binds the name, in its slot if it has one
 func (e *Environment) Set(name string, val Object) Object {
+	for i, n := range e.names {
+		if n == name {
+			e.slots[i] = val
+			return val
+		}
+	}
+
+	if e.store == nil {
+		e.store = make(map[string]Object)
+	}
+
 	e.store[name] = val
 	return val
 }
@@ -42,12 +131,20 @@ func (e *Environment) Merge(other *Environment) {
 	for k, v := range other.store {
 		e.Set(k, v)
 	}
+
+	for i, name := range other.names {
+		if other.slots[i] != nil {
+			e.Set(name, other.slots[i])
+		}
+	}
 }
 
 type ObjectType string
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	BIGINT_OBJ       = "BIGINT"
+	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
@@ -57,6 +154,8 @@ const (
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
+	REGEX_OBJ        = "REGEX"
+	TIME_OBJ         = "TIME"
 )
 
 type Object interface {
@@ -71,6 +170,30 @@ type Integer struct {
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
 
+// BigInt is an integer of any size, the integer arithmetic promotes to it on overflow
+type BigInt struct {
+	Value *big.Int
+}
+
+func (b *BigInt) Type() ObjectType { return BIGINT_OBJ }
+func (b *BigInt) Inspect() string  { return b.Value.String() }
+
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+
+// Inspect keeps a decimal point or an exponent, so the floats are told apart from the integers
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+
+	return s
+}
+
 type Boolean struct {
 	Value bool
 }
@@ -92,6 +215,14 @@ func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 
 type Error struct {
 	Message string
+	Failure *Failure
+}
+
+// Failure is attached to the errors raised by the assert builtins
+type Failure struct {
+	Assertion string
+	Expected  Object
+	Actual    Object
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
@@ -101,6 +232,9 @@ type Function struct {
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
+	Name       string
+	// Locals holds the names of the slots of the environments of the calls
+	Locals []string
 }
 
 func (f *Function) Type() ObjectType { return FUNC_OBJ }
@@ -114,10 +248,9 @@ func (f *Function) Inspect() string {
 
 	out.WriteString("fn")
 	out.WriteString("(")
-	out.WriteString(strings.Join(params, ","))
-	out.WriteString(") {\n")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
 	out.WriteString(f.Body.String())
-	out.WriteString("\n}")
 
 	return out.String()
 }
@@ -130,12 +263,19 @@ type String struct {
 func (s *String) Type() ObjectType { return STRING_OBJ }
 func (s *String) Inspect() string  { return s.StringRep }
 
+// NewString makes a string of the value as is, NewStringObject decodes the escape sequences of a
+// literal
+func NewString(value string) *String {
+	return &String{Value: []uint8(value), StringRep: value}
+}
+
 func NewStringObject(raw string) *String {
 	var value []uint8
 	length := len(raw)
 
 	for i := 0; i < length; i++ {
-		if raw[i] == '\\' {
+		// A trailing backslash has nothing to escape and is kept as is
+		if raw[i] == '\\' && i+1 < length {
 			var code uint8
 			switch raw[i+1] {
 			case 't':
@@ -152,6 +292,8 @@ func NewStringObject(raw string) *String {
 				code = 34
 			case '\\':
 				code = 92
+			case '$':
+				code = 36
 			}
 			value = append(value, code)
 			i++
@@ -162,7 +304,7 @@ func NewStringObject(raw string) *String {
 
 	return &String{
 		Value:     value,
-		StringRep: raw,
+		StringRep: string(value),
 	}
 }
 
@@ -196,6 +338,22 @@ func (lhs *String) Compare(rhs *String) int {
 	}
 }
 
+// Regex is a compiled regular expression of the syntax of Go's regexp
+type Regex struct {
+	Value *regexp.Regexp
+}
+
+func (r *Regex) Type() ObjectType { return REGEX_OBJ }
+func (r *Regex) Inspect() string  { return "/" + r.Value.String() + "/" }
+
+// Time is an instant with its time zone, the durations are integers of milliseconds
+type Time struct {
+	Value time.Time
+}
+
+func (t *Time) Type() ObjectType { return TIME_OBJ }
+func (t *Time) Inspect() string  { return t.Value.Format(time.RFC3339Nano) }
+
 type BuiltinFunction func(env *Environment, args ...Object) Object
 
 type Builtin struct {
@@ -250,6 +408,19 @@ func (i *Integer) HashKey() HashKey {
 	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
 }
 
+// HashKey of a big integer fitting in an int64 is the key of the integer, so they are the same key
+func (b *BigInt) HashKey() HashKey {
+	if b.Value.IsInt64() {
+		return HashKey{Type: INTEGER_OBJ, Value: uint64(b.Value.Int64())}
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte{byte(b.Value.Sign() + 1)})
+	h.Write(b.Value.Bytes())
+
+	return HashKey{Type: b.Type(), Value: h.Sum64()}
+}
+
 func (s *String) HashKey() HashKey {
 	h := fnv.New64a()
 	h.Write([]byte(s.Value))
@@ -262,9 +433,12 @@ type HashPair struct {
 	Value Object
 }
 
+// Hash keeps its keys in the order they are inserted, so it is inspected and iterated in a
+// deterministic order
 type Hash struct {
 	Pairs  map[HashKey][]HashPair
 	Length int
+	keys   []Object
 }
 
 func (h *Hash) Type() ObjectType { return HASH_OBJ }
@@ -272,12 +446,8 @@ func (h *Hash) Inspect() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for _, pairList := range h.Pairs {
-		if pairList != nil {
-			for _, pair := range pairList {
-				pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
-			}
-		}
+	for _, pair := range h.Items() {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
 	}
 
 	out.WriteString("{")
@@ -299,17 +469,23 @@ func NewHash() *Hash {
 	}
 }
 
-// true if replace, otherwise create
+// true if replace, otherwise create. The keys not Hashable are ignored
 func (h *Hash) Set(key, value Object) bool {
-	hashKey := key.(Hashable).HashKey()
+	hashable, ok := key.(Hashable)
+	if !ok {
+		return false
+	}
+
+	hashKey := hashable.HashKey()
 	switch key := key.(type) {
-	case *String:
+	case *String, *BigInt:
 		pairs, ok := h.Pairs[hashKey]
 
 		// If we don't found
 		if !ok || pairs == nil {
 			h.Pairs[hashKey] = []HashPair{{Key: key, Value: value}}
 			h.Length++
+			h.keys = append(h.keys, key)
 			return false
 		}
 
@@ -317,9 +493,7 @@ func (h *Hash) Set(key, value Object) bool {
 		length := len(pairs)
 		var idx int
 		for idx = 0; idx < length; idx++ {
-			pairKey := pairs[idx].Key.(*String)
-
-			if pairKey.Compare(key) == 0 {
+			if sameKey(pairs[idx].Key, key) {
 				break
 			}
 		}
@@ -328,6 +502,7 @@ func (h *Hash) Set(key, value Object) bool {
 		if idx == length {
 			h.Pairs[hashKey] = append(h.Pairs[hashKey], HashPair{Key: key, Value: value})
 			h.Length++
+			h.keys = append(h.keys, key)
 			return false
 		} else {
 			h.Pairs[hashKey][idx].Value = value
@@ -338,6 +513,7 @@ func (h *Hash) Set(key, value Object) bool {
 		if !ok || pairs == nil {
 			h.Pairs[hashKey] = []HashPair{{Key: key, Value: value}}
 			h.Length++
+			h.keys = append(h.keys, key)
 			return false
 		} else {
 			h.Pairs[hashKey][0].Value = value
@@ -348,9 +524,14 @@ func (h *Hash) Set(key, value Object) bool {
 
 // true if successfully delete
 func (h *Hash) Delete(key Object) bool {
-	hashKey := key.(Hashable).HashKey()
+	hashable, ok := key.(Hashable)
+	if !ok {
+		return false
+	}
+
+	hashKey := hashable.HashKey()
 	switch key := key.(type) {
-	case *String:
+	case *String, *BigInt:
 		pairs, ok := h.Pairs[hashKey]
 
 		if !ok || pairs == nil {
@@ -360,14 +541,13 @@ func (h *Hash) Delete(key Object) bool {
 		length := len(pairs)
 		var idx int
 		for idx = 0; idx < length; idx++ {
-			pairKey := pairs[idx].Key.(*String)
-
-			if pairKey.Compare(key) == 0 {
+			if sameKey(pairs[idx].Key, key) {
 				newPairs := []HashPair{}
 				newPairs = append(newPairs, pairs[:idx]...)
 				newPairs = append(newPairs, pairs[idx+1:]...)
 				h.Pairs[hashKey] = newPairs
 				h.Length--
+				h.removeKey(key)
 				return true
 			}
 		}
@@ -380,6 +560,7 @@ func (h *Hash) Delete(key Object) bool {
 		} else {
 			delete(h.Pairs, hashKey)
 			h.Length--
+			h.removeKey(key)
 			return true
 		}
 	}
@@ -387,17 +568,20 @@ func (h *Hash) Delete(key Object) bool {
 
 // Replace the real get
 func (h *Hash) Get(key Object) (Object, bool) {
-	hashKey := key.(Hashable)
+	hashKey, ok := key.(Hashable)
+	if !ok {
+		return key, false
+	}
+
 	switch key := key.(type) {
-	case *String:
+	case *String, *BigInt:
 		pairs, ok := h.Pairs[hashKey.HashKey()]
 		if !ok || pairs == nil {
 			return key, false
 		}
 
 		for _, pair := range pairs {
-			pairKey := pair.Key.(*String)
-			if pairKey.Compare(key) == 0 {
+			if sameKey(pair.Key, key) {
 				return pair.Value, true
 			}
 		}
@@ -413,14 +597,53 @@ func (h *Hash) Get(key Object) (Object, bool) {
 	}
 }
 
+func (h *Hash) removeKey(key Object) {
+	for idx, k := range h.keys {
+		if sameKey(k, key) {
+			h.keys = append(h.keys[:idx], h.keys[idx+1:]...)
+			return
+		}
+	}
+}
+
+// sameKey tells whether two keys are equal, the strings and the big integers may collide
+func sameKey(a, b Object) bool {
+	if a.(Hashable).HashKey() != b.(Hashable).HashKey() {
+		return false
+	}
+
+	if a, ok := a.(*String); ok {
+		return a.Compare(b.(*String)) == 0
+	}
+
+	if a, ok := a.(*BigInt); ok && !a.Value.IsInt64() {
+		return a.Value.Cmp(b.(*BigInt).Value) == 0
+	}
+
+	return true
+}
+
+// Items returns all the pairs of the hash in the order of insertion
+func (h *Hash) Items() []HashPair {
+	items := make([]HashPair, 0, len(h.keys))
+	for _, key := range h.keys {
+		value, _ := h.Get(key)
+		items = append(items, HashPair{Key: key, Value: value})
+	}
+
+	return items
+}
+
 func (h *Hash) Clone() *Hash {
-	pairs := make(map[HashKey][]HashPair)
+	pairs := make(map[HashKey][]HashPair, len(h.Pairs))
 	for k, v := range h.Pairs {
-		pairs[k] = v
+		// The pairs are copied since Set replaces the values in place
+		pairs[k] = append([]HashPair(nil), v...)
 	}
 
 	return &Hash{
 		Pairs:  pairs,
 		Length: h.Length,
+		keys:   append([]Object(nil), h.keys...),
 	}
 }
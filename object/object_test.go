@@ -1,6 +1,11 @@
 package object
 
-import "testing"
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := NewStringObject("Hello World")
@@ -59,6 +64,40 @@ func TestIntegerHashKey(t *testing.T) {
 	}
 }
 
+func TestBigIntHashKey(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+
+	if (&BigInt{Value: big.NewInt(-5)}).HashKey() != (&Integer{Value: -5}).HashKey() {
+		t.Errorf("a big integer fitting in int64 does not have the hash key of the integer")
+	}
+
+	if (&BigInt{Value: huge}).HashKey() != (&BigInt{Value: new(big.Int).Set(huge)}).HashKey() {
+		t.Errorf("big integers with same value have different hash keys")
+	}
+
+	if (&BigInt{Value: huge}).HashKey() == (&BigInt{Value: new(big.Int).Neg(huge)}).HashKey() {
+		t.Errorf("big integers with opposite values have same hash keys")
+	}
+
+	h := NewHash()
+	h.Set(&Integer{Value: 1}, NewString("one"))
+	h.Set(&BigInt{Value: big.NewInt(1)}, NewString("uno"))
+	h.Set(&BigInt{Value: huge}, NewString("huge"))
+
+	if h.Len() != 2 {
+		t.Fatalf("wrong length. got=%d, want=2", h.Len())
+	}
+	if value, _ := h.Get(&Integer{Value: 1}); value.Inspect() != "uno" {
+		t.Errorf("wrong value of 1. got=%s", value.Inspect())
+	}
+	if value, ok := h.Get(&BigInt{Value: new(big.Int).Set(huge)}); !ok || value.Inspect() != "huge" {
+		t.Errorf("big integer key not found")
+	}
+	if !h.Delete(&BigInt{Value: new(big.Int).Set(huge)}) || h.Len() != 1 {
+		t.Errorf("big integer key not deleted")
+	}
+}
+
 func TestHashPairEqual(t *testing.T) {
 	hp1 := &HashPair{NewStringObject("abc"), NewStringObject("xyz")}
 	hp2 := &HashPair{NewStringObject("abc"), NewStringObject("xyz")}
@@ -72,3 +111,140 @@ func TestHashPairEqual(t *testing.T) {
 		t.Errorf("the different string compare got equal.")
 	}
 }
+
+func benchmarkKeys(n int) []Object {
+	keys := make([]Object, 0, n)
+	for i := 0; i < n; i++ {
+		if i%2 == 0 {
+			keys = append(keys, &Integer{Value: int64(i)})
+		} else {
+			keys = append(keys, NewStringObject(fmt.Sprintf("key%d", i)))
+		}
+	}
+
+	return keys
+}
+
+func BenchmarkHashSet(b *testing.B) {
+	keys := benchmarkKeys(1000)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		hash := NewHash()
+		for _, key := range keys {
+			hash.Set(key, key)
+		}
+	}
+}
+
+func BenchmarkHashGet(b *testing.B) {
+	keys := benchmarkKeys(1000)
+	hash := NewHash()
+	for _, key := range keys {
+		hash.Set(key, key)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, key := range keys {
+			if _, ok := hash.Get(key); !ok {
+				b.Fatalf("key %s not found", key.Inspect())
+			}
+		}
+	}
+}
+
+func BenchmarkHashClone(b *testing.B) {
+	hash := NewHash()
+	for _, key := range benchmarkKeys(1000) {
+		hash.Set(key, key)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		hash.Clone()
+	}
+}
+
+func TestNewStringObject(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+	}{
+		{"hello", "hello"},
+		{`a\tb\n`, "a\tb\n"},
+		{`\"quoted\" \\`, "\"quoted\" \\"},
+		{`trailing\`, "trailing\\"},
+		{`\`, "\\"},
+	}
+
+	for _, tt := range tests {
+		if got := string(NewStringObject(tt.raw).Value); got != tt.expected {
+			t.Errorf("NewStringObject(%q) wrong. got=%q, want=%q", tt.raw, got, tt.expected)
+		}
+	}
+}
+
+func TestHashNotHashableKey(t *testing.T) {
+	hash := NewHash()
+	key := &Array{}
+
+	if hash.Set(key, &Integer{Value: 1}) || hash.Len() != 0 {
+		t.Errorf("an array key is set")
+	}
+
+	if _, ok := hash.Get(key); ok {
+		t.Errorf("an array key is found")
+	}
+
+	if hash.Delete(key) {
+		t.Errorf("an array key is deleted")
+	}
+}
+
+func TestHashOrder(t *testing.T) {
+	hash := NewHash()
+	for _, key := range []Object{NewString("b"), &Integer{Value: 1}, NewString("a"), &Boolean{Value: true}} {
+		hash.Set(key, key)
+	}
+	hash.Set(NewString("b"), NewString("replaced"))
+	hash.Delete(&Integer{Value: 1})
+	hash.Set(&Integer{Value: 1}, &Integer{Value: 2})
+
+	if got := hash.Inspect(); got != "{b: replaced, a: a, true: true, 1: 2}" {
+		t.Errorf("wrong order. got=%s", got)
+	}
+
+	clone := hash.Clone()
+	clone.Set(NewString("a"), NewString("changed"))
+	clone.Delete(NewString("b"))
+
+	if got := hash.Inspect(); got != "{b: replaced, a: a, true: true, 1: 2}" {
+		t.Errorf("the clone changed the hash. got=%s", got)
+	}
+	if got := clone.Inspect(); got != "{a: changed, true: true, 1: 2}" {
+		t.Errorf("wrong clone. got=%s", got)
+	}
+}
+
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{1, "1.0"},
+		{-2.5, "-2.5"},
+		{1e21, "1e+21"},
+		{0.1, "0.1"},
+		{math.Inf(1), "+Inf"},
+		{math.NaN(), "NaN"},
+	}
+
+	for _, tt := range tests {
+		if got := (&Float{Value: tt.value}).Inspect(); got != tt.expected {
+			t.Errorf("Inspect of %v wrong. got=%q, want=%q", tt.value, got, tt.expected)
+		}
+	}
+}
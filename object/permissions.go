@@ -0,0 +1,119 @@
+package object
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Permissions allow the file system builtins to access the files under the root directories.
+// The files under Read can be read, the files under Write can also be written.
+type Permissions struct {
+	Read  []string
+	Write []string
+}
+
+// NewPermissions resolves the roots to absolute paths without symbolic links
+func NewPermissions(read, write []string) (*Permissions, error) {
+	p := &Permissions{}
+
+	for _, roots := range []struct {
+		from []string
+		to   *[]string
+	}{{read, &p.Read}, {write, &p.Write}} {
+		for _, root := range roots.from {
+			resolved, err := resolvePath(root)
+			if err != nil {
+				return nil, err
+			}
+			*roots.to = append(*roots.to, resolved)
+		}
+	}
+
+	return p, nil
+}
+
+// Check resolves the path and reports an error if it is not under a root allowing the access.
+// The symbolic links are followed, so they cannot lead out of the roots.
+func (p *Permissions) Check(path string, write bool) (string, error) {
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	roots := p.Write
+	if !write {
+		roots = append(append([]string{}, p.Read...), p.Write...)
+	}
+
+	for _, root := range roots {
+		if within(root, resolved) {
+			return resolved, nil
+		}
+	}
+
+	access := "read"
+	if write {
+		access = "write"
+	}
+	return "", fmt.Errorf("permission denied to %s %s", access, path)
+}
+
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// maxLinks bounds the dangling symbolic links followed by resolvePath, like ELOOP in the kernel
+const maxLinks = 40
+
+// resolvePath makes the path absolute and follows the symbolic links. The part of the path that
+// does not exist yet is kept as is, e.g. the file to be created.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	missing := []string{}
+	for links := 0; ; {
+		resolved, err := filepath.EvalSymlinks(abs)
+		if err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				resolved = filepath.Join(resolved, missing[i])
+			}
+			return resolved, nil
+		}
+
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		// A dangling symbolic link is replaced by its target, otherwise writing to it would create
+		// the target out of the roots
+		if info, lstatErr := os.Lstat(abs); lstatErr == nil && info.Mode()&os.ModeSymlink != 0 {
+			links++
+			if links > maxLinks {
+				return "", fmt.Errorf("too many links in %s", path)
+			}
+
+			target, err := os.Readlink(abs)
+			if err != nil {
+				return "", err
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(abs), target)
+			}
+			abs = target
+			continue
+		}
+
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return "", err
+		}
+		missing = append(missing, filepath.Base(abs))
+		abs = parent
+	}
+}
@@ -0,0 +1,80 @@
+package object
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPermissions(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sub := range []string{"data", "out", "secret"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The dangling links point to the files to be created
+	links := map[string]string{
+		"data/link":    filepath.Join(dir, "secret"),
+		"out/evil":     filepath.Join(dir, "secret", "pwned"),
+		"out/evil_dir": filepath.Join(dir, "missing", "dir"),
+		"out/kept":     "new.txt",
+		"out/chain":    "kept",
+		"out/loop":     "loop",
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := NewPermissions([]string{filepath.Join(dir, "data")}, []string{filepath.Join(dir, "out")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path  string
+		write bool
+		// expected is the resolved path, empty if the access is denied
+		expected string
+	}{
+		{"data/file", false, "data/file"},
+		{"data/file", true, ""},
+		{"out/new/file", true, "out/new/file"},
+		{"out/file", false, "out/file"},
+		{"data", false, "data"},
+		{"data/../secret/file", false, ""},
+		{"data/link/file", false, ""},
+		{"out/evil", true, ""},
+		{"out/evil_dir/file", true, ""},
+		{"out/kept", true, "out/new.txt"},
+		{"out/chain", true, "out/new.txt"},
+		{"out/loop", true, ""},
+		{"secret", false, ""},
+		{"dataset", false, ""},
+		{"", false, ""},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.path)
+		got, err := p.Check(path, tt.write)
+
+		if tt.expected == "" {
+			if err == nil {
+				t.Errorf("Check(%q, %t) is allowed, got=%q", tt.path, tt.write, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Check(%q, %t) returned error: %s", tt.path, tt.write, err)
+		} else if got != filepath.Join(dir, tt.expected) {
+			t.Errorf("Check(%q, %t) wrong. got=%q, want=%q", tt.path, tt.write, got, filepath.Join(dir, tt.expected))
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package object
+
+import (
+	"bufio"
+	"context"
+	"github.com/lxdlam/monkey-plus/ast"
+	"io"
+	"math/rand"
+	"os"
+	"time"
+)
+
+// Runtime holds the states shared by all the environments of one interpreter
+type Runtime struct {
+	Hooks []Hook
+	// Stdout is where puts writes to, os.Stdout if not set
+	Stdout io.Writer
+	// Stdin is where the input builtins read from, os.Stdin if not set
+	Stdin io.Reader
+	stdin *bufio.Reader
+	// NoSlots disables the slot resolution, the local names are looked up by name like the globals
+	NoSlots bool
+	// MaxSteps stops the evaluation with an error after this many statements, 0 for no limit
+	MaxSteps int
+	// CheckOverflow makes the integer arithmetic fail with an error instead of promoting the result
+	// to a big integer
+	CheckOverflow bool
+	// Steps is the number of the statements evaluated so far
+	Steps int
+	// Permissions limit the files the builtins can access, every file is allowed if nil
+	Permissions *Permissions
+	// Rand is the source of the random builtins, seeded from the time if not set
+	Rand *rand.Rand
+	// Clock tells the time to the time builtins, the system clock if not set
+	Clock Clock
+	// Context stops the evaluation and the sleeps once it is canceled, never canceled if not set
+	Context context.Context
+}
+
+// Hook observes the evaluation, e.g. the coverage recorder
+type Hook interface {
+	// LoadProgram is called every time before a program is evaluated
+	LoadProgram(program *ast.Program)
+	// BeforeStatement is called before every statement in a program or a block is evaluated
+	BeforeStatement(stmt ast.Statement, env *Environment)
+	// EnterFunction and ExitFunction are called around every call of a Monkey function. A tail
+	// call exits the caller before entering the callee, and the result of the caller is nil.
+	EnterFunction(fn *Function, args []Object)
+	ExitFunction(fn *Function, result Object)
+}
+
+func (r *Runtime) AddHook(hook Hook) {
+	r.Hooks = append(r.Hooks, hook)
+}
+
+// CheckPath returns the path the builtins should open, or an error if the access is denied
+func (r *Runtime) CheckPath(path string, write bool) (string, error) {
+	if r.Permissions == nil {
+		return path, nil
+	}
+
+	return r.Permissions.Check(path, write)
+}
+
+// In returns the buffered Stdin, it is shared by all the reads so no input is lost. A Stdin that is
+// a *bufio.Reader is used as is, so the caller can share it too.
+func (r *Runtime) In() *bufio.Reader {
+	if r.stdin == nil {
+		var in io.Reader = os.Stdin
+		if r.Stdin != nil {
+			in = r.Stdin
+		}
+
+		if reader, ok := in.(*bufio.Reader); ok {
+			r.stdin = reader
+		} else {
+			r.stdin = bufio.NewReader(in)
+		}
+	}
+
+	return r.stdin
+}
+
+// Random returns the source of the random builtins, each runtime has its own so the interpreters do
+// not share their states
+func (r *Runtime) Random() *rand.Rand {
+	if r.Rand == nil {
+		r.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	return r.Rand
+}
+
+// Seed replaces the source of the random builtins, the same seed gives the same values
+func (r *Runtime) Seed(seed int64) {
+	r.Rand = rand.New(rand.NewSource(seed))
+}
+
+func (r *Runtime) Now() time.Time {
+	if r.Clock == nil {
+		return systemClock{}.Now()
+	}
+
+	return r.Clock.Now()
+}
+
+// Sleep waits on the clock for the duration, it returns the error of the context if it is canceled
+// before
+func (r *Runtime) Sleep(d time.Duration) error {
+	ctx := r.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if r.Clock == nil {
+		return systemClock{}.Sleep(ctx, d)
+	}
+
+	return r.Clock.Sleep(ctx, d)
+}
+
+// Err returns the error of the context once it is canceled, nil otherwise
+func (r *Runtime) Err() error {
+	if r.Context == nil {
+		return nil
+	}
+
+	return r.Context.Err()
+}
+
+func (r *Runtime) Out() io.Writer {
+	if r.Stdout == nil {
+		return os.Stdout
+	}
+
+	return r.Stdout
+}
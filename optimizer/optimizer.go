@@ -0,0 +1,367 @@
+package optimizer
+
+import (
+	"github.com/lxdlam/monkey-plus/ast"
+	"github.com/lxdlam/monkey-plus/evaluator"
+	"github.com/lxdlam/monkey-plus/object"
+	"github.com/lxdlam/monkey-plus/resolver"
+	"github.com/lxdlam/monkey-plus/token"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Optimize rewrites the program in place and returns it. It folds the prefix and infix
+// operations on literals, prunes the branches of the constant `if` conditions and inlines the
+// let bindings of literals that are never rebound. The program evaluates to the same result,
+// the operations failing at runtime like `1 / 0` are left as is.
+func Optimize(program *ast.Program) *ast.Program {
+	o := &optimizer{}
+	program.Statements = o.statements(program.Statements)
+
+	// Inlining the constants may make new constants, e.g. `let day = 60 * 60 * 24; let week = day * 7;`
+	for {
+		o.constants = constants(program)
+		if len(o.constants) == 0 {
+			return program
+		}
+
+		program.Statements = o.statements(program.Statements)
+	}
+}
+
+type optimizer struct {
+	// constants maps the identifiers to inline to the literal values of their bindings
+	constants map[*ast.Identifier]ast.Expression
+}
+
+func (o *optimizer) statements(stmts []ast.Statement) []ast.Statement {
+	result := []ast.Statement{}
+
+	for i, stmt := range stmts {
+		stmt = o.statement(stmt)
+
+		if branch, ok := constantBranch(stmt); ok {
+			// The statements of the branch run in the enclosing scope, so they are spliced in
+			// place. The value of the last statement must stay the value of the branch.
+			if branch != nil && len(branch.Statements) != 0 {
+				result = append(result, branch.Statements...)
+				continue
+			}
+
+			if i != len(stmts)-1 {
+				continue
+			}
+		}
+
+		result = append(result, stmt)
+	}
+
+	return result
+}
+
+// constantBranch returns the branch an `if` statement with a constant condition runs
+func constantBranch(stmt ast.Statement) (*ast.BlockStatement, bool) {
+	es, ok := stmt.(*ast.ExpressionStatement)
+	if !ok || es == nil {
+		return nil, false
+	}
+
+	ie, ok := es.Expression.(*ast.IfExpression)
+	if !ok || ie == nil || !isLiteral(ie.Condition) {
+		return nil, false
+	}
+
+	if truthy(ie.Condition) {
+		return ie.Consequence, true
+	}
+	return ie.Alternative, true
+}
+
+func (o *optimizer) statement(stmt ast.Statement) ast.Statement {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		if stmt != nil {
+			stmt.Value = o.expression(stmt.Value)
+		}
+	case *ast.ReturnStatement:
+		if stmt != nil {
+			stmt.ReturnValue = o.expression(stmt.ReturnValue)
+		}
+	case *ast.ExpressionStatement:
+		if stmt != nil {
+			stmt.Expression = o.expression(stmt.Expression)
+		}
+	case *ast.BlockStatement:
+		o.block(stmt)
+	}
+
+	return stmt
+}
+
+func (o *optimizer) block(block *ast.BlockStatement) {
+	if block != nil {
+		block.Statements = o.statements(block.Statements)
+	}
+}
+
+func (o *optimizer) expression(exp ast.Expression) ast.Expression {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		if value, ok := o.constants[exp]; ok {
+			return copyLiteral(value, exp.Pos())
+		}
+	case *ast.PrefixExpression:
+		if exp == nil {
+			return exp
+		}
+
+		exp.Right = o.expression(exp.Right)
+		if isLiteral(exp.Right) {
+			return fold(exp)
+		}
+	case *ast.InfixExpression:
+		if exp == nil {
+			return exp
+		}
+
+		exp.Left = o.expression(exp.Left)
+		exp.Right = o.expression(exp.Right)
+		if isLiteral(exp.Left) && isLiteral(exp.Right) {
+			return fold(exp)
+		}
+	case *ast.IfExpression:
+		if exp == nil {
+			return exp
+		}
+
+		return o.ifExpression(exp)
+	case *ast.FunctionLiteral:
+		if exp != nil {
+			o.block(exp.Body)
+		}
+	case *ast.CallExpression:
+		if exp == nil {
+			return exp
+		}
+
+		exp.Function = o.expression(exp.Function)
+		for i, arg := range exp.Arguments {
+			exp.Arguments[i] = o.expression(arg)
+		}
+	case *ast.ArrayLiteral:
+		if exp == nil {
+			return exp
+		}
+
+		for i, el := range exp.Elements {
+			exp.Elements[i] = o.expression(el)
+		}
+	case *ast.InterpolatedString:
+		// A string literal replacing an expression is printed back as text of the same value
+		for i, part := range exp.Parts {
+			exp.Parts[i] = o.expression(part)
+		}
+	case *ast.IndexExpression:
+		if exp == nil {
+			return exp
+		}
+
+		exp.Left = o.expression(exp.Left)
+		exp.Index = o.expression(exp.Index)
+	case *ast.HashLiteral:
+		if exp == nil || len(exp.Keys) != len(exp.Pairs) {
+			return exp
+		}
+
+		// The pairs are keyed by the key expressions, so they are rebuilt
+		pairs := make(map[ast.Expression]ast.Expression)
+		for i, key := range exp.Keys {
+			value := o.expression(exp.Pairs[key])
+			exp.Keys[i] = o.expression(key)
+			pairs[exp.Keys[i]] = value
+		}
+		exp.Pairs = pairs
+	}
+
+	return exp
+}
+
+func (o *optimizer) ifExpression(exp *ast.IfExpression) ast.Expression {
+	exp.Condition = o.expression(exp.Condition)
+	o.block(exp.Consequence)
+	o.block(exp.Alternative)
+
+	if !isLiteral(exp.Condition) {
+		return exp
+	}
+
+	branch := exp.Alternative
+	if truthy(exp.Condition) {
+		branch = exp.Consequence
+	}
+
+	// A branch of a single expression is as good as the expression
+	if branch != nil && len(branch.Statements) == 1 {
+		if es, ok := branch.Statements[0].(*ast.ExpressionStatement); ok && es != nil {
+			return es.Expression
+		}
+	}
+
+	// The dead branch is dropped, the condition still selects the live one
+	if truthy(exp.Condition) {
+		exp.Alternative = nil
+	} else {
+		exp.Consequence = &ast.BlockStatement{Token: exp.Consequence.Token, End: exp.Consequence.Token.Pos}
+	}
+
+	return exp
+}
+
+func isLiteral(exp ast.Expression) bool {
+	switch exp.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.BigIntLiteral, *ast.StringLiteral, *ast.Boolean:
+		return true
+	}
+
+	return false
+}
+
+// truthy follows the conditions of the evaluator, only false and null are falsy
+func truthy(exp ast.Expression) bool {
+	b, ok := exp.(*ast.Boolean)
+	return !ok || b.Value
+}
+
+// fold evaluates the operation on literals, it is kept if the evaluation fails. The overflows are
+// checked so they are left to the runtime, where they wrap around or fail depending on the mode.
+func fold(exp ast.Expression) ast.Expression {
+	// The folded literal starts where the operation starts
+	pos := exp.Pos()
+	if infix, ok := exp.(*ast.InfixExpression); ok {
+		pos = infix.Left.Pos()
+	}
+
+	switch obj := evaluator.Eval(exp, object.NewEnvironmentWithRuntime(&object.Runtime{CheckOverflow: true})).(type) {
+	case *object.Integer:
+		// The digits of the smallest integer are out of range, it has no literal
+		if obj.Value == math.MinInt64 {
+			return exp
+		}
+		return &ast.IntegerLiteral{
+			Token: token.Token{Type: token.INT, Literal: strconv.FormatInt(obj.Value, 10), Pos: pos},
+			Value: obj.Value,
+		}
+	case *object.Boolean:
+		tok := token.Token{Type: token.FALSE, Literal: "false", Pos: pos}
+		if obj.Value {
+			tok = token.Token{Type: token.TRUE, Literal: "true", Pos: pos}
+		}
+		return &ast.Boolean{Token: tok, Value: obj.Value}
+	case *object.String:
+		// Escape sequences are decoded when the literal is evaluated, so the strings needing
+		// them or looking like an interpolation cannot be written back as literals
+		value := string(obj.Value)
+		if !strings.ContainsAny(value, "\\\"$") && strings.IndexFunc(value, unicode.IsControl) < 0 {
+			return &ast.StringLiteral{Token: token.Token{Type: token.STRING, Literal: value, Pos: pos}, Value: value}
+		}
+	}
+
+	return exp
+}
+
+func copyLiteral(exp ast.Expression, pos token.Position) ast.Expression {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		lit := *exp
+		lit.Token.Pos = pos
+		return &lit
+	case *ast.FloatLiteral:
+		lit := *exp
+		lit.Token.Pos = pos
+		return &lit
+	case *ast.BigIntLiteral:
+		lit := *exp
+		lit.Token.Pos = pos
+		return &lit
+	case *ast.StringLiteral:
+		lit := *exp
+		lit.Token.Pos = pos
+		return &lit
+	case *ast.Boolean:
+		lit := *exp
+		lit.Token.Pos = pos
+		return &lit
+	}
+
+	return exp
+}
+
+// constants finds the uses of the let bindings that can be replaced by their literal values. A
+// binding qualifies when its let statement always runs, no other binding of its scope has the
+// same name, and `eval` or `load` are not used since they may rebind it at runtime.
+func constants(program *ast.Program) map[*ast.Identifier]ast.Expression {
+	info := resolver.Resolve(program, nil)
+
+	for _, ident := range info.Unresolved {
+		if ident.Value == "eval" || ident.Value == "load" {
+			return nil
+		}
+	}
+
+	result := make(map[*ast.Identifier]ast.Expression)
+	for _, stmt := range unconditionalLets(program) {
+		if stmt.Name == nil || !isLiteral(stmt.Value) {
+			continue
+		}
+
+		b := info.Defs[stmt.Name]
+		if b == nil || rebound(b) {
+			continue
+		}
+
+		for _, use := range b.Uses {
+			// Function bodies resolve to the final bindings, even when they run before the let
+			if b.Ident.Pos().Before(use.Pos()) {
+				result[use] = stmt.Value
+			}
+		}
+	}
+
+	return result
+}
+
+func rebound(b *resolver.Binding) bool {
+	for _, other := range b.Scope.Bindings {
+		if other != b && other.Name == b.Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unconditionalLets returns the let statements at the top level of the program and of the
+// function bodies, the ones in `if` blocks bind a name only when their branch runs
+func unconditionalLets(program *ast.Program) []*ast.LetStatement {
+	lets := []*ast.LetStatement{}
+
+	add := func(stmts []ast.Statement) {
+		for _, stmt := range stmts {
+			if let, ok := stmt.(*ast.LetStatement); ok && let != nil {
+				lets = append(lets, let)
+			}
+		}
+	}
+
+	add(program.Statements)
+	ast.Inspect(program, func(n ast.Node) bool {
+		if fl, ok := n.(*ast.FunctionLiteral); ok && fl.Body != nil {
+			add(fl.Body.Statements)
+		}
+		return true
+	})
+
+	return lets
+}
@@ -0,0 +1,107 @@
+package optimizer
+
+import (
+	"github.com/lxdlam/monkey-plus/ast"
+	"github.com/lxdlam/monkey-plus/evaluator"
+	"github.com/lxdlam/monkey-plus/format"
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/object"
+	"github.com/lxdlam/monkey-plus/parser"
+	"testing"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has errors: %v", p.Errors())
+	}
+
+	return program
+}
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"60 * 60 * 24", "86400;\n"},
+		{`"prefix" + "-" + "x"`, "\"prefix-x\";\n"},
+		{"-(2 * 3); !true; !5; 1 < 2 == true; 1 == \"1\"", "-6;\nfalse;\nfalse;\ntrue;\nfalse;\n"},
+		{`"a" < "b"; "a" == "a"; true && false || true`, "true;\ntrue;\ntrue;\n"},
+		// The operations failing at runtime are kept
+		{"1 / 0; 5 % (2 - 2); 1 + \"a\"; -true", "1 / 0;\n5 % 0;\n1 + \"a\";\n-true;\n"},
+		{`"a\n" + "b"; "\"" + "b"`, "\"a\\n\" + \"b\";\n\"\\\"\" + \"b\";\n"},
+		{"x + 1 * 2", "x + 2;\n"},
+		// The overflows are left to the runtime, the floats and the big results are propagated but
+		// not folded
+		{"9223372036854775807 + 1; -(-9223372036854775807 - 1)", "9223372036854775807 + 1;\n-(-9223372036854775807 - 1);\n"},
+		{"let r = 0.5; r * 2", "let r = 0.5;\n0.5 * 2;\n"},
+		{"let b = 5n; b * 2; b * 100000000000000000000n", "let b = 5n;\n10;\n5n * 100000000000000000000n;\n"},
+		{
+			"let day = 60 * 60 * 24; let week = day * 7; puts(week)",
+			"let day = 86400;\nlet week = 604800;\nputs(604800);\n",
+		},
+		// Rebound names, uses running before the let and conditional lets are not inlined
+		{"let a = 1; let a = a + 1; a", "let a = 1;\nlet a = a + 1;\na;\n"},
+		{"let f = fn() { k }; let k = 1; f() + k", "let f = fn() {\n  k;\n};\nlet k = 1;\nf() + 1;\n"},
+		{"if (x) { let c = 1; }; c", "if (x) {\n  let c = 1;\n}\nc;\n"},
+		{"let f = fn(n) { let n = 1; n }", "let f = fn(n) {\n  let n = 1;\n  n;\n};\n"},
+		{`let n = 1; eval("let n = 2"); n`, "let n = 1;\neval(\"let n = 2\");\nn;\n"},
+		{"let f = fn(x) { x }; let x = 1; f(x)", "let f = fn(x) {\n  x;\n};\nlet x = 1;\nf(1);\n"},
+		// Constant conditions
+		{"if (false) { puts(1) }; puts(2)", "puts(2);\n"},
+		{"if (1 > 2) { a } else { b; c }; d", "b;\nc;\nd;\n"},
+		{"let x = if (true) { 1 } else { 2 }; x", "let x = 1;\n1;\n"},
+		{"if (\"\") { let y = 2; }; y", "let y = 2;\n2;\n"},
+		{"let f = fn() { if (false) { 1 } }", "let f = fn() {\n  if (false) {}\n};\n"},
+		{"let v = if (false) { 1 } else { puts(1); 2 }", "let v = if (false) {} else {\n  puts(1);\n  2;\n};\n"},
+		{"let debug = false; if (debug) { puts(1) } puts(2)", "let debug = false;\nputs(2);\n"},
+		{`let h = {"a" + "b": 1 + 1}; h["ab"]`, "let h = {\"ab\": 2};\nh[\"ab\"];\n"},
+		{"let n = -5; n - n; [n][0]", "let n = -5;\n0;\n[-5][0];\n"},
+		{`let n = 2; "${n * 3} ${"a" + "b"}"; "$" + "{x}"`, "let n = 2;\n\"${6} ab\";\n\"$\" + \"{x}\";\n"},
+	}
+
+	for _, tt := range tests {
+		program := Optimize(parse(t, tt.input))
+
+		if got := format.Program(program, nil); got != tt.expected {
+			t.Errorf("Optimize(%q) wrong.\ngot=%q\nwant=%q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestOptimizePreservesResults(t *testing.T) {
+	evaluator.InitBuiltins()
+
+	tests := []string{
+		"let day = 60 * 60 * 24; day * 7",
+		`let greet = fn(name) { "hello, " + name }; greet("monkey" + "!")`,
+		"let f = fn(n) { if (true) { return n * 2; } n }; f(21)",
+		"let f = fn() { if (false) { 1 } }; f()",
+		"if (true) { let z = 3; } z * (2 - 2)",
+		"let half = fn(n) { n / (1 - 1) }; half(4)",
+		"let a = 1; let g = fn() { a }; let a = 2; g()",
+		"let f = fn() { k }; f(); let k = 1;",
+		`"a\n" + "b"`,
+		"let xs = [1 + 1, 2 * 2]; xs[1] - xs[0]",
+		"if (1 == 2) { 1 }",
+	}
+
+	for _, input := range tests {
+		expected := evaluator.Eval(parse(t, input), object.NewEnvironment())
+		got := evaluator.Eval(Optimize(parse(t, input)), object.NewEnvironment())
+
+		if inspect(got) != inspect(expected) {
+			t.Errorf("result of %q changed. got=%s, want=%s", input, inspect(got), inspect(expected))
+		}
+	}
+}
+
+func inspect(obj object.Object) string {
+	if obj == nil {
+		return "<nil>"
+	}
+
+	return string(obj.Type()) + " " + obj.Inspect()
+}
@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"github.com/lxdlam/monkey-plus/lexer"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// addSeeds adds the inputs of the tests and the benchmark programs to the fuzzing corpus
+func addSeeds(f *testing.F) {
+	seeds := []string{
+		"let x = 5; let y = true; let foobar = y;",
+		"return 5; return foobar;",
+		"-a * b; !-a; a + b * c + d / e - f; 3 + 4; -5 * 5; 5 > 4 == 3 < 4; 7 % 3 == 1",
+		"a + add(b * c) + d; add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8)); a * [1, 2, 3, 4][b * c] * d",
+		"if (x < y) { x } else { y }; if (x) { return 1; } 2",
+		"fn(x, y) { x + y; }; fn() {}; let myFunction = fn() { };",
+		`"hello world"; [1, 2 * 2, 3 + 3]; myArray[1 + 1]; {"one": 1, "two": 2}; {}; {"one": 0 + 1}`,
+		"let f = fn(a: string, b: [int]) -> bool { true }; let g = fn(a, b: int) { a };",
+		"fn(n) { if (n) { return f(n); } else { g(n) } h(n) }",
+		"let x 5; let = 10; let 838383;",
+		"a && b || !c",
+		`"Hello ${name}, ${len(items) + 1} items ${"}"} \${x}"; "${}"; "${a b}"`,
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	files, _ := filepath.Glob(filepath.Join("..", "benchmarks", "*.mp"))
+	for _, file := range files {
+		if source, err := ioutil.ReadFile(file); err == nil {
+			f.Add(string(source))
+		}
+	}
+}
+
+// FuzzParseProgram checks that the parser does not panic, and that the programs printed by
+// String parse back to the same programs
+func FuzzParseProgram(f *testing.F) {
+	addSeeds(f)
+
+	f.Fuzz(func(t *testing.T, input string) {
+		p := New(lexer.New(input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			return
+		}
+
+		printed := program.String()
+		reparser := New(lexer.New(printed))
+		reparsed := reparser.ParseProgram()
+		if len(reparser.Errors()) != 0 {
+			t.Fatalf("%q printed as %q does not parse: %v", input, printed, reparser.Errors())
+		}
+
+		if got := reparsed.String(); got != printed {
+			t.Fatalf("%q printed as %q parses to a different program %q", input, printed, got)
+		}
+	})
+}
@@ -1,11 +1,15 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"github.com/lxdlam/monkey-plus/ast"
 	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/suggest"
 	"github.com/lxdlam/monkey-plus/token"
+	"math/big"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -59,9 +63,15 @@ type (
 	infixParseFn  func(ast.Expression) ast.Expression
 )
 
+// Error is a parse error located at the token it was reported on
+type Error struct {
+	Token   token.Token
+	Message string
+}
+
 type Parser struct {
 	l      *lexer.Lexer
-	errors []string
+	errors []Error
 
 	curToken  token.Token
 	peekToken token.Token
@@ -73,12 +83,14 @@ type Parser struct {
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		l:      l,
-		errors: []string{},
+		errors: []Error{},
 	}
 
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
+	p.registerPrefix(token.BIGINT, p.parseBigIntLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
@@ -87,6 +99,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.INTERPOLATED, p.parseInterpolatedString)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 
@@ -112,9 +125,23 @@ func New(l *lexer.Lexer) *Parser {
 }
 
 func (p *Parser) Errors() []string {
+	msgs := []string{}
+	for _, err := range p.errors {
+		msgs = append(msgs, err.Message)
+	}
+
+	return msgs
+}
+
+// ErrorList returns the parse errors together with the tokens they were reported on
+func (p *Parser) ErrorList() []Error {
 	return p.errors
 }
 
+func (p *Parser) addError(tok token.Token, msg string) {
+	p.errors = append(p.errors, Error{Token: tok, Message: msg})
+}
+
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
@@ -155,6 +182,14 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		p.nextToken()
+		if stmt.Type = p.parseType(); stmt.Type == nil {
+			return nil
+		}
+	}
+
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
 	}
@@ -163,6 +198,10 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 
 	stmt.Value = p.parseExpression(LOWEST)
 
+	if fl, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		fl.Name = stmt.Name.Value
+	}
+
 	// Because the not only `let a = b;` but also `let a = fn(){}`, which is end with no semicolon
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
@@ -191,7 +230,7 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 
 func (p *Parser) peekError(t token.TokenType) {
 	msg := fmt.Sprintf("expected next token to be %s, got %s instead.", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.addError(p.peekToken, msg)
 }
 
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
@@ -221,6 +260,13 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 
 	stmt.Expression = p.parseExpression(LOWEST)
 
+	// An identifier directly followed by an operand is most likely a misspelled keyword, e.g. `retrun x`
+	if ident, ok := stmt.Expression.(*ast.Identifier); ok && p.peekStartsOperand() {
+		if keyword := suggest.Closest(ident.Value, token.Keywords()); keyword != "" {
+			p.addError(ident.Token, fmt.Sprintf("unexpected identifier %s%s", ident.Value, suggest.Hint(keyword)))
+		}
+	}
+
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
@@ -228,6 +274,10 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	return stmt
 }
 
+func (p *Parser) peekStartsOperand() bool {
+	return p.peekTokenIs(token.IDENT) || p.peekTokenIs(token.INT) || p.peekTokenIs(token.STRING)
+}
+
 func (p *Parser) parseExpression(precedence int) ast.Expression {
 	prefix := p.prefixParseFns[p.curToken.Type]
 	if prefix == nil {
@@ -254,13 +304,20 @@ func (p *Parser) parseIdentifier() ast.Expression {
 	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+// parseIntegerLiteral makes a big integer of the literals out of the range of int64, like the
+// arithmetic overflowing
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		if value, ok := new(big.Int).SetString(p.curToken.Literal, 0); ok {
+			return &ast.BigIntLiteral{Token: p.curToken, Value: value}
+		}
+	}
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(p.curToken, msg)
 		return nil
 	}
 
@@ -269,9 +326,35 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return lit
 }
 
+func (p *Parser) parseBigIntLiteral() ast.Expression {
+	value, ok := new(big.Int).SetString(strings.TrimSuffix(p.curToken.Literal, "n"), 10)
+	if !ok {
+		msg := fmt.Sprintf("could not parse %q as big integer", p.curToken.Literal)
+		p.addError(p.curToken, msg)
+		return nil
+	}
+
+	return &ast.BigIntLiteral{Token: p.curToken, Value: value}
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
+		p.addError(p.curToken, msg)
+		return nil
+	}
+
+	return &ast.FloatLiteral{Token: p.curToken, Value: value}
+}
+
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
 	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	// Single `&` and `|` are not operators
+	if t == token.ILLEGAL && (p.curToken.Literal == "&" || p.curToken.Literal == "|") {
+		msg += suggest.Hint(p.curToken.Literal + p.curToken.Literal)
+	}
+	p.addError(p.curToken, msg)
 }
 
 func (p *Parser) parsePrefixExpression() ast.Expression {
@@ -334,6 +417,12 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		p.nextToken()
 	}
 
+	if p.curTokenIs(token.EOF) {
+		p.addError(p.curToken, fmt.Sprintf("expected next token to be %s, got %s instead.", token.RBRACE, token.EOF))
+	}
+
+	block.End = p.curToken.Pos
+
 	return block
 }
 
@@ -377,17 +466,70 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
-	lit.Parameters = p.parseFunctionParameters()
+	lit.Parameters, lit.ParameterTypes = p.parseFunctionParameters()
+
+	if p.peekTokenIs(token.ARROW) {
+		p.nextToken()
+		p.nextToken()
+		if lit.ReturnType = p.parseType(); lit.ReturnType == nil {
+			return nil
+		}
+	}
 
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
 
 	lit.Body = p.parseBlockStatement()
+	markTailCalls(lit.Body, true)
 
 	return lit
 }
 
+// markTailCalls marks the calls whose values are returned by the function as is, the evaluator
+// runs them without growing the stack. The returns propagate only through the statements of the
+// blocks, so the ones nested in other expressions are skipped.
+func markTailCalls(block *ast.BlockStatement, tail bool) {
+	if block == nil {
+		return
+	}
+
+	for i, stmt := range block.Statements {
+		switch stmt := stmt.(type) {
+		case *ast.ReturnStatement:
+			if stmt != nil {
+				markTailCall(stmt.ReturnValue)
+			}
+		case *ast.ExpressionStatement:
+			if stmt == nil {
+				continue
+			}
+
+			last := tail && i == len(block.Statements)-1
+			if ie, ok := stmt.Expression.(*ast.IfExpression); ok && ie != nil {
+				markTailCalls(ie.Consequence, last)
+				markTailCalls(ie.Alternative, last)
+			} else if last {
+				markTailCall(stmt.Expression)
+			}
+		}
+	}
+}
+
+func markTailCall(exp ast.Expression) {
+	switch exp := exp.(type) {
+	case *ast.CallExpression:
+		if exp != nil {
+			exp.Tail = true
+		}
+	case *ast.IfExpression:
+		if exp != nil {
+			markTailCalls(exp.Consequence, true)
+			markTailCalls(exp.Alternative, true)
+		}
+	}
+}
+
 func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	list := []ast.Expression{}
 
@@ -412,26 +554,22 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	return list
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
+// parseFunctionParameters returns the parameters and their annotations, the annotations are nil
+// if no parameter is annotated
+func (p *Parser) parseFunctionParameters() ([]*ast.Identifier, []ast.TypeExpression) {
 	var identifiers []*ast.Identifier
+	var types []ast.TypeExpression
+	annotated := false
 
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		return identifiers
-	}
-
-	p.nextToken()
-
-	ident := &ast.Identifier{
-		Token: p.curToken,
-		Value: p.curToken.Literal,
+		return identifiers, nil
 	}
 
-	identifiers = append(identifiers, ident)
-
-	for p.peekTokenIs(token.COMMA) {
-		p.nextToken()
-		p.nextToken()
+	for {
+		if !p.expectPeek(token.IDENT) {
+			return nil, nil
+		}
 
 		ident := &ast.Identifier{
 			Token: p.curToken,
@@ -439,13 +577,104 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 		}
 
 		identifiers = append(identifiers, ident)
+
+		var t ast.TypeExpression
+		if p.peekTokenIs(token.COLON) {
+			p.nextToken()
+			p.nextToken()
+			if t = p.parseType(); t == nil {
+				return nil, nil
+			}
+			annotated = true
+		}
+		types = append(types, t)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
 	}
 
 	if !p.expectPeek(token.RPAREN) {
+		return nil, nil
+	}
+
+	if !annotated {
+		types = nil
+	}
+
+	return identifiers, types
+}
+
+// parseType parses a type annotation like `int`, `[string]`, `{string: int}` or `fn(int) -> bool`
+func (p *Parser) parseType() ast.TypeExpression {
+	switch p.curToken.Type {
+	case token.IDENT:
+		return &ast.NamedType{Token: p.curToken, Name: p.curToken.Literal}
+	case token.LBRACKET:
+		t := &ast.ArrayType{Token: p.curToken}
+		p.nextToken()
+		if t.Element = p.parseType(); t.Element == nil || !p.expectPeek(token.RBRACKET) {
+			return nil
+		}
+		return t
+	case token.LBRACE:
+		t := &ast.HashType{Token: p.curToken}
+		p.nextToken()
+		if t.Key = p.parseType(); t.Key == nil || !p.expectPeek(token.COLON) {
+			return nil
+		}
+		p.nextToken()
+		if t.Value = p.parseType(); t.Value == nil || !p.expectPeek(token.RBRACE) {
+			return nil
+		}
+		return t
+	case token.FUNCTION:
+		return p.parseFunctionType()
+	default:
+		p.addError(p.curToken, fmt.Sprintf("expected a type, got %s instead.", p.curToken.Type))
+		return nil
+	}
+}
+
+func (p *Parser) parseFunctionType() ast.TypeExpression {
+	t := &ast.FunctionType{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
 		return nil
 	}
 
-	return identifiers
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+	} else {
+		for {
+			p.nextToken()
+			param := p.parseType()
+			if param == nil {
+				return nil
+			}
+			t.Parameters = append(t.Parameters, param)
+
+			if !p.peekTokenIs(token.COMMA) {
+				break
+			}
+			p.nextToken()
+		}
+
+		if !p.expectPeek(token.RPAREN) {
+			return nil
+		}
+	}
+
+	if p.peekTokenIs(token.ARROW) {
+		p.nextToken()
+		p.nextToken()
+		if t.Return = p.parseType(); t.Return == nil {
+			return nil
+		}
+	}
+
+	return t
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
@@ -463,6 +692,33 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	exp := &ast.InterpolatedString{Token: p.curToken}
+
+	for _, segment := range lexer.Segments(p.curToken) {
+		if !segment.Expression {
+			tok := token.Token{Type: token.STRING, Literal: segment.Text, Pos: segment.Pos}
+			exp.Parts = append(exp.Parts, &ast.StringLiteral{Token: tok, Value: segment.Text})
+			continue
+		}
+
+		// The embedded expressions are parsed on their own, at their positions in the source
+		inner := New(lexer.NewAt(segment.Text, segment.Pos))
+		if inner.curTokenIs(token.EOF) {
+			inner.addError(inner.curToken, "empty expression in the interpolated string")
+		} else {
+			exp.Parts = append(exp.Parts, inner.parseExpression(LOWEST))
+			if !inner.peekTokenIs(token.EOF) {
+				inner.addError(inner.peekToken, fmt.Sprintf("unexpected %s in the interpolated string", inner.peekToken.Type))
+			}
+		}
+
+		p.errors = append(p.errors, inner.errors...)
+	}
+
+	return exp
+}
+
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.curToken}
 
@@ -498,6 +754,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		p.nextToken()
 		value := p.parseExpression(LOWEST)
 		hash.Pairs[key] = value
+		hash.Keys = append(hash.Keys, key)
 
 		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
 			return nil
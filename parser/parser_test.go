@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"github.com/lxdlam/monkey-plus/ast"
 	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/token"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 )
 
@@ -139,6 +142,68 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestFloatLiteralExpression(t *testing.T) {
+	l := lexer.New("2.50;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Value != 2.5 {
+		t.Errorf("literal.Value not %g. got=%g", 2.5, literal.Value)
+	}
+	if literal.TokenLiteral() != "2.50" {
+		t.Errorf("literal.TokenLiteral not %s. got=%s", "2.50", literal.TokenLiteral())
+	}
+}
+
+func TestBigIntLiteralExpression(t *testing.T) {
+	l := lexer.New("123456789012345678901234567890n;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.BigIntLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.BigIntLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Value.String() != "123456789012345678901234567890" {
+		t.Errorf("literal.Value wrong. got=%s", literal.Value)
+	}
+	if literal.TokenLiteral() != "123456789012345678901234567890n" {
+		t.Errorf("literal.TokenLiteral wrong. got=%s", literal.TokenLiteral())
+	}
+}
+
+func TestIntegerLiteralOutOfRange(t *testing.T) {
+	l := lexer.New("100000000000000000000; 9223372036854775807;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.BigIntLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.BigIntLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Value.String() != "100000000000000000000" {
+		t.Errorf("literal.Value wrong. got=%s", literal.Value)
+	}
+	if literal.TokenLiteral() != "100000000000000000000" {
+		t.Errorf("literal.TokenLiteral wrong. got=%s", literal.TokenLiteral())
+	}
+
+	stmt = program.Statements[1].(*ast.ExpressionStatement)
+	if _, ok := stmt.Expression.(*ast.IntegerLiteral); !ok {
+		t.Errorf("exp not *ast.IntegerLiteral. got=%T", stmt.Expression)
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	prefixTests := []struct {
 		input    string
@@ -282,7 +347,7 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		},
 		{
 			"3 + 4; -5 * 5",
-			"(3 + 4)((-5) * 5)",
+			"(3 + 4);((-5) * 5)",
 		},
 		{
 			"5 > 4 == 3 < 4",
@@ -877,6 +942,49 @@ func TestStringLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestInterpolatedString(t *testing.T) {
+	input := `"Hello ${name}, you have ${len(items) + 1} items\${x}"`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+
+	checkParserErrors(t, p)
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	str, ok := stmt.Expression.(*ast.InterpolatedString)
+	if !ok {
+		t.Fatalf("exp not *ast.InterpolatedString. got=%T", stmt.Expression)
+	}
+
+	tests := []struct {
+		expected string
+		pos      token.Position
+	}{
+		{`"Hello "`, token.Position{Line: 1, Column: 2}},
+		{"name", token.Position{Line: 1, Column: 10}},
+		{`", you have "`, token.Position{Line: 1, Column: 15}},
+		{"(len(items) + 1)", token.Position{Line: 1, Column: 39}},
+		{`" items\${x}"`, token.Position{Line: 1, Column: 43}},
+	}
+
+	if len(str.Parts) != len(tests) {
+		t.Fatalf("wrong number of parts. want=%d, got=%d", len(tests), len(str.Parts))
+	}
+
+	for i, tt := range tests {
+		if got := str.Parts[i].String(); got != tt.expected {
+			t.Errorf("parts[%d] wrong. want=%q, got=%q", i, tt.expected, got)
+		}
+		if got := str.Parts[i].Pos(); got != tt.pos {
+			t.Errorf("parts[%d] position wrong. want=%s, got=%s", i, tt.pos, got)
+		}
+	}
+
+	expected := `"Hello ${name}, you have ${(len(items) + 1)} items\${x}"`
+	if str.String() != expected {
+		t.Errorf("str.String() wrong. want=%q, got=%q", expected, str.String())
+	}
+}
+
 func TestParsingEmptyArrayLiterals(t *testing.T) {
 	input := "[]"
 
@@ -966,7 +1074,7 @@ func TestParsingHashLiteralsStringKeys(t *testing.T) {
 			t.Errorf("key is not ast.StringLiteral. got=%T", key)
 		}
 
-		expectedValue := expected[literal.String()]
+		expectedValue := expected[literal.Value]
 
 		testIntegerLiteral(t, value, expectedValue)
 	}
@@ -1028,12 +1136,203 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 			continue
 		}
 
-		testFunc, ok := tests[literal.String()]
+		testFunc, ok := tests[literal.Value]
 		if !ok {
-			t.Errorf("No test function for key %q found", literal.String())
+			t.Errorf("No test function for key %q found", literal.Value)
 			continue
 		}
 
 		testFunc(value)
 	}
 }
+
+func TestFunctionLiteralWithName(t *testing.T) {
+	input := `let myFunction = fn() { };`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.LetStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.LetStatement. got=%T", program.Statements[0])
+	}
+
+	function, ok := stmt.Value.(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("stmt.Value is not ast.FunctionLiteral. got=%T", stmt.Value)
+	}
+
+	if function.Name != "myFunction" {
+		t.Errorf("function literal name wrong. want 'myFunction', got=%q", function.Name)
+	}
+}
+
+func TestErrorSuggestions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		line     int
+		column   int
+	}{
+		{"retrun x;", "unexpected identifier retrun (did you mean `return`?)", 1, 1},
+		{"let a = 1;\nlett b = 2;", "unexpected identifier lett (did you mean `let`?)", 2, 1},
+		{"a & b", "no prefix parse function for ILLEGAL found (did you mean `&&`?)", 1, 3},
+		{"a | b", "no prefix parse function for ILLEGAL found (did you mean `||`?)", 1, 3},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.ErrorList()
+		if len(errors) == 0 {
+			t.Errorf("expected errors for %q", tt.input)
+			continue
+		}
+
+		if errors[0].Message != tt.expected {
+			t.Errorf("wrong error for %q. got=%q, want=%q", tt.input, errors[0].Message, tt.expected)
+		}
+
+		if errors[0].Token.Pos.Line != tt.line || errors[0].Token.Pos.Column != tt.column {
+			t.Errorf("wrong error position for %q. got=%s, want=%d:%d", tt.input, errors[0].Token.Pos, tt.line, tt.column)
+		}
+	}
+
+	// Identifiers unlike any keyword are left to the evaluator
+	p := New(lexer.New("foo bar"))
+	p.ParseProgram()
+	checkParserErrors(t, p)
+}
+
+func TestMalformedPrograms(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(1) { 1 }", "expected next token to be IDENT, got INT instead."},
+		{"fn(a, ) { a }", "expected next token to be IDENT, got ) instead."},
+		{"fn(\x9d) {}", "expected next token to be IDENT, got ILLEGAL instead."},
+		{"fn(x) { x", "expected next token to be }, got EOF instead."},
+		{"if (x) { 1 } else {", "expected next token to be }, got EOF instead."},
+		{`"a${}b"`, "empty expression in the interpolated string"},
+		{`"${a b}"`, "unexpected IDENT in the interpolated string"},
+		{`"${let}"`, "no prefix parse function for LET found"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		if errors := p.Errors(); len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("wrong errors for %q. got=%q, want=%q", tt.input, errors, tt.expected)
+		}
+	}
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: int = 5;", "let x: int = 5;"},
+		{"let xs: [string] = [];", "let xs: [string] = [];"},
+		{"let h: {string: [int]} = {};", "let h: {string: [int]} = {};"},
+		{"let f = fn(a: string, b: [int]) -> bool { true };", "let f = fn(a: string, b: [int]) -> bool { true };"},
+		{"let f = fn(a, b: int) { a };", "let f = fn(a, b: int) { a };"},
+		{"let g: fn(int, fn() -> int) -> bool = f;", "let g: fn(int, fn() -> int) -> bool = f;"},
+		{"let g: fn() = f;", "let g: fn() = f;"},
+		{"5 - 3", "(5 - 3)"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("wrong program for %q. got=%q, want=%q", tt.input, program.String(), tt.expected)
+		}
+	}
+
+	p := New(lexer.New("let f = fn(a, b: int) { a };"))
+	program := p.ParseProgram()
+	fl := program.Statements[0].(*ast.LetStatement).Value.(*ast.FunctionLiteral)
+	if fl.ParameterType(0) != nil || fl.ParameterType(1).String() != "int" || fl.ReturnType != nil {
+		t.Errorf("wrong parameter types. got=%v, return=%v", fl.ParameterTypes, fl.ReturnType)
+	}
+
+	for _, input := range []string{"let x: = 5;", "let x: [int = 5;", "fn(a: 5) {}", "fn() -> {}"} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected errors for %q", input)
+		}
+	}
+}
+
+func TestTailCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []bool
+	}{
+		{"fn(n) { f(n) }", []bool{true}},
+		{"fn(n) { f(n); g(n) }", []bool{false, true}},
+		{"fn(n) { return f(g(n)); }", []bool{true, false}},
+		{"fn(n) { if (n) { return f(n); } else { g(n) } h(n) }", []bool{true, false, true}},
+		{"fn(n) { if (n) { f(n) } else { g(n) } }", []bool{true, true}},
+		{"fn(n) { 1 + f(n) }", []bool{false}},
+		{"fn(n) { let x = if (n) { return f(n) }; x }", []bool{false}},
+		{"fn(n) { fn() { f(n) } }", []bool{true}},
+		{"f(n)", []bool{false}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		got := []bool{}
+		ast.Inspect(program, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpression); ok {
+				got = append(got, call.Tail)
+			}
+			return true
+		})
+
+		if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+			t.Errorf("wrong tail calls for %q. got=%v, want=%v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func BenchmarkParseProgram(b *testing.B) {
+	files, err := filepath.Glob(filepath.Join("..", "benchmarks", "*.mp"))
+	if err != nil || len(files) == 0 {
+		b.Fatalf("no benchmark programs found: %v", err)
+	}
+
+	var input []byte
+	for _, file := range files {
+		source, err := ioutil.ReadFile(file)
+		if err != nil {
+			b.Fatal(err)
+		}
+		input = append(append(input, source...), '\n')
+	}
+
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		p := New(lexer.New(string(input)))
+		p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			b.Fatal(p.Errors())
+		}
+	}
+}
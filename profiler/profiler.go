@@ -0,0 +1,277 @@
+package profiler
+
+import (
+	"compress/gzip"
+	"fmt"
+	"github.com/lxdlam/monkey-plus/ast"
+	"github.com/lxdlam/monkey-plus/object"
+	"github.com/lxdlam/monkey-plus/token"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Function struct {
+	ID   uint64
+	Name string
+	File string
+	Pos  token.Position
+}
+
+// Sample is the aggregated calls of one call stack, the leaf function is the first one of the stack
+type Sample struct {
+	Stack []*Function
+	Calls int64
+	// Self is the time spent in the leaf function, excluding the time of its callees
+	Self time.Duration
+}
+
+type definition struct {
+	file string
+	pos  token.Position
+}
+
+type frame struct {
+	fn       *Function
+	start    time.Time
+	children time.Duration
+}
+
+// Profiler tracks the time and calls of every Monkey function. It implements object.Hook.
+type Profiler struct {
+	definitions map[*ast.BlockStatement]definition
+	functions   map[*ast.BlockStatement]*Function
+	stack       []*frame
+	samples     map[string]*Sample
+	start       time.Time
+	duration    time.Duration
+	now         func() time.Time
+}
+
+func New() *Profiler {
+	return newWithClock(time.Now)
+}
+
+func newWithClock(now func() time.Time) *Profiler {
+	p := &Profiler{
+		definitions: make(map[*ast.BlockStatement]definition),
+		functions:   make(map[*ast.BlockStatement]*Function),
+		samples:     make(map[string]*Sample),
+		start:       now(),
+		now:         now,
+	}
+
+	main := &Function{ID: 1, Name: "main"}
+	p.stack = []*frame{{fn: main, start: p.start}}
+
+	return p
+}
+
+func (p *Profiler) LoadProgram(program *ast.Program) {
+	main := p.stack[0].fn
+	if main.File == "" && program.File != "" {
+		main.File = program.File
+		main.Name = "main@" + filepath.Base(program.File)
+		main.Pos = token.Position{Line: 1, Column: 1}
+	}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		if fl, ok := node.(*ast.FunctionLiteral); ok {
+			if _, ok := p.definitions[fl.Body]; !ok {
+				p.definitions[fl.Body] = definition{file: program.File, pos: fl.Pos()}
+			}
+		}
+		return true
+	})
+}
+
+func (p *Profiler) BeforeStatement(stmt ast.Statement, env *object.Environment) {}
+
+func (p *Profiler) EnterFunction(fn *object.Function, args []object.Object) {
+	p.stack = append(p.stack, &frame{fn: p.function(fn), start: p.now()})
+}
+
+func (p *Profiler) ExitFunction(fn *object.Function, result object.Object) {
+	if len(p.stack) <= 1 {
+		return
+	}
+
+	top := p.stack[len(p.stack)-1]
+	elapsed := p.now().Sub(top.start)
+	p.record(top.children, elapsed)
+
+	p.stack = p.stack[:len(p.stack)-1]
+	p.stack[len(p.stack)-1].children += elapsed
+}
+
+// Stop finishes the profiling, the time not spent in any function is attributed to the main function
+func (p *Profiler) Stop() {
+	for len(p.stack) > 1 {
+		p.ExitFunction(nil, nil)
+	}
+
+	main := p.stack[0]
+	p.duration = p.now().Sub(main.start)
+	p.record(main.children, p.duration)
+	main.children = p.duration
+}
+
+func (p *Profiler) record(children, elapsed time.Duration) {
+	stack := make([]*Function, len(p.stack))
+	ids := make([]string, len(p.stack))
+	for i := range p.stack {
+		stack[i] = p.stack[len(p.stack)-1-i].fn
+		ids[i] = fmt.Sprint(stack[i].ID)
+	}
+
+	key := strings.Join(ids, ",")
+	sample, ok := p.samples[key]
+	if !ok {
+		sample = &Sample{Stack: stack}
+		p.samples[key] = sample
+	}
+
+	sample.Calls++
+	sample.Self += elapsed - children
+}
+
+func (p *Profiler) function(fn *object.Function) *Function {
+	if f, ok := p.functions[fn.Body]; ok {
+		return f
+	}
+
+	def, ok := p.definitions[fn.Body]
+	if !ok {
+		def = definition{pos: fn.Body.Pos()}
+	}
+
+	name := fn.Name
+	if name == "" {
+		name = "fn"
+	}
+
+	if def.file != "" {
+		name = fmt.Sprintf("%s@%s:%d", name, filepath.Base(def.file), def.pos.Line)
+	} else {
+		name = fmt.Sprintf("%s@%d", name, def.pos.Line)
+	}
+
+	f := &Function{ID: uint64(len(p.functions) + 2), Name: name, File: def.file, Pos: def.pos}
+	p.functions[fn.Body] = f
+
+	return f
+}
+
+// Samples returns the samples ordered by their stacks
+func (p *Profiler) Samples() []*Sample {
+	var samples []*Sample
+	for _, sample := range p.samples {
+		samples = append(samples, sample)
+	}
+
+	// Compare the stacks from the root
+	sort.Slice(samples, func(i, j int) bool {
+		a, b := samples[i].Stack, samples[j].Stack
+		for k := 1; k <= len(a) && k <= len(b); k++ {
+			if a[len(a)-k].ID != b[len(b)-k].ID {
+				return a[len(a)-k].ID < b[len(b)-k].ID
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	return samples
+}
+
+// WriteProfile writes the gzipped profile.proto, which can be read by `go tool pprof`
+func (p *Profiler) WriteProfile(w io.Writer) error {
+	indices := map[string]int64{"": 0}
+	table := []string{""}
+	str := func(s string) int64 {
+		if idx, ok := indices[s]; ok {
+			return idx
+		}
+		indices[s] = int64(len(table))
+		table = append(table, s)
+		return indices[s]
+	}
+
+	var b protoBuffer
+
+	valueType := func(field int, typ, unit string) {
+		b.message(field, func(m *protoBuffer) {
+			m.int64(1, str(typ))
+			m.int64(2, str(unit))
+		})
+	}
+
+	// sample_type
+	valueType(1, "calls", "count")
+	valueType(1, "time", "nanoseconds")
+
+	samples := p.Samples()
+	functions := map[uint64]*Function{}
+
+	// sample
+	for _, sample := range samples {
+		locations := make([]uint64, len(sample.Stack))
+		for i, fn := range sample.Stack {
+			locations[i] = fn.ID
+			functions[fn.ID] = fn
+		}
+
+		b.message(2, func(m *protoBuffer) {
+			m.packedUint64(1, locations)
+			m.packedInt64(2, []int64{sample.Calls, int64(sample.Self)})
+		})
+	}
+
+	var ids []uint64
+	for id := range functions {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	// location, every function has exactly one location at its definition
+	for _, id := range ids {
+		fn := functions[id]
+		b.message(4, func(m *protoBuffer) {
+			m.uint64(1, fn.ID)
+			m.message(4, func(line *protoBuffer) {
+				line.uint64(1, fn.ID)
+				line.int64(2, int64(fn.Pos.Line))
+			})
+		})
+	}
+
+	// function
+	for _, id := range ids {
+		fn := functions[id]
+		b.message(5, func(m *protoBuffer) {
+			m.uint64(1, fn.ID)
+			m.int64(2, str(fn.Name))
+			m.int64(3, str(fn.Name))
+			m.int64(4, str(fn.File))
+			m.int64(5, int64(fn.Pos.Line))
+		})
+	}
+
+	// string_table, all the strings are collected above
+	for _, s := range table {
+		b.string(6, s)
+	}
+
+	b.int64(9, p.start.UnixNano())
+	b.int64(10, int64(p.duration))
+	valueType(11, "time", "nanoseconds")
+	b.int64(12, 1)
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(b.data); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
@@ -0,0 +1,138 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/lxdlam/monkey-plus/evaluator"
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/object"
+	"github.com/lxdlam/monkey-plus/parser"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+const input = `let square = fn(x) { x * x };
+let sum = fn(n) {
+  if (n == 0) {
+    0
+  } else {
+    square(n) + sum(n - 1)
+  }
+};
+sum(3);
+fn() { 1 }();
+`
+
+func runWithProfiler(t *testing.T) *Profiler {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	program.File = "sum.mp"
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has errors: %v", p.Errors())
+	}
+
+	// Every reading of the clock advances it by 1ms
+	var ticks time.Duration
+	prof := newWithClock(func() time.Time {
+		ticks += time.Millisecond
+		return time.Unix(0, 0).Add(ticks)
+	})
+
+	runtime := &object.Runtime{}
+	runtime.AddHook(prof)
+	evaluator.Eval(program, object.NewEnvironmentWithRuntime(runtime))
+	prof.Stop()
+
+	return prof
+}
+
+func TestSamples(t *testing.T) {
+	samples := runWithProfiler(t).Samples()
+
+	expected := []struct {
+		stack string
+		calls int64
+	}{
+		{"main@sum.mp", 1},
+		{"sum@sum.mp:2 < main@sum.mp", 1},
+		{"sum@sum.mp:2 < sum@sum.mp:2 < main@sum.mp", 1},
+		{"sum@sum.mp:2 < sum@sum.mp:2 < sum@sum.mp:2 < main@sum.mp", 1},
+		{"sum@sum.mp:2 < sum@sum.mp:2 < sum@sum.mp:2 < sum@sum.mp:2 < main@sum.mp", 1},
+		{"square@sum.mp:1 < sum@sum.mp:2 < sum@sum.mp:2 < sum@sum.mp:2 < main@sum.mp", 1},
+		{"square@sum.mp:1 < sum@sum.mp:2 < sum@sum.mp:2 < main@sum.mp", 1},
+		{"square@sum.mp:1 < sum@sum.mp:2 < main@sum.mp", 1},
+		{"fn@sum.mp:10 < main@sum.mp", 1},
+	}
+
+	if len(samples) != len(expected) {
+		t.Fatalf("wrong number of samples. got=%d, want=%d", len(samples), len(expected))
+	}
+
+	var total time.Duration
+	for i, tt := range expected {
+		var names []string
+		for _, fn := range samples[i].Stack {
+			names = append(names, fn.Name)
+		}
+
+		if stack := strings.Join(names, " < "); stack != tt.stack {
+			t.Errorf("samples[%d] has wrong stack. got=%q, want=%q", i, stack, tt.stack)
+		}
+
+		if samples[i].Calls != tt.calls {
+			t.Errorf("samples[%d] has wrong calls. got=%d, want=%d", i, samples[i].Calls, tt.calls)
+		}
+
+		if samples[i].Self <= 0 {
+			t.Errorf("samples[%d] has non-positive self time %s", i, samples[i].Self)
+		}
+		total += samples[i].Self
+	}
+
+	// The self time of all the samples sums up to the whole duration
+	if prof := runWithProfiler(t); total != prof.duration {
+		t.Errorf("wrong total time. got=%s, want=%s", total, prof.duration)
+	}
+}
+
+func TestWriteProfile(t *testing.T) {
+	var out bytes.Buffer
+	if err := runWithProfiler(t).WriteProfile(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatalf("profile is not gzipped: %s", err)
+	}
+
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{"calls", "nanoseconds", "square@sum.mp:1", "sum.mp"} {
+		if !bytes.Contains(data, []byte(s)) {
+			t.Errorf("profile does not contain string %q", s)
+		}
+	}
+}
+
+func TestProtoBuffer(t *testing.T) {
+	var b protoBuffer
+	b.uint64(1, 150)
+	b.string(2, "testing")
+	b.packedUint64(4, []uint64{3, 270})
+
+	expected := []byte{
+		0x08, 0x96, 0x01,
+		0x12, 0x07, 't', 'e', 's', 't', 'i', 'n', 'g',
+		0x22, 0x03, 0x03, 0x8e, 0x02,
+	}
+
+	if !bytes.Equal(b.data, expected) {
+		t.Errorf("wrong encoding. got=%x, want=%x", b.data, expected)
+	}
+}
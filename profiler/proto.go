@@ -0,0 +1,76 @@
+package profiler
+
+// A minimal protocol buffers encoder, which is just enough to write the profile.proto messages
+// of pprof without importing any third party packages.
+// See https://github.com/google/pprof/blob/master/proto/profile.proto for the schema.
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+type protoBuffer struct {
+	data []byte
+}
+
+func (b *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		b.data = append(b.data, byte(x)|0x80)
+		x >>= 7
+	}
+	b.data = append(b.data, byte(x))
+}
+
+func (b *protoBuffer) key(field int, wire int) {
+	b.varint(uint64(field)<<3 | uint64(wire))
+}
+
+func (b *protoBuffer) uint64(field int, x uint64) {
+	if x == 0 {
+		return
+	}
+	b.key(field, wireVarint)
+	b.varint(x)
+}
+
+func (b *protoBuffer) int64(field int, x int64) {
+	b.uint64(field, uint64(x))
+}
+
+func (b *protoBuffer) string(field int, s string) {
+	b.key(field, wireBytes)
+	b.varint(uint64(len(s)))
+	b.data = append(b.data, s...)
+}
+
+func (b *protoBuffer) packedUint64(field int, xs []uint64) {
+	if len(xs) == 0 {
+		return
+	}
+
+	var packed protoBuffer
+	for _, x := range xs {
+		packed.varint(x)
+	}
+	b.bytes(field, packed.data)
+}
+
+func (b *protoBuffer) packedInt64(field int, xs []int64) {
+	us := make([]uint64, len(xs))
+	for i, x := range xs {
+		us[i] = uint64(x)
+	}
+	b.packedUint64(field, us)
+}
+
+func (b *protoBuffer) bytes(field int, data []byte) {
+	b.key(field, wireBytes)
+	b.varint(uint64(len(data)))
+	b.data = append(b.data, data...)
+}
+
+func (b *protoBuffer) message(field int, encode func(m *protoBuffer)) {
+	var m protoBuffer
+	encode(&m)
+	b.bytes(field, m.data)
+}
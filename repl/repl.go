@@ -9,6 +9,7 @@ import (
 	"github.com/lxdlam/monkey-plus/parser"
 	"io"
 	"log"
+	"strings"
 )
 
 const PROMPT = ">> "
@@ -25,19 +26,20 @@ const MONKEY_FACE = `            __,__
            '-----'
 `
 
+// Start reads the lines from in and evaluates them. The input builtins read from in too, they get
+// the lines after the one being evaluated.
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
+	reader := bufio.NewReader(in)
+	env := object.NewEnvironmentWithRuntime(&object.Runtime{Stdin: reader, Stdout: out})
 
 	for {
 		fmt.Printf(PROMPT)
-		scanned := scanner.Scan()
-
-		if !scanned {
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
 			return
 		}
 
-		line := scanner.Text()
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
 		l := lexer.New(line)
 		p := parser.New(l)
 
@@ -0,0 +1,282 @@
+package resolver
+
+import (
+	"github.com/lxdlam/monkey-plus/ast"
+	"github.com/lxdlam/monkey-plus/token"
+	"sort"
+)
+
+type Kind int
+
+const (
+	Let Kind = iota
+	Parameter
+	Predeclared
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Let:
+		return "let"
+	case Parameter:
+		return "parameter"
+	default:
+		return "builtin"
+	}
+}
+
+// Binding is a name introduced by a let statement, a function parameter or the interpreter
+type Binding struct {
+	Name string
+	Kind Kind
+	// Ident is the identifier introducing the binding, nil for predeclared names
+	Ident *ast.Identifier
+	// Decl is the let statement or function literal declaring the binding
+	Decl  ast.Node
+	Scope *Scope
+	// Uses lists the identifiers referring to the binding in source order
+	Uses []*ast.Identifier
+}
+
+// Scope is the program or the body of a function literal, `if` blocks do not open a scope
+type Scope struct {
+	Parent *Scope
+	// Node is the program or function literal opening the scope, nil for the predeclared scope
+	Node     ast.Node
+	Bindings []*Binding
+	Children []*Scope
+	Start    token.Position
+	End      token.Position
+
+	names map[string]*Binding
+}
+
+func newScope(parent *Scope, node ast.Node) *Scope {
+	s := &Scope{Parent: parent, Node: node, names: make(map[string]*Binding)}
+	if parent != nil {
+		parent.Children = append(parent.Children, s)
+	}
+
+	return s
+}
+
+func (s *Scope) declare(b *Binding) {
+	b.Scope = s
+	s.Bindings = append(s.Bindings, b)
+	s.names[b.Name] = b
+}
+
+// Lookup returns the binding name refers to in the scope after all its statements ran
+func (s *Scope) Lookup(name string) *Binding {
+	for ; s != nil; s = s.Parent {
+		if b, ok := s.names[name]; ok {
+			return b
+		}
+	}
+
+	return nil
+}
+
+func (s *Scope) contains(pos token.Position) bool {
+	if s.Node == nil {
+		return true
+	}
+
+	if _, ok := s.Node.(*ast.Program); ok {
+		return true
+	}
+
+	return !pos.Before(s.Start) && !s.End.Before(pos)
+}
+
+// Visible returns the bindings visible at pos in the scope, inner bindings shadow outer ones
+func (s *Scope) Visible(pos token.Position) []*Binding {
+	seen := make(map[string]bool)
+	visible := []*Binding{}
+
+	for scope := s; scope != nil; scope = scope.Parent {
+		for i := len(scope.Bindings) - 1; i >= 0; i-- {
+			b := scope.Bindings[i]
+			if seen[b.Name] {
+				continue
+			}
+
+			// Bindings of the innermost scope are only defined once their let statement ran
+			if scope == s && b.Kind == Let && !b.Ident.Pos().Before(pos) {
+				continue
+			}
+
+			seen[b.Name] = true
+			visible = append(visible, b)
+		}
+	}
+
+	sort.Slice(visible, func(i, j int) bool { return visible[i].Name < visible[j].Name })
+
+	return visible
+}
+
+type Info struct {
+	// Universe holds the predeclared names, its only child is Global
+	Universe *Scope
+	Global   *Scope
+	// Defs maps the identifiers introducing bindings to them
+	Defs map[*ast.Identifier]*Binding
+	// Uses maps the identifiers referring to bindings to them
+	Uses map[*ast.Identifier]*Binding
+	// Scopes maps the identifiers referring to names to the scopes they appear in
+	Scopes map[*ast.Identifier]*Scope
+	// Unresolved lists the identifiers that refer to no binding, in source order
+	Unresolved []*ast.Identifier
+}
+
+// Resolve binds every identifier of the program statically. Names are resolved like the
+// evaluator does: a let binding is visible after its statement in its own scope, and function
+// bodies see the final bindings of the enclosing scopes since they run after them.
+func Resolve(program *ast.Program, predeclared []string) *Info {
+	info := &Info{
+		Defs:   make(map[*ast.Identifier]*Binding),
+		Uses:   make(map[*ast.Identifier]*Binding),
+		Scopes: make(map[*ast.Identifier]*Scope),
+	}
+
+	info.Universe = newScope(nil, nil)
+	for _, name := range predeclared {
+		info.Universe.declare(&Binding{Name: name, Kind: Predeclared})
+	}
+
+	info.Global = newScope(info.Universe, program)
+	info.Global.Start = token.Position{Line: 1, Column: 1}
+
+	r := &resolver{info: info}
+	r.resolveScope(info.Global, program.Statements)
+
+	// Function bodies are resolved late, so restore the source order
+	sortIdents(info.Unresolved)
+	sortUses(info.Universe)
+
+	return info
+}
+
+func sortUses(scope *Scope) {
+	for _, b := range scope.Bindings {
+		sortIdents(b.Uses)
+	}
+
+	for _, child := range scope.Children {
+		sortUses(child)
+	}
+}
+
+func sortIdents(idents []*ast.Identifier) {
+	sort.SliceStable(idents, func(i, j int) bool { return idents[i].Pos().Before(idents[j].Pos()) })
+}
+
+// BindingAt returns the binding of the identifier at pos and the identifier itself
+func (info *Info) BindingAt(pos token.Position) (*Binding, *ast.Identifier) {
+	for ident, b := range info.Defs {
+		if identContains(ident, pos) {
+			return b, ident
+		}
+	}
+
+	for ident, b := range info.Uses {
+		if identContains(ident, pos) {
+			return b, ident
+		}
+	}
+
+	return nil, nil
+}
+
+// ScopeAt returns the innermost scope containing pos
+func (info *Info) ScopeAt(pos token.Position) *Scope {
+	scope := info.Global
+
+	for {
+		inner := (*Scope)(nil)
+		for _, child := range scope.Children {
+			if child.contains(pos) {
+				inner = child
+				break
+			}
+		}
+
+		if inner == nil {
+			return scope
+		}
+
+		scope = inner
+	}
+}
+
+func identContains(ident *ast.Identifier, pos token.Position) bool {
+	start := ident.Pos()
+	return start.Line == pos.Line && start.Column <= pos.Column && pos.Column < start.Column+len(ident.Value)
+}
+
+type resolver struct {
+	info    *Info
+	pending []*ast.FunctionLiteral
+}
+
+func (r *resolver) resolveScope(scope *Scope, statements []ast.Statement) {
+	outer := r.pending
+	r.pending = nil
+
+	for _, stmt := range statements {
+		r.walk(scope, stmt)
+	}
+
+	// Function bodies are resolved once the enclosing scope is complete
+	functions := r.pending
+	r.pending = outer
+
+	for _, fl := range functions {
+		child := newScope(scope, fl)
+		child.Start = fl.Pos()
+		if fl.Body != nil {
+			child.End = fl.Body.End
+		}
+
+		for _, param := range fl.Parameters {
+			r.define(child, &Binding{Name: param.Value, Kind: Parameter, Ident: param, Decl: fl})
+		}
+
+		if fl.Body != nil {
+			r.resolveScope(child, fl.Body.Statements)
+		}
+	}
+}
+
+func (r *resolver) walk(scope *Scope, node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.LetStatement:
+			r.walk(scope, n.Value)
+			if n.Name != nil {
+				r.define(scope, &Binding{Name: n.Name.Value, Kind: Let, Ident: n.Name, Decl: n})
+			}
+			return false
+		case *ast.FunctionLiteral:
+			r.pending = append(r.pending, n)
+			return false
+		case *ast.Identifier:
+			r.info.Scopes[n] = scope
+			if b := scope.Lookup(n.Value); b != nil {
+				b.Uses = append(b.Uses, n)
+				r.info.Uses[n] = b
+			} else {
+				r.info.Unresolved = append(r.info.Unresolved, n)
+			}
+			return false
+		}
+
+		return true
+	})
+}
+
+func (r *resolver) define(scope *Scope, b *Binding) {
+	scope.declare(b)
+	r.info.Defs[b.Ident] = b
+}
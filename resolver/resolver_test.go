@@ -0,0 +1,127 @@
+package resolver
+
+import (
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/parser"
+	"github.com/lxdlam/monkey-plus/token"
+	"testing"
+)
+
+const input = `let x = 1;
+let add = fn(a, b) {
+  let sum = a + b + x;
+  sum
+};
+let x = add(x, later());
+let later = fn() { len(missing) };
+puts(after);
+let after = 2;
+`
+
+func resolve(t *testing.T, input string) *Info {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has errors: %v", p.Errors())
+	}
+
+	return Resolve(program, []string{"len", "puts"})
+}
+
+func TestResolve(t *testing.T) {
+	info := resolve(t, input)
+
+	tests := []struct {
+		pos      token.Position
+		name     string
+		kind     Kind
+		defLine  int
+		useLines []int
+	}{
+		// The first x is used by the right side of the second let only
+		{token.Position{Line: 1, Column: 5}, "x", Let, 1, []int{6}},
+		// The body of add runs after the whole program, so it sees the second x
+		{token.Position{Line: 3, Column: 21}, "x", Let, 6, []int{3}},
+		{token.Position{Line: 3, Column: 13}, "a", Parameter, 2, []int{3}},
+		{token.Position{Line: 4, Column: 3}, "sum", Let, 3, []int{4}},
+		// later is called before its let statement ran
+		{token.Position{Line: 7, Column: 5}, "later", Let, 7, []int{}},
+		{token.Position{Line: 7, Column: 20}, "len", Predeclared, 0, []int{7}},
+	}
+
+	for _, tt := range tests {
+		b, _ := info.BindingAt(tt.pos)
+		if b == nil {
+			t.Errorf("no binding at %s", tt.pos)
+			continue
+		}
+
+		if b.Name != tt.name || b.Kind != tt.kind {
+			t.Errorf("wrong binding at %s. got=%s %s, want=%s %s", tt.pos, b.Kind, b.Name, tt.kind, tt.name)
+		}
+
+		if b.Ident != nil && b.Ident.Pos().Line != tt.defLine {
+			t.Errorf("wrong definition line of %s. got=%d, want=%d", tt.name, b.Ident.Pos().Line, tt.defLine)
+		}
+
+		if len(b.Uses) != len(tt.useLines) {
+			t.Errorf("wrong number of uses of %s. got=%d, want=%d", tt.name, len(b.Uses), len(tt.useLines))
+			continue
+		}
+
+		for i, line := range tt.useLines {
+			if b.Uses[i].Pos().Line != line {
+				t.Errorf("wrong line of use %d of %s. got=%d, want=%d", i, tt.name, b.Uses[i].Pos().Line, line)
+			}
+		}
+	}
+}
+
+func TestUnresolved(t *testing.T) {
+	info := resolve(t, input)
+
+	expected := []string{"later", "missing", "after"}
+	if len(info.Unresolved) != len(expected) {
+		t.Fatalf("wrong number of unresolved identifiers. got=%d, want=%d", len(info.Unresolved), len(expected))
+	}
+
+	// missing is found last since function bodies are resolved after the program
+	for i, name := range expected {
+		if info.Unresolved[i].Value != name {
+			t.Errorf("unresolved[%d] wrong. got=%q, want=%q", i, info.Unresolved[i].Value, name)
+		}
+	}
+}
+
+func TestScopeAt(t *testing.T) {
+	info := resolve(t, input)
+
+	tests := []struct {
+		pos     token.Position
+		visible []string
+	}{
+		{token.Position{Line: 1, Column: 1}, []string{"len", "puts"}},
+		{token.Position{Line: 4, Column: 3}, []string{"a", "add", "after", "b", "later", "len", "puts", "sum", "x"}},
+		{token.Position{Line: 8, Column: 1}, []string{"add", "later", "len", "puts", "x"}},
+	}
+
+	for _, tt := range tests {
+		visible := info.ScopeAt(tt.pos).Visible(tt.pos)
+		names := []string{}
+		for _, b := range visible {
+			names = append(names, b.Name)
+		}
+
+		if len(names) != len(tt.visible) {
+			t.Errorf("wrong visible names at %s. got=%v, want=%v", tt.pos, names, tt.visible)
+			continue
+		}
+
+		for i := range names {
+			if names[i] != tt.visible[i] {
+				t.Errorf("wrong visible names at %s. got=%v, want=%v", tt.pos, names, tt.visible)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package resolver
+
+import (
+	"github.com/lxdlam/monkey-plus/ast"
+)
+
+// AssignSlots resolves the names local to the function literals of the program to the slots of
+// the environments of their calls. The names of the program scope are left to the lookup by
+// name, since the REPL, `load` and the debugger bind them at runtime.
+func AssignSlots(program *ast.Program) {
+	info := Resolve(program, nil)
+
+	// The bindings of the same name in a scope share a slot, a let statement may rebind a name
+	slots := make(map[*Binding]int)
+	var assign func(scope *Scope)
+	assign = func(scope *Scope) {
+		if fl, ok := scope.Node.(*ast.FunctionLiteral); ok {
+			indexes := make(map[string]int)
+			fl.Locals = []string{}
+
+			for _, b := range scope.Bindings {
+				if _, ok := indexes[b.Name]; !ok {
+					indexes[b.Name] = len(fl.Locals)
+					fl.Locals = append(fl.Locals, b.Name)
+				}
+
+				slots[b] = indexes[b.Name]
+				b.Ident.Slot = &ast.Slot{Depth: 0, Index: slots[b]}
+			}
+		} else {
+			for _, b := range scope.Bindings {
+				if b.Ident != nil {
+					b.Ident.Slot = nil
+				}
+			}
+		}
+
+		for _, child := range scope.Children {
+			assign(child)
+		}
+	}
+	assign(info.Global)
+
+	for ident, scope := range info.Scopes {
+		b, ok := info.Uses[ident]
+		if !ok || b.Scope == info.Global || b.Scope == info.Universe {
+			ident.Slot = nil
+			continue
+		}
+
+		// Every scope out of the global one is the body of a function, called in its own environment
+		depth := 0
+		for ; scope != b.Scope; scope = scope.Parent {
+			depth++
+		}
+
+		ident.Slot = &ast.Slot{Depth: depth, Index: slots[b]}
+	}
+}
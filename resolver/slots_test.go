@@ -0,0 +1,62 @@
+package resolver
+
+import (
+	"fmt"
+	"github.com/lxdlam/monkey-plus/ast"
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/parser"
+	"strings"
+	"testing"
+)
+
+func TestAssignSlots(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		locals   []string
+	}{
+		{"let x = 1; x", "x:- x:-", nil},
+		{"fn(a, b) { let c = a; b + c }", "a:0/0 b:0/1 c:0/2 a:0/0 b:0/1 c:0/2", []string{"a b c"}},
+		// Free names are looked up by name, the outer locals are reached through the depth
+		{"fn(a) { fn(b) { a + b + g + len } }", "a:0/0 b:0/0 a:1/0 b:0/0 g:- len:-", []string{"a", "b"}},
+		// A name rebound in a scope keeps its slot
+		{"fn(a) { let a = a + 1; let b = 2; let b = b; }", "a:0/0 a:0/0 a:0/0 b:0/1 b:0/1 b:0/1", []string{"a b"}},
+		// Before its let statement a name refers to the outer binding
+		{"let v = 1; fn() { v; let v = 2; v }", "v:- v:- v:0/0 v:0/0", []string{"v"}},
+		{"fn() { let f = fn(n) { f(n) }; f }", "f:0/0 n:0/0 f:1/0 n:0/0 f:0/0", []string{"f", "n"}},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser has errors: %v", p.Errors())
+		}
+
+		AssignSlots(program)
+
+		slots := []string{}
+		locals := []string{}
+		ast.Inspect(program, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.Identifier:
+				slot := "-"
+				if n.Slot != nil {
+					slot = fmt.Sprintf("%d/%d", n.Slot.Depth, n.Slot.Index)
+				}
+				slots = append(slots, n.Value+":"+slot)
+			case *ast.FunctionLiteral:
+				locals = append(locals, strings.Join(n.Locals, " "))
+			}
+			return true
+		})
+
+		if got := strings.Join(slots, " "); got != tt.expected {
+			t.Errorf("wrong slots for %q.\ngot=%s\nwant=%s", tt.input, got, tt.expected)
+		}
+
+		if fmt.Sprint(locals) != fmt.Sprint(tt.locals) {
+			t.Errorf("wrong locals for %q. got=%q, want=%q", tt.input, locals, tt.locals)
+		}
+	}
+}
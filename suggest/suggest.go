@@ -0,0 +1,78 @@
+package suggest
+
+import (
+	"sort"
+)
+
+// Distance returns the edit distance between a and b, counting a transposition of two adjacent
+// characters as a single edit
+func Distance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+
+	for j := 0; j <= len(b); j++ {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = minInt(d[i-1][j]+1, minInt(d[i][j-1]+1, d[i-1][j-1]+cost))
+
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(a)][len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Closest returns the candidate closest to name, or "" if none is close enough. Names shorter than
+// three characters get no suggestion, longer names allow one edit every three characters.
+func Closest(name string, candidates []string) string {
+	sorted := append([]string{}, candidates...)
+	sort.Strings(sorted)
+
+	best, bestDistance := "", len(name)/3+1
+
+	for _, candidate := range sorted {
+		if candidate == name {
+			continue
+		}
+
+		if distance := Distance(name, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	return best
+}
+
+// Hint formats the suggestion to be appended to an error message
+func Hint(suggestion string) string {
+	if suggestion == "" {
+		return ""
+	}
+
+	return " (did you mean `" + suggestion + "`?)"
+}
+
+// DidYouMean returns the hint for the candidate closest to name, or "" if none is close enough
+func DidYouMean(name string, candidates []string) string {
+	return Hint(Closest(name, candidates))
+}
@@ -0,0 +1,48 @@
+package suggest
+
+import (
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"len", "len", 0},
+		{"lenn", "len", 1},
+		{"pust", "puts", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	}
+
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.expected {
+			t.Errorf("Distance(%q, %q) wrong. got=%d, want=%d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	candidates := []string{"len", "puts", "first", "rest", "return", "let", "x"}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"lenn", " (did you mean `len`?)"},
+		{"pust", " (did you mean `puts`?)"},
+		{"retrun", " (did you mean `return`?)"},
+		{"fisrt", " (did you mean `first`?)"},
+		{"y", ""},
+		{"unrelated", ""},
+		{"le", ""},
+	}
+
+	for _, tt := range tests {
+		if got := DidYouMean(tt.name, candidates); got != tt.expected {
+			t.Errorf("DidYouMean(%q) wrong. got=%q, want=%q", tt.name, got, tt.expected)
+		}
+	}
+}
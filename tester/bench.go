@@ -0,0 +1,189 @@
+package tester
+
+import (
+	"context"
+	"fmt"
+	"github.com/lxdlam/monkey-plus/ast"
+	"github.com/lxdlam/monkey-plus/evaluator"
+	"github.com/lxdlam/monkey-plus/object"
+	"io"
+	"regexp"
+	goruntime "runtime"
+	"strings"
+	"time"
+)
+
+const BENCH_FUNC_PREFIX = "bench_"
+
+type Benchmark struct {
+	Name string
+	// N is the number of the measured calls
+	N        int
+	Duration time.Duration
+	// Bytes and Allocs are the totals of the measured calls
+	Bytes  uint64
+	Allocs uint64
+	Err    *object.Error
+}
+
+func (b *Benchmark) NsPerOp() int64 {
+	return b.Duration.Nanoseconds() / int64(b.N)
+}
+
+// String formats the benchmark like `go test -bench -benchmem`, so `benchstat` can compare the runs
+func (b *Benchmark) String() string {
+	return fmt.Sprintf("%s\t%8d\t%10d ns/op\t%8d B/op\t%8d allocs/op",
+		benchmarkName(b.Name), b.N, b.NsPerOp(), b.Bytes/uint64(b.N), b.Allocs/uint64(b.N))
+}
+
+// benchmarkName converts bench_hash_set into BenchmarkHashSet
+func benchmarkName(name string) string {
+	words := strings.Split(strings.TrimPrefix(name, BENCH_FUNC_PREFIX), "_")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+
+	return "Benchmark" + strings.Join(words, "")
+}
+
+type Bencher struct {
+	Out    io.Writer
+	Filter *regexp.Regexp
+	// Time is how long every benchmark runs at least, it is ignored if Iterations is set
+	Time       time.Duration
+	Iterations int
+	// Warmup is the number of the calls before measuring
+	Warmup int
+	// Count is the number of times every benchmark is measured
+	Count int
+	// Context stops the benchmarks once it is canceled
+	Context context.Context
+}
+
+func NewBencher(out io.Writer) *Bencher {
+	return &Bencher{Out: out, Time: time.Second, Warmup: 1, Count: 1}
+}
+
+// Run runs the benchmark functions of every file and reports whether all of them succeeded.
+func (b *Bencher) Run(files []string) bool {
+	fmt.Fprintf(b.Out, "goos: %s\ngoarch: %s\n", goruntime.GOOS, goruntime.GOARCH)
+
+	passed := true
+	for _, file := range files {
+		if !b.RunFile(file) {
+			passed = false
+		}
+	}
+
+	return passed
+}
+
+func (b *Bencher) RunFile(path string) bool {
+	start := time.Now()
+
+	program, err := parseFile(path)
+	if err != nil {
+		fmt.Fprintf(b.Out, "FAIL\t%s [setup failed]\n    %s\n", path, err)
+		return false
+	}
+
+	fmt.Fprintf(b.Out, "pkg: %s\n", path)
+
+	passed := true
+	for _, name := range functionNames(program, BENCH_FUNC_PREFIX) {
+		if b.Filter != nil && !b.Filter.MatchString(name) {
+			continue
+		}
+
+		for i := 0; i < b.Count; i++ {
+			bench := b.runBenchmark(program, name)
+			if bench.Err != nil {
+				fmt.Fprintf(b.Out, "--- FAIL: %s\n    error: %s\n", benchmarkName(name), bench.Err.Message)
+				passed = false
+				break
+			}
+
+			fmt.Fprintln(b.Out, bench.String())
+		}
+	}
+
+	if passed {
+		fmt.Fprintf(b.Out, "ok  \t%s\t%.3fs\n", path, time.Since(start).Seconds())
+	} else {
+		fmt.Fprintf(b.Out, "FAIL\t%s\t%.3fs\n", path, time.Since(start).Seconds())
+	}
+
+	return passed
+}
+
+// Like the tests, every benchmark runs in its own environment. The top level bindings are not measured.
+func (b *Bencher) runBenchmark(program *ast.Program, name string) *Benchmark {
+	bench := &Benchmark{Name: name}
+
+	env := object.NewEnvironmentWithRuntime(&object.Runtime{Context: b.Context})
+	if errObj, ok := evaluator.Eval(program, env).(*object.Error); ok {
+		bench.Err = errObj
+		return bench
+	}
+
+	fn, ok := env.Get(name)
+	if !ok || fn.Type() != object.FUNC_OBJ {
+		bench.Err = &object.Error{Message: fmt.Sprintf("%s is not a function", name)}
+		return bench
+	}
+
+	if bench.Err = b.measure(bench, fn, env, b.Warmup); bench.Err != nil {
+		return bench
+	}
+
+	if b.Iterations > 0 {
+		bench.Err = b.measure(bench, fn, env, b.Iterations)
+		return bench
+	}
+
+	// Grow the number of the calls until they run long enough, like the testing package does
+	n := 1
+	for {
+		if bench.Err = b.measure(bench, fn, env, n); bench.Err != nil || bench.Duration >= b.Time || n >= 1e9 {
+			return bench
+		}
+
+		next := n * 100
+		if bench.Duration > 0 {
+			predicted := int(int64(n) * b.Time.Nanoseconds() / bench.Duration.Nanoseconds())
+			if predicted+predicted/5 < next {
+				next = predicted + predicted/5
+			}
+		}
+
+		if next <= n {
+			next = n + 1
+		}
+		n = next
+	}
+}
+
+// measure calls the function n times and records the time and the allocations of the calls
+func (b *Bencher) measure(bench *Benchmark, fn object.Object, env *object.Environment, n int) *object.Error {
+	var before, after goruntime.MemStats
+	goruntime.GC()
+	goruntime.ReadMemStats(&before)
+	start := time.Now()
+
+	for i := 0; i < n; i++ {
+		if errObj, ok := evaluator.Call(fn, []object.Object{}, env).(*object.Error); ok {
+			return errObj
+		}
+	}
+
+	bench.Duration = time.Since(start)
+	goruntime.ReadMemStats(&after)
+
+	bench.N = n
+	bench.Bytes = after.TotalAlloc - before.TotalAlloc
+	bench.Allocs = after.Mallocs - before.Mallocs
+
+	return nil
+}
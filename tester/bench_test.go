@@ -0,0 +1,118 @@
+package tester
+
+import (
+	"bytes"
+	"github.com/lxdlam/monkey-plus/evaluator"
+	"github.com/lxdlam/monkey-plus/object"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const mathBench = `
+let calls = 0;
+
+let bench_add = fn() {
+  1 + 2;
+};
+
+let bench_string_concat = fn() {
+  "a" + "b";
+};
+
+let bench_broken = fn() {
+  missing;
+};
+`
+
+func TestBencher(t *testing.T) {
+	evaluator.InitBuiltins()
+	dir := writeTestFiles(t, map[string]string{"math_test.mp": mathBench})
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	bencher := NewBencher(&out)
+	bencher.Iterations = 10
+	bencher.Count = 2
+
+	if bencher.Run([]string{filepath.Join(dir, "math_test.mp")}) {
+		t.Errorf("benchmarks should fail")
+	}
+
+	lines := regexp.MustCompile(`(?m)^Benchmark\w+\t +10\t +\d+ ns/op\t +\d+ B/op\t +\d+ allocs/op$`).FindAllString(out.String(), -1)
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "BenchmarkAdd\t") || !strings.HasPrefix(lines[2], "BenchmarkStringConcat\t") {
+		t.Errorf("wrong benchmark lines. got=%q", out.String())
+	}
+
+	if !strings.Contains(out.String(), "--- FAIL: BenchmarkBroken\n    error: identifier not found: missing") ||
+		!strings.Contains(out.String(), "pkg: "+filepath.Join(dir, "math_test.mp")) {
+		t.Errorf("wrong report. got=%q", out.String())
+	}
+
+	out.Reset()
+	bencher.Filter = regexp.MustCompile("add")
+	if !bencher.Run([]string{filepath.Join(dir, "math_test.mp")}) || strings.Contains(out.String(), "Concat") {
+		t.Errorf("filtered benchmarks should pass. got=%q", out.String())
+	}
+}
+
+func TestBenchmarkTime(t *testing.T) {
+	evaluator.InitBuiltins()
+	dir := writeTestFiles(t, map[string]string{"math_test.mp": mathBench})
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	bencher := NewBencher(&out)
+	bencher.Time = 1e7
+
+	program, err := parseFile(filepath.Join(dir, "math_test.mp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bench := bencher.runBenchmark(program, "bench_add")
+	if bench.Err != nil || bench.N <= 1 || bench.Duration < bencher.Time {
+		t.Errorf("wrong benchmark. N=%d, duration=%s, err=%v", bench.N, bench.Duration, bench.Err)
+	}
+}
+
+// BenchmarkCorpus runs the benchmark functions of the Monkey programs under benchmarks/
+func BenchmarkCorpus(b *testing.B) {
+	evaluator.InitBuiltins()
+
+	files, err := Discover([]string{filepath.Join("..", "benchmarks")})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, file := range files {
+		program, err := parseFile(file)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		for _, name := range functionNames(program, BENCH_FUNC_PREFIX) {
+			env := object.NewEnvironment()
+			if result := evaluator.Eval(program, env); isErrorObject(result) {
+				b.Fatal(result.Inspect())
+			}
+			fn, _ := env.Get(name)
+
+			b.Run(strings.TrimPrefix(benchmarkName(name), "Benchmark"), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if result := evaluator.Call(fn, []object.Object{}, env); isErrorObject(result) {
+						b.Fatal(result.Inspect())
+					}
+				}
+			})
+		}
+	}
+}
+
+func isErrorObject(obj object.Object) bool {
+	_, ok := obj.(*object.Error)
+	return ok
+}
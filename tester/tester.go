@@ -0,0 +1,238 @@
+package tester
+
+import (
+	"context"
+	"fmt"
+	"github.com/lxdlam/monkey-plus/ast"
+	"github.com/lxdlam/monkey-plus/coverage"
+	"github.com/lxdlam/monkey-plus/evaluator"
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/object"
+	"github.com/lxdlam/monkey-plus/parser"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	TEST_FILE_SUFFIX = "_test.mp"
+	TEST_FUNC_PREFIX = "test_"
+)
+
+type Result struct {
+	Name     string
+	Passed   bool
+	Duration time.Duration
+	Err      *object.Error
+}
+
+type FileResult struct {
+	Path     string
+	Results  []*Result
+	Duration time.Duration
+	// Err is set when the file itself could not be loaded, e.g. parse errors
+	Err error
+}
+
+func (fr *FileResult) Passed() bool {
+	if fr.Err != nil {
+		return false
+	}
+
+	for _, r := range fr.Results {
+		if !r.Passed {
+			return false
+		}
+	}
+
+	return true
+}
+
+type Runner struct {
+	Out    io.Writer
+	Filter *regexp.Regexp
+	// Coverage records the executed statements of all the tests if set
+	Coverage *coverage.Profile
+	// Seed seeds the random builtins of every test if set, so the tests are reproducible
+	Seed *int64
+	// Clock is the clock of every test if set, e.g. a fake clock for the tests of the time
+	Clock object.Clock
+	// Context stops the tests once it is canceled, the remaining ones fail at once
+	Context context.Context
+}
+
+func New(out io.Writer) *Runner {
+	return &Runner{Out: out}
+}
+
+// Discover expands the paths into the test files. Directories are walked recursively.
+func Discover(paths []string) ([]string, error) {
+	var files []string
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !info.IsDir() && strings.HasSuffix(info.Name(), TEST_FILE_SUFFIX) {
+				files = append(files, p)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// Run runs every test file and reports whether all of them passed.
+func (r *Runner) Run(files []string) bool {
+	passed := true
+
+	for _, file := range files {
+		if !r.RunFile(file).Passed() {
+			passed = false
+		}
+	}
+
+	return passed
+}
+
+func (r *Runner) RunFile(path string) *FileResult {
+	start := time.Now()
+	fr := &FileResult{Path: path}
+
+	program, err := parseFile(path)
+	if err != nil {
+		fr.Err = err
+	} else {
+		for _, name := range functionNames(program, TEST_FUNC_PREFIX) {
+			if r.Filter != nil && !r.Filter.MatchString(name) {
+				continue
+			}
+
+			result := r.runTest(program, name)
+			fr.Results = append(fr.Results, result)
+			r.report(result)
+		}
+	}
+
+	fr.Duration = time.Since(start)
+
+	if fr.Err != nil {
+		fmt.Fprintf(r.Out, "FAIL\t%s [setup failed]\n    %s\n", path, fr.Err)
+	} else if fr.Passed() {
+		fmt.Fprintf(r.Out, "ok  \t%s\t%.3fs\n", path, fr.Duration.Seconds())
+	} else {
+		fmt.Fprintf(r.Out, "FAIL\t%s\t%.3fs\n", path, fr.Duration.Seconds())
+	}
+
+	return fr
+}
+
+// Every test runs in its own environment, so the top level bindings are evaluated again for each test
+func (r *Runner) runTest(program *ast.Program, name string) *Result {
+	start := time.Now()
+	result := &Result{Name: name}
+
+	runtime := &object.Runtime{Clock: r.Clock, Context: r.Context}
+	if r.Coverage != nil {
+		runtime.AddHook(r.Coverage)
+	}
+	if r.Seed != nil {
+		runtime.Seed(*r.Seed)
+	}
+
+	env := object.NewEnvironmentWithRuntime(runtime)
+	evaluated := evaluator.Eval(program, env)
+
+	if errObj, ok := evaluated.(*object.Error); ok {
+		result.Err = errObj
+	} else if fn, ok := env.Get(name); !ok || fn.Type() != object.FUNC_OBJ {
+		result.Err = &object.Error{Message: fmt.Sprintf("%s is not a function", name)}
+	} else if errObj, ok := evaluator.Call(fn, []object.Object{}, env).(*object.Error); ok {
+		result.Err = errObj
+	}
+
+	result.Passed = result.Err == nil
+	result.Duration = time.Since(start)
+
+	return result
+}
+
+func (r *Runner) ReportCoverage() {
+	for _, summary := range r.Coverage.Summary() {
+		fmt.Fprintf(r.Out, "coverage: %.1f%% of statements in %s\n", summary.Percent(), summary.File)
+	}
+}
+
+func (r *Runner) report(result *Result) {
+	if result.Passed {
+		fmt.Fprintf(r.Out, "--- PASS: %s (%.2fs)\n", result.Name, result.Duration.Seconds())
+		return
+	}
+
+	fmt.Fprintf(r.Out, "--- FAIL: %s (%.2fs)\n", result.Name, result.Duration.Seconds())
+	if result.Err.Failure != nil {
+		fmt.Fprintf(r.Out, "    %s\n", result.Err.Message)
+	} else {
+		fmt.Fprintf(r.Out, "    error: %s\n", result.Err.Message)
+	}
+}
+
+func parseFile(path string) (*ast.Program, error) {
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser.New(lexer.New(string(source)))
+	program := p.ParseProgram()
+	program.File = path
+
+	if len(p.Errors()) != 0 {
+		return nil, fmt.Errorf("parse error: %s", strings.Join(p.Errors(), "; "))
+	}
+
+	return program, nil
+}
+
+// functionNames returns the names of the top level functions starting with the prefix
+func functionNames(program *ast.Program, prefix string) []string {
+	var names []string
+	seen := make(map[string]bool)
+
+	for _, stmt := range program.Statements {
+		let, ok := stmt.(*ast.LetStatement)
+		if !ok || !strings.HasPrefix(let.Name.Value, prefix) || seen[let.Name.Value] {
+			continue
+		}
+
+		if _, ok := let.Value.(*ast.FunctionLiteral); ok {
+			names = append(names, let.Name.Value)
+			seen[let.Name.Value] = true
+		}
+	}
+
+	return names
+}
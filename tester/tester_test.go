@@ -0,0 +1,201 @@
+package tester
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/lxdlam/monkey-plus/evaluator"
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/object"
+	"github.com/lxdlam/monkey-plus/parser"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+const mathTest = `
+let counter = 0;
+
+let test_add = fn() {
+  assert_eq(1 + 2, 3);
+};
+
+let test_isolated = fn() {
+  let counter = counter + 1;
+  assert_eq(counter, 1);
+};
+
+let test_sub = fn() {
+  assert_eq(5 - 3, 3);
+};
+
+let helper = fn() { assert(false); };
+`
+
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "monkey-tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestDiscover(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"math_test.mp":     mathTest,
+		"lib.mp":           "let a = 1;",
+		"sub/str_test.mp":  "",
+		"sub/str_test.txt": "",
+	})
+	defer os.RemoveAll(dir)
+
+	files, err := Discover([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{filepath.Join(dir, "math_test.mp"), filepath.Join(dir, "sub", "str_test.mp")}
+	if len(files) != len(expected) {
+		t.Fatalf("wrong number of files. got=%v, want=%v", files, expected)
+	}
+
+	for i, file := range files {
+		if file != expected[i] {
+			t.Errorf("files[%d] wrong. got=%s, want=%s", i, file, expected[i])
+		}
+	}
+}
+
+func TestRunFile(t *testing.T) {
+	evaluator.InitBuiltins()
+	dir := writeTestFiles(t, map[string]string{"math_test.mp": mathTest})
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	runner := New(&out)
+	result := runner.RunFile(filepath.Join(dir, "math_test.mp"))
+
+	if result.Passed() {
+		t.Errorf("file should fail")
+	}
+
+	expected := map[string]bool{"test_add": true, "test_isolated": true, "test_sub": false}
+	if len(result.Results) != len(expected) {
+		t.Fatalf("wrong number of results. got=%d, want=%d", len(result.Results), len(expected))
+	}
+
+	for _, r := range result.Results {
+		if r.Passed != expected[r.Name] {
+			t.Errorf("%s passed=%t, want=%t", r.Name, r.Passed, expected[r.Name])
+		}
+	}
+
+	if !strings.Contains(out.String(), "--- FAIL: test_sub") ||
+		!strings.Contains(out.String(), "assertion failed: got=2, want=3") {
+		t.Errorf("wrong report. got=%q", out.String())
+	}
+}
+
+func TestRunFilter(t *testing.T) {
+	evaluator.InitBuiltins()
+	dir := writeTestFiles(t, map[string]string{"math_test.mp": mathTest})
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	runner := New(&out)
+	runner.Filter = regexp.MustCompile("add|isolated")
+
+	if !runner.Run([]string{filepath.Join(dir, "math_test.mp")}) {
+		t.Errorf("filtered tests should pass. got=%q", out.String())
+	}
+}
+
+func TestRunSeed(t *testing.T) {
+	evaluator.InitBuiltins()
+
+	runtime := &object.Runtime{}
+	runtime.Seed(5)
+	program := parser.New(lexer.New("rand_int(0, 1000000)")).ParseProgram()
+	first := evaluator.Eval(program, object.NewEnvironmentWithRuntime(runtime)).Inspect()
+
+	// Every test starts from the seed, whatever the previous ones drew
+	test := fmt.Sprintf("let test_a = fn() { assert_eq(rand_int(0, 1000000), %s); rand_float(); };\n", first) +
+		fmt.Sprintf("let test_b = fn() { assert_eq(rand_int(0, 1000000), %s); };\n", first)
+	dir := writeTestFiles(t, map[string]string{"rand_test.mp": test})
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	runner := New(&out)
+	seed := int64(5)
+	runner.Seed = &seed
+
+	if !runner.Run([]string{filepath.Join(dir, "rand_test.mp")}) {
+		t.Errorf("seeded tests should pass. got=%q", out.String())
+	}
+}
+
+func TestRunClock(t *testing.T) {
+	evaluator.InitBuiltins()
+
+	test := `let test_now = fn() { assert_eq(format_time(now(), "2006-01-02"), "2024-03-01"); sleep(1000); };
+let test_later = fn() { assert_eq(unix(), 1709251201); };
+`
+	dir := writeTestFiles(t, map[string]string{"time_test.mp": test})
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	runner := New(&out)
+	runner.Clock = object.NewFakeClock(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	if !runner.Run([]string{filepath.Join(dir, "time_test.mp")}) {
+		t.Errorf("tests on the fake clock should pass. got=%q", out.String())
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	evaluator.InitBuiltins()
+	dir := writeTestFiles(t, map[string]string{"slow_test.mp": "let test_slow = fn() { sleep(60000) };"})
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var out bytes.Buffer
+	runner := New(&out)
+	runner.Context = ctx
+
+	if runner.Run([]string{filepath.Join(dir, "slow_test.mp")}) {
+		t.Fatalf("canceled tests should fail")
+	}
+	if !strings.Contains(out.String(), "evaluation canceled: context canceled") {
+		t.Errorf("wrong output. got=%q", out.String())
+	}
+}
+
+func TestRunParseError(t *testing.T) {
+	evaluator.InitBuiltins()
+	dir := writeTestFiles(t, map[string]string{"bad_test.mp": "let = 1;"})
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	result := New(&out).RunFile(filepath.Join(dir, "bad_test.mp"))
+
+	if result.Err == nil || result.Passed() {
+		t.Errorf("parse error should fail the file")
+	}
+}
@@ -1,19 +1,44 @@
 package token
 
+import (
+	"fmt"
+	"sort"
+)
+
 type TokenType string
 
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Before reports whether p comes before q in the source
+func (p Position) Before(q Position) bool {
+	return p.Line < q.Line || p.Line == q.Line && p.Column < q.Column
+}
+
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position
 }
 
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
 
-	IDENT  = "IDENT"
-	INT    = "INT"
+	IDENT = "IDENT"
+	INT   = "INT"
+	FLOAT = "FLOAT"
+	// BIGINT is an integer with the `n` suffix, e.g. 123n
+	BIGINT = "BIGINT"
 	STRING = "STRING"
+	// INTERPOLATED is a string with `${expression}` parts, its literal is the raw content
+	INTERPOLATED = "INTERPOLATED"
 
 	ASSIGN   = "="
 	PLUS     = "+"
@@ -32,6 +57,8 @@ const (
 	AND = "&&"
 	OR  = "||"
 
+	ARROW = "->"
+
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
@@ -62,6 +89,18 @@ var keywords = map[string]TokenType{
 	"return": RETURN,
 }
 
+// Keywords returns the sorted keywords of the language
+func Keywords() []string {
+	names := []string{}
+	for name := range keywords {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
 func LookupIdent(ident string) TokenType {
 	if tok, ok := keywords[ident]; ok {
 		return tok
@@ -0,0 +1,67 @@
+package transport
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The base protocol shared by the Debug Adapter Protocol and the Language Server Protocol:
+// every message is a JSON document preceded by a Content-Length header
+
+// ReadMessage reads one message with the Content-Length header
+func ReadMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+
+		idx := strings.Index(line, ":")
+		if idx < 0 {
+			return nil, fmt.Errorf("invalid header %q", line)
+		}
+
+		if strings.TrimSpace(line[:idx]) == "Content-Length" {
+			length, err = strconv.Atoi(strings.TrimSpace(line[idx+1:]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid Content-Length %q", line)
+			}
+		}
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+func WriteMessage(w io.Writer, message interface{}) error {
+	content, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+
+	_, err = w.Write(content)
+	return err
+}
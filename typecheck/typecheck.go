@@ -0,0 +1,440 @@
+package typecheck
+
+import (
+	"fmt"
+	"github.com/lxdlam/monkey-plus/ast"
+	"github.com/lxdlam/monkey-plus/resolver"
+	"github.com/lxdlam/monkey-plus/token"
+	"sort"
+)
+
+type Error struct {
+	Pos     token.Position
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Pos.String() + ": " + e.Message
+}
+
+// Check infers the types of the program and reports the mismatches, sorted by position. The
+// predeclared names are the builtins of the evaluator.
+func Check(program *ast.Program, predeclared []string) []*Error {
+	c := &checker{
+		info:  resolver.Resolve(program, predeclared),
+		types: make(map[*resolver.Binding]Type),
+	}
+
+	for _, stmt := range program.Statements {
+		c.statement(stmt)
+	}
+
+	sort.SliceStable(c.errors, func(i, j int) bool { return c.errors[i].Pos.Before(c.errors[j].Pos) })
+
+	return c.errors
+}
+
+type checker struct {
+	info   *resolver.Info
+	types  map[*resolver.Binding]Type
+	errors []*Error
+	// functions is the stack of the function literals being checked
+	functions []*function
+}
+
+type function struct {
+	// declared is the annotated return type, nil if there is none
+	declared Type
+	returns  Type
+}
+
+func (c *checker) errorf(node ast.Node, format string, a ...interface{}) {
+	c.errors = append(c.errors, &Error{Pos: node.Pos(), Message: fmt.Sprintf(format, a...)})
+}
+
+// statement returns the type of the value of the statement, nil if it returns from the function
+func (c *checker) statement(stmt ast.Statement) Type {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		if stmt != nil {
+			c.let(stmt)
+		}
+		return Null
+	case *ast.ReturnStatement:
+		if stmt != nil {
+			c.ret(stmt)
+		}
+		return nil
+	case *ast.ExpressionStatement:
+		if stmt != nil {
+			return c.expression(stmt.Expression)
+		}
+	case *ast.BlockStatement:
+		return c.block(stmt)
+	}
+
+	return Any
+}
+
+func (c *checker) block(block *ast.BlockStatement) Type {
+	if block == nil || len(block.Statements) == 0 {
+		return Null
+	}
+
+	var t Type
+	for _, stmt := range block.Statements {
+		t = c.statement(stmt)
+	}
+
+	return t
+}
+
+func (c *checker) let(stmt *ast.LetStatement) {
+	b := c.info.Defs[stmt.Name]
+
+	var declared Type
+	if stmt.Type != nil {
+		declared = c.fromAnnotation(stmt.Type)
+		// The declared type is known by the recursive calls in the value
+		c.types[b] = declared
+	} else if fl, ok := stmt.Value.(*ast.FunctionLiteral); ok && fl != nil && fl.ReturnType != nil {
+		c.types[b] = c.signature(fl)
+	}
+
+	actual := c.expression(stmt.Value)
+
+	if declared != nil {
+		if !Consistent(actual, declared) {
+			c.errorf(stmt.Value, "cannot use %s as %s in let %s", actual, declared, stmt.Name.Value)
+		}
+		c.types[b] = declared
+	} else {
+		c.types[b] = actual
+	}
+}
+
+func (c *checker) ret(stmt *ast.ReturnStatement) {
+	t := c.expression(stmt.ReturnValue)
+
+	if len(c.functions) == 0 {
+		return
+	}
+
+	fn := c.functions[len(c.functions)-1]
+	fn.returns = join(fn.returns, t)
+
+	if fn.declared != nil && !Consistent(t, fn.declared) {
+		c.errorf(stmt, "cannot return %s from a function returning %s", t, fn.declared)
+	}
+}
+
+// signature is the type of the function literal from its annotations only
+func (c *checker) signature(fl *ast.FunctionLiteral) *Function {
+	f := &Function{Parameters: []Type{}, Return: c.fromAnnotation(fl.ReturnType)}
+	for i := range fl.Parameters {
+		f.Parameters = append(f.Parameters, c.fromAnnotation(fl.ParameterType(i)))
+	}
+
+	return f
+}
+
+func (c *checker) function(fl *ast.FunctionLiteral) Type {
+	f := c.signature(fl)
+
+	for i, param := range fl.Parameters {
+		if b, ok := c.info.Defs[param]; ok {
+			c.types[b] = f.Parameters[i]
+		}
+	}
+
+	fn := &function{}
+	if fl.ReturnType != nil {
+		fn.declared = f.Return
+	}
+
+	c.functions = append(c.functions, fn)
+	value := c.block(fl.Body)
+	c.functions = c.functions[:len(c.functions)-1]
+
+	// The value of the last statement is returned implicitly
+	if value != nil && fn.declared != nil && !Consistent(value, fn.declared) {
+		last := fl.Body.Statements[len(fl.Body.Statements)-1]
+		c.errorf(last, "cannot return %s from a function returning %s", value, fn.declared)
+	}
+
+	if fn.declared == nil {
+		f.Return = join(fn.returns, value)
+		if f.Return == nil {
+			f.Return = Any
+		}
+	}
+
+	return f
+}
+
+func (c *checker) expression(exp ast.Expression) Type {
+	switch exp := exp.(type) {
+	// The integers are promoted to the big integers on overflow, so int covers both
+	case *ast.IntegerLiteral, *ast.BigIntLiteral:
+		return Int
+	case *ast.FloatLiteral:
+		return Float
+	case *ast.StringLiteral:
+		return String
+	case *ast.InterpolatedString:
+		for _, part := range exp.Parts {
+			c.expression(part)
+		}
+		return String
+	case *ast.Boolean:
+		return Bool
+	case *ast.Identifier:
+		return c.identifier(exp)
+	case *ast.PrefixExpression:
+		return c.prefix(exp)
+	case *ast.InfixExpression:
+		return c.infix(exp)
+	case *ast.IfExpression:
+		if exp == nil {
+			return Any
+		}
+
+		c.expression(exp.Condition)
+		consequence := c.block(exp.Consequence)
+		alternative := Type(Null)
+		if exp.Alternative != nil {
+			alternative = c.block(exp.Alternative)
+		}
+
+		if t := join(consequence, alternative); t != nil {
+			return t
+		}
+		return Any
+	case *ast.FunctionLiteral:
+		if exp == nil {
+			return Any
+		}
+		return c.function(exp)
+	case *ast.CallExpression:
+		return c.call(exp)
+	case *ast.ArrayLiteral:
+		var element Type
+		for _, el := range exp.Elements {
+			element = join(element, c.expression(el))
+		}
+
+		if element == nil {
+			element = Any
+		}
+		return &Array{Element: element}
+	case *ast.HashLiteral:
+		var key, value Type
+		for _, k := range exp.Keys {
+			t := c.expression(k)
+			if !hashable(t) {
+				c.errorf(k, "unusable as hash key: %s", t)
+			}
+
+			key = join(key, t)
+			value = join(value, c.expression(exp.Pairs[k]))
+		}
+
+		if key == nil {
+			return anyHash
+		}
+		return &Hash{Key: key, Value: value}
+	case *ast.IndexExpression:
+		return c.index(exp)
+	}
+
+	return Any
+}
+
+func (c *checker) identifier(ident *ast.Identifier) Type {
+	b, ok := c.info.Uses[ident]
+	if !ok {
+		return Any
+	}
+
+	if b.Kind == resolver.Predeclared {
+		if sig, ok := builtins[b.Name]; ok {
+			return sig
+		}
+		if t, ok := constants[b.Name]; ok {
+			return t
+		}
+		return Any
+	}
+
+	// The binding is not checked yet when a function body refers to a later let statement
+	if t, ok := c.types[b]; ok {
+		return t
+	}
+	return Any
+}
+
+func (c *checker) prefix(exp *ast.PrefixExpression) Type {
+	right := c.expression(exp.Right)
+
+	switch exp.Operator {
+	case "!":
+		return Bool
+	case "-":
+		if right == Float {
+			return Float
+		}
+		if right != Int && right != Any {
+			c.errorf(exp, "unknown operator: -%s", right)
+		}
+		return Int
+	}
+
+	return Any
+}
+
+// infix follows evalInfixExpression of the evaluator
+func (c *checker) infix(exp *ast.InfixExpression) Type {
+	left := c.expression(exp.Left)
+	right := c.expression(exp.Right)
+	op := exp.Operator
+
+	comparison := op == "==" || op == "!=" || op == "<" || op == ">"
+
+	if left == Any || right == Any {
+		if comparison || op == "&&" || op == "||" {
+			return Bool
+		}
+
+		if left == Any {
+			return right
+		}
+		return left
+	}
+
+	switch {
+	case left == Int && right == Int:
+		switch op {
+		case "+", "-", "*", "/", "%":
+			return Int
+		case "<", ">", "==", "!=":
+			return Bool
+		}
+	case (left == Float || left == Int) && (right == Float || right == Int):
+		switch op {
+		case "+", "-", "*", "/", "%":
+			return Float
+		case "<", ">", "==", "!=":
+			return Bool
+		}
+	case left == String && right == String:
+		switch op {
+		case "+":
+			return String
+		case "<", ">", "==", "!=":
+			return Bool
+		}
+	case left == Bool && right == Bool:
+		switch op {
+		case "&&", "||", "==", "!=":
+			return Bool
+		}
+	case left == Time && right == Int:
+		switch op {
+		case "+", "-":
+			return Time
+		}
+	case left == Time && right == Time:
+		switch op {
+		case "-":
+			return Int
+		case "<", ">", "==", "!=":
+			return Bool
+		}
+	case op == "==" || op == "!=":
+		return Bool
+	case left.String() != right.String():
+		c.errorf(exp, "type mismatch: %s %s %s", left, op, right)
+		return Any
+	}
+
+	c.errorf(exp, "unknown operator: %s %s %s", left, op, right)
+	return Any
+}
+
+func (c *checker) index(exp *ast.IndexExpression) Type {
+	left := c.expression(exp.Left)
+	index := c.expression(exp.Index)
+
+	switch left := left.(type) {
+	case *Array:
+		if Consistent(index, Int) {
+			return left.Element
+		}
+	case *Hash:
+		if !hashable(index) {
+			c.errorf(exp.Index, "unusable as hash key: %s", index)
+		} else if !Consistent(index, left.Key) {
+			c.errorf(exp.Index, "cannot use %s as %s key", index, left.Key)
+		}
+		return left.Value
+	case Basic:
+		if left == Any || left == String && Consistent(index, Int) {
+			return left
+		}
+	}
+
+	c.errorf(exp, "index operator not supported: %s[%s]", left, index)
+	return Any
+}
+
+func (c *checker) call(exp *ast.CallExpression) Type {
+	callee := c.expression(exp.Function)
+
+	args := []Type{}
+	for _, arg := range exp.Arguments {
+		args = append(args, c.expression(arg))
+	}
+
+	switch callee := callee.(type) {
+	case *Function:
+		if c.arguments(exp, args, callee.Parameters, len(callee.Parameters), false) {
+			return callee.Return
+		}
+		return Any
+	case *builtin:
+		if c.arguments(exp, args, callee.parameters, callee.required, callee.variadic) {
+			return callee.result(args)
+		}
+		return Any
+	case Basic:
+		if callee == Any {
+			return Any
+		}
+	}
+
+	c.errorf(exp, "not a function: %s", callee)
+	return Any
+}
+
+// arguments checks the arguments against the parameters and reports whether they match
+func (c *checker) arguments(exp *ast.CallExpression, args, params []Type, required int, variadic bool) bool {
+	if !variadic && (len(args) < required || len(args) > len(params)) {
+		want := fmt.Sprint(required)
+		if required != len(params) {
+			want = fmt.Sprintf("%d or %d", required, len(params))
+		}
+
+		c.errorf(exp, "wrong number of arguments. got=%d, want=%s", len(args), want)
+		return false
+	}
+
+	ok := true
+	for i, arg := range args {
+		if i < len(params) && !Consistent(arg, params[i]) {
+			c.errorf(exp.Arguments[i], "cannot use %s as %s in argument %d of %s", arg, params[i], i+1, exp.Function)
+			ok = false
+		}
+	}
+
+	return ok
+}
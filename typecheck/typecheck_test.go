@@ -0,0 +1,170 @@
+package typecheck
+
+import (
+	"github.com/lxdlam/monkey-plus/evaluator"
+	"github.com/lxdlam/monkey-plus/lexer"
+	"github.com/lxdlam/monkey-plus/parser"
+	"testing"
+)
+
+func check(t *testing.T, input string) []*Error {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has errors: %v", p.Errors())
+	}
+
+	evaluator.InitBuiltins()
+	return Check(program, evaluator.BuiltinNames())
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		// Unannotated code is accepted unless the literals do not fit
+		{`let add = fn(a, b) { a + b }; add(1, "x");`, []string{}},
+		{`1 + "x"`, []string{"1:3: type mismatch: int + string"}},
+		{`-"x"; "a" - "b"; true + false`, []string{
+			"1:1: unknown operator: -string",
+			"1:11: unknown operator: string - string",
+			"1:23: unknown operator: bool + bool",
+		}},
+		{`let x: int = "five";`, []string{"1:14: cannot use string as int in let x"}},
+		{`let x: [int] = [1, 2]; let y: [string] = x;`, []string{"1:42: cannot use [int] as [string] in let y"}},
+		{`let x: unknown = 1;`, []string{"1:8: unknown type: unknown"}},
+		{`let h: {[int]: int} = {};`, []string{"1:9: unusable as hash key: [int]"}},
+		{`let f = fn(a: int, b: string) -> bool { true }; f("a", "b"); f(1);`, []string{
+			"1:51: cannot use string as int in argument 1 of f",
+			"1:63: wrong number of arguments. got=1, want=2",
+		}},
+		{`let f = fn(a: int) -> string { if (a > 0) { return a; } "none" };`, []string{
+			"1:45: cannot return int from a function returning string",
+		}},
+		{`let f = fn() -> int { "x" };`, []string{"1:23: cannot return string from a function returning int"}},
+		// The inferred return types flow into the callers
+		{`let name = fn() { "monkey" }; name() * 2;`, []string{"1:38: type mismatch: string * int"}},
+		// Recursive calls see the annotated signature
+		{`let fact = fn(n: int) -> int { if (n < 2) { 1 } else { n * fact("x") } };`, []string{
+			"1:65: cannot use string as int in argument 1 of fact",
+		}},
+		{`let f: fn(int) -> int = fn(x: int) -> int { x }; let g: fn(string) -> int = f;`, []string{
+			"1:77: cannot use fn(int) -> int as fn(string) -> int in let g",
+		}},
+		{`len(1); first([1])("x"); len([1]) + first(["a"]); puts(1, "a", true)`, []string{
+			"1:5: cannot use int as string | [any] | {any: any} in argument 1 of len",
+			"1:19: not a function: int",
+			"1:35: type mismatch: int + string",
+		}},
+		{`assert(1 == 1); assert_eq(1); assert_error(fn() { 1 });`, []string{
+			"1:26: wrong number of arguments. got=1, want=2 or 3",
+		}},
+		{`let xs = [1, 2]; xs["a"]; let h = {"a": 1}; h[1]; h[[1]]; 5[0]; "abc"[1] + 1`, []string{
+			"1:20: index operator not supported: [int][string]",
+			"1:47: cannot use int as string key",
+			"1:53: unusable as hash key: [int]",
+			"1:60: index operator not supported: int[int]",
+			"1:74: type mismatch: string + int",
+		}},
+		{`map(1, len); sum(range(3)) + "a"; sort([1], 2); first(filter([1], fn(x) { true })) + 1`, []string{
+			"1:5: cannot use int as [any] | string | {any: any} in argument 1 of map",
+			"1:28: type mismatch: int + string",
+			"1:45: cannot use int as fn() -> any in argument 2 of sort",
+		}},
+		{`upper(1); split("a,b", ",")[0] + 1; parse_int("1") + chr(65)`, []string{
+			"1:7: cannot use int as string in argument 1 of upper",
+			"1:32: type mismatch: string + int",
+			"1:52: type mismatch: int + string",
+		}},
+		{`"${1 + "a"}"; "${1}" + 1; format("%d", 1) + str(2); str(1, 2)`, []string{
+			"1:6: type mismatch: int + string",
+			"1:22: type mismatch: string + int",
+			"1:56: wrong number of arguments. got=2, want=1",
+		}},
+		{`let r: regex = regex("a+"); let s: regex = "a+"; match(1, "a"); split_re(r, "b")[0] + 1`, []string{
+			"1:44: cannot use string as regex in let s",
+			"1:56: cannot use int as regex | string in argument 1 of match",
+			"1:85: type mismatch: string + int",
+		}},
+		{`let f: float = json_parse("1.5"); json_stringify(f, true); json_stringify({}, "  ") + 1`, []string{
+			"1:53: cannot use bool as int | string in argument 2 of json_stringify",
+			"1:85: type mismatch: string + int",
+		}},
+		{`read_lines("a")[0] + 1; write_file("a", 1); len(list_dir(basename(path_join("a", "b"))))`, []string{
+			"1:20: type mismatch: string + int",
+			"1:41: cannot use int as string in argument 2 of write_file",
+		}},
+		{`input(); input("> ", 1); read_line() + 1; read_all(1)`, []string{
+			"1:15: wrong number of arguments. got=2, want=0 or 1",
+			"1:51: wrong number of arguments. got=1, want=0",
+		}},
+		{`let f: float = 1.5 * 2; let n: int = floor(f) + abs(-1); sqrt("4"); let p: int = -PI`, []string{
+			"1:63: cannot use string as int | float in argument 1 of sqrt",
+			"1:82: cannot use float as int in let p",
+		}},
+		{`let s: float = sum([1.5, 2.5]); let n: int = sum([1, 2]); let x: string = sum([1.5])`, []string{
+			"1:78: cannot use float as string in let x",
+		}},
+		{`let b: int = 12n * 2; let n: int = int(bigint("7")); bigint(1.5); b + "a"`, []string{
+			"1:61: cannot use float as int | string in argument 1 of bigint",
+			"1:69: type mismatch: int + string",
+		}},
+		{`let n: int = rand_int(1, 6) + choice([1, 2]); let f: float = rand_float(); shuffle("ab") + 1; seed(1.5)`, []string{
+			"1:90: type mismatch: string + int",
+			"1:100: cannot use float as int in argument 1 of seed",
+		}},
+		{`let t: time = now() + duration("1s"); let ms: int = t - now(); t < now(); format_time(t, 1); t * 2; let s: string = unix()`, []string{
+			"1:90: cannot use int as string in argument 2 of format_time",
+			"1:96: unknown operator: time * int",
+			"1:121: cannot use int as string in let s",
+		}},
+		// any opts out of the checks
+		{`let x: any = 1; x + "a"; let f = fn(a: any) -> int { a }; f("a")`, []string{}},
+		{`let xs = [1, "a"]; xs[0] + 1; let n: int = if (true) { 1 } else { "x" };`, []string{}},
+	}
+
+	for _, tt := range tests {
+		errors := check(t, tt.input)
+
+		got := []string{}
+		for _, err := range errors {
+			got = append(got, err.Error())
+		}
+
+		if len(got) != len(tt.expected) {
+			t.Errorf("wrong errors for %q.\ngot=%q\nwant=%q", tt.input, got, tt.expected)
+			continue
+		}
+
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("wrong errors for %q.\ngot=%q\nwant=%q", tt.input, got, tt.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestConsistent(t *testing.T) {
+	tests := []struct {
+		a, b     Type
+		expected bool
+	}{
+		{Int, Int, true},
+		{Int, String, false},
+		{Any, &Array{Int}, true},
+		{&Array{Any}, &Array{Int}, true},
+		{&Hash{String, Int}, &Hash{String, String}, false},
+		{&Function{[]Type{Int}, Bool}, &Function{[]Type{Any}, Bool}, true},
+		{&Function{[]Type{Int}, Bool}, &Function{[]Type{Int, Int}, Bool}, false},
+		{String, union{Int, String}, true},
+		{Bool, union{Int, String}, false},
+	}
+
+	for _, tt := range tests {
+		if got := Consistent(tt.a, tt.b); got != tt.expected {
+			t.Errorf("Consistent(%s, %s) wrong. got=%t, want=%t", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package typecheck
+
+import (
+	"github.com/lxdlam/monkey-plus/ast"
+	"strings"
+)
+
+// Type is a static type. Any is the dynamic type of the gradual typing, it is consistent with all
+// the types, so the code without annotations is never rejected for its types.
+type Type interface {
+	String() string
+}
+
+type Basic string
+
+func (b Basic) String() string { return string(b) }
+
+const (
+	Int    Basic = "int"
+	Float  Basic = "float"
+	String Basic = "string"
+	Bool   Basic = "bool"
+	Null   Basic = "null"
+	Regex  Basic = "regex"
+	Time   Basic = "time"
+	Any    Basic = "any"
+)
+
+type Array struct {
+	Element Type
+}
+
+func (a *Array) String() string { return "[" + a.Element.String() + "]" }
+
+type Hash struct {
+	Key   Type
+	Value Type
+}
+
+func (h *Hash) String() string { return "{" + h.Key.String() + ": " + h.Value.String() + "}" }
+
+type Function struct {
+	Parameters []Type
+	Return     Type
+}
+
+func (f *Function) String() string {
+	params := []string{}
+	for _, p := range f.Parameters {
+		params = append(params, p.String())
+	}
+
+	return "fn(" + strings.Join(params, ", ") + ") -> " + f.Return.String()
+}
+
+// union is only used by the signatures of the builtins accepting several types
+type union []Type
+
+func (u union) String() string {
+	types := []string{}
+	for _, t := range u {
+		types = append(types, t.String())
+	}
+
+	return strings.Join(types, " | ")
+}
+
+// builtin is the type of a builtin, its calls are checked against its signature
+type builtin struct {
+	name       string
+	parameters []Type
+	// required is the number of the parameters that must be passed, all of them if 0 and none if -1
+	required int
+	variadic bool
+	// result computes the return type from the types of the arguments
+	result func(args []Type) Type
+}
+
+func (b *builtin) String() string { return "builtin " + b.name }
+
+var anyHash = &Hash{Key: Any, Value: Any}
+
+var (
+	// sequence is the arrays and the strings, collection adds the hashes
+	sequence   = union{&Array{Any}, String}
+	collection = union{&Array{Any}, String, anyHash}
+	callback   = &Function{Return: Any}
+	// pattern is a regex or a string compiled to one
+	pattern = union{Regex, String}
+	number  = union{Int, Float}
+)
+
+// constants are the types of the predeclared values of the evaluator
+var constants = map[string]Type{
+	"PI": Float,
+	"E":  Float,
+}
+
+func returns(t Type) func(args []Type) Type {
+	return func(args []Type) Type { return t }
+}
+
+// element returns the element type of the array passed as the first argument
+func element(args []Type) Type {
+	if a, ok := args[0].(*Array); ok {
+		return a.Element
+	}
+	return Any
+}
+
+// same returns the type of the first argument
+func same(args []Type) Type {
+	return args[0]
+}
+
+// numeric is int or float if all the arguments are, the result may be any of them otherwise
+func numeric(args []Type) Type {
+	for _, t := range []Type{Int, Float} {
+		all := true
+		for _, arg := range args {
+			all = all && arg == t
+		}
+		if all {
+			return t
+		}
+	}
+	return Any
+}
+
+// summed is the result of `sum`, int or float if all the elements are, any otherwise
+func summed(args []Type) Type {
+	if t := element(args); t == Int || t == Float {
+		return t
+	}
+	return Any
+}
+
+// mapped is the result of `map`, a hash for a hash and an array otherwise
+func mapped(args []Type) Type {
+	if _, ok := args[0].(*Hash); ok {
+		return anyHash
+	}
+	return &Array{Any}
+}
+
+var builtins = map[string]*builtin{
+	"len":      {parameters: []Type{union{String, &Array{Any}, anyHash}}, result: returns(Int)},
+	"first":    {parameters: []Type{&Array{Any}}, result: element},
+	"last":     {parameters: []Type{&Array{Any}}, result: element},
+	"rest":     {parameters: []Type{&Array{Any}}, result: same},
+	"push":     {parameters: []Type{&Array{Any}, Any}, result: same},
+	"set":      {parameters: []Type{anyHash, Any, Any}, result: same},
+	"contains": {parameters: []Type{union{anyHash, String}, Any}, result: returns(Bool)},
+	"delete":   {parameters: []Type{anyHash, Any}, result: same},
+	"puts":     {variadic: true, result: returns(Null)},
+	"eval":     {parameters: []Type{String}, result: returns(Any)},
+	"load":     {parameters: []Type{String}, result: returns(Bool)},
+	"type":     {parameters: []Type{Any}, result: returns(String)},
+	"assert":   {parameters: []Type{Any, String}, required: 1, result: returns(Null)},
+	"assert_eq": {
+		parameters: []Type{Any, Any, String}, required: 2, result: returns(Null),
+	},
+	"assert_error":   {parameters: []Type{&Function{Return: Any}}, result: returns(String)},
+	"map":            {parameters: []Type{collection, callback}, result: mapped},
+	"filter":         {parameters: []Type{collection, callback}, result: same},
+	"reduce":         {parameters: []Type{sequence, callback, Any}, required: 2, result: returns(Any)},
+	"each":           {parameters: []Type{collection, callback}, result: returns(Null)},
+	"any":            {parameters: []Type{collection, callback}, result: returns(Bool)},
+	"all":            {parameters: []Type{collection, callback}, result: returns(Bool)},
+	"find":           {parameters: []Type{sequence, callback}, result: element},
+	"sort":           {parameters: []Type{sequence, callback}, required: 1, result: same},
+	"reverse":        {parameters: []Type{sequence}, result: same},
+	"zip":            {variadic: true, result: returns(&Array{&Array{Any}})},
+	"flatten":        {parameters: []Type{&Array{Any}, Int}, required: 1, result: returns(&Array{Any})},
+	"range":          {parameters: []Type{Int, Int, Int}, required: 1, result: returns(&Array{Int})},
+	"sum":            {parameters: []Type{&Array{Any}}, result: summed},
+	"min":            {variadic: true, result: returns(Any)},
+	"max":            {variadic: true, result: returns(Any)},
+	"split":          {parameters: []Type{String, String}, result: returns(&Array{String})},
+	"join":           {parameters: []Type{&Array{String}, String}, result: returns(String)},
+	"trim":           {parameters: []Type{String, String}, required: 1, result: returns(String)},
+	"trim_left":      {parameters: []Type{String, String}, required: 1, result: returns(String)},
+	"trim_right":     {parameters: []Type{String, String}, required: 1, result: returns(String)},
+	"replace":        {parameters: []Type{String, String, String, Int}, required: 3, result: returns(String)},
+	"starts_with":    {parameters: []Type{String, String}, result: returns(Bool)},
+	"ends_with":      {parameters: []Type{String, String}, result: returns(Bool)},
+	"index_of":       {parameters: []Type{String, String}, result: returns(Int)},
+	"upper":          {parameters: []Type{String}, result: returns(String)},
+	"lower":          {parameters: []Type{String}, result: returns(String)},
+	"repeat":         {parameters: []Type{String, Int}, result: returns(String)},
+	"substr":         {parameters: []Type{String, Int, Int}, required: 2, result: returns(String)},
+	"slice":          {parameters: []Type{sequence, Int, Int}, required: 2, result: same},
+	"chars":          {parameters: []Type{String}, result: returns(&Array{String})},
+	"ord":            {parameters: []Type{String}, result: returns(Int)},
+	"chr":            {parameters: []Type{Int}, result: returns(String)},
+	"parse_int":      {parameters: []Type{String, Int}, required: 1, result: returns(Int)},
+	"format":         {variadic: true, result: returns(String)},
+	"str":            {parameters: []Type{Any}, result: returns(String)},
+	"regex":          {parameters: []Type{String}, result: returns(Regex)},
+	"match":          {parameters: []Type{pattern, String}, result: returns(Bool)},
+	"find_all":       {parameters: []Type{pattern, String}, result: returns(&Array{&Array{Any}})},
+	"replace_re":     {parameters: []Type{pattern, String, union{String, callback}}, result: returns(String)},
+	"split_re":       {parameters: []Type{pattern, String}, result: returns(&Array{String})},
+	"json_parse":     {parameters: []Type{String}, result: returns(Any)},
+	"json_stringify": {parameters: []Type{Any, union{Int, String}}, required: 1, result: returns(String)},
+	"read_file":      {parameters: []Type{String}, result: returns(String)},
+	"read_lines":     {parameters: []Type{String}, result: returns(&Array{String})},
+	"write_file":     {parameters: []Type{String, String}, result: returns(Null)},
+	"append_file":    {parameters: []Type{String, String}, result: returns(Null)},
+	"list_dir":       {parameters: []Type{String}, result: returns(&Array{String})},
+	"exists":         {parameters: []Type{String}, result: returns(Bool)},
+	"mkdir":          {parameters: []Type{String}, result: returns(Null)},
+	"remove":         {parameters: []Type{String}, result: returns(Null)},
+	"path_join":      {variadic: true, result: returns(String)},
+	"basename":       {parameters: []Type{String}, result: returns(String)},
+	"dirname":        {parameters: []Type{String}, result: returns(String)},
+	"read_line":      {result: returns(Any)},
+	"read_all":       {result: returns(Any)},
+	"input":          {parameters: []Type{String}, required: -1, result: returns(Any)},
+	"abs":            {parameters: []Type{number}, result: numeric},
+	"pow":            {parameters: []Type{number, number}, result: returns(Any)},
+	"sqrt":           {parameters: []Type{number}, result: returns(Float)},
+	"floor":          {parameters: []Type{number}, result: returns(Int)},
+	"ceil":           {parameters: []Type{number}, result: returns(Int)},
+	"gcd":            {parameters: []Type{Int, Int}, result: returns(Int)},
+	"clamp":          {parameters: []Type{number, number, number}, result: numeric},
+	"sin":            {parameters: []Type{number}, result: returns(Float)},
+	"cos":            {parameters: []Type{number}, result: returns(Float)},
+	"tan":            {parameters: []Type{number}, result: returns(Float)},
+	"asin":           {parameters: []Type{number}, result: returns(Float)},
+	"acos":           {parameters: []Type{number}, result: returns(Float)},
+	"int":            {parameters: []Type{Int}, result: returns(Int)},
+	"bigint":         {parameters: []Type{union{Int, String}}, result: returns(Int)},
+	"rand_int":       {parameters: []Type{Int, Int}, result: returns(Int)},
+	"rand_float":     {result: returns(Float)},
+	"choice":         {parameters: []Type{sequence}, result: element},
+	"shuffle":        {parameters: []Type{sequence}, result: same},
+	"sample":         {parameters: []Type{sequence, Int}, result: same},
+	"seed":           {parameters: []Type{Int}, result: returns(Null)},
+	"now":            {result: returns(Time)},
+	"unix":           {parameters: []Type{Time}, required: -1, result: returns(Int)},
+	"sleep":          {parameters: []Type{Int}, result: returns(Null)},
+	"format_time":    {parameters: []Type{Time, String}, result: returns(String)},
+	"parse_time":     {parameters: []Type{String, String}, result: returns(Time)},
+	"duration":       {parameters: []Type{String}, result: returns(Int)},
+	"atan":           {parameters: []Type{number, number}, required: 1, result: returns(Float)},
+}
+
+func init() {
+	for name, b := range builtins {
+		b.name = name
+		if b.required == -1 {
+			b.required = 0
+		} else if b.required == 0 && !b.variadic {
+			b.required = len(b.parameters)
+		}
+	}
+}
+
+// Consistent reports whether a value of type a can be used where b is expected. It is the
+// consistency relation of the gradual typing: Any is consistent with every type.
+func Consistent(a, b Type) bool {
+	if a == Any || b == Any {
+		return true
+	}
+
+	if u, ok := b.(union); ok {
+		for _, t := range u {
+			if Consistent(a, t) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch a := a.(type) {
+	case Basic:
+		return a == b
+	case *Array:
+		other, ok := b.(*Array)
+		return ok && Consistent(a.Element, other.Element)
+	case *Hash:
+		other, ok := b.(*Hash)
+		return ok && Consistent(a.Key, other.Key) && Consistent(a.Value, other.Value)
+	case *Function:
+		if _, ok := b.(*builtin); ok {
+			return true
+		}
+
+		other, ok := b.(*Function)
+		if !ok {
+			return false
+		}
+
+		// A function type without parameters accepts any function, e.g. the argument of `assert_error`
+		if other.Parameters == nil {
+			return Consistent(a.Return, other.Return)
+		}
+
+		if len(a.Parameters) != len(other.Parameters) {
+			return false
+		}
+
+		for i := range a.Parameters {
+			if !Consistent(a.Parameters[i], other.Parameters[i]) {
+				return false
+			}
+		}
+
+		return Consistent(a.Return, other.Return)
+	case *builtin:
+		_, ok := b.(*Function)
+		return ok || a == b
+	}
+
+	return false
+}
+
+// join returns the type covering both types, nil stands for the blocks that always return
+func join(a, b Type) Type {
+	if a == nil {
+		return b
+	}
+
+	if b == nil || a.String() == b.String() {
+		return a
+	}
+
+	return Any
+}
+
+// fromAnnotation converts a type annotation, unknown names are reported by the checker
+func (c *checker) fromAnnotation(t ast.TypeExpression) Type {
+	switch t := t.(type) {
+	case nil:
+		return Any
+	case *ast.NamedType:
+		switch Basic(t.Name) {
+		case Int, Float, String, Bool, Null, Regex, Time, Any:
+			return Basic(t.Name)
+		}
+
+		c.errorf(t, "unknown type: %s", t.Name)
+		return Any
+	case *ast.ArrayType:
+		return &Array{Element: c.fromAnnotation(t.Element)}
+	case *ast.HashType:
+		key := c.fromAnnotation(t.Key)
+		if !hashable(key) {
+			c.errorf(t.Key, "unusable as hash key: %s", key)
+		}
+		return &Hash{Key: key, Value: c.fromAnnotation(t.Value)}
+	case *ast.FunctionType:
+		f := &Function{Parameters: []Type{}, Return: c.fromAnnotation(t.Return)}
+		for _, p := range t.Parameters {
+			f.Parameters = append(f.Parameters, c.fromAnnotation(p))
+		}
+		return f
+	}
+
+	return Any
+}
+
+func hashable(t Type) bool {
+	return t == Any || t == Int || t == String || t == Bool
+}